@@ -1,6 +1,7 @@
 package main
 
 import (
+	"flag"
 	"log/slog"
 	"os"
 	"venturo-core/configs"
@@ -8,31 +9,38 @@ import (
 )
 
 func main() {
+	flags := configs.BindFlags(flag.CommandLine)
+	flag.Parse()
+
 	slog.Info("Migration tool started")
 
-	config, err := configs.LoadConfig()
+	config, err := configs.LoadConfig(flags)
 	if err != nil {
 		slog.Error("Failed to load configuration", "error", err)
 		os.Exit(1)
 	}
 
-	database.ConnectDB(&config)
+	db, err := database.ConnectMigrationDB(&config)
+	if err != nil {
+		slog.Error("Failed to connect to database", "error", err)
+		os.Exit(1)
+	}
 
-	if len(os.Args) < 2 {
+	if flag.NArg() < 1 {
 		slog.Error("Please provide an argument: up, down, or fresh")
 		os.Exit(1)
 	}
 
-	command := os.Args[1]
+	command := flag.Arg(0)
 
 	switch command {
 	case "up":
-		database.MigrateUp()
+		database.MigrateUp(db)
 	case "down":
-		database.MigrateDown()
+		database.MigrateDown(db)
 	case "fresh":
-		database.Drop()
-		database.MigrateUp()
+		database.Drop(db)
+		database.MigrateUp(db)
 	default:
 		slog.Error("Unknown command", "command", command)
 		os.Exit(1)
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"venturo-core/configs"
+	"venturo-core/internal/database"
+	"venturo-core/internal/service"
+)
+
+// rebuild recomputes the sales aggregates from paid transactions and prints
+// every figure that differs from what is stored. Pass -apply to overwrite the
+// stored aggregates with the recomputed ones.
+func main() {
+	apply := flag.Bool("apply", false, "replace the stored aggregates with the recomputed ones")
+	flags := configs.BindFlags(flag.CommandLine)
+	flag.Parse()
+
+	slog.Info("Rebuild tool started", "apply", *apply)
+
+	config, err := configs.LoadConfig(flags)
+	if err != nil {
+		slog.Error("Failed to load configuration", "error", err)
+		os.Exit(1)
+	}
+
+	db, err := database.ConnectDB(&config)
+	if err != nil {
+		slog.Error("Failed to connect to database", "error", err)
+		os.Exit(1)
+	}
+
+	// A dry run reads from a replica when there is one.
+	replicas, err := database.ConnectReplicas(&config)
+	if err != nil {
+		slog.Error("Failed to set up read replicas", "error", err)
+		os.Exit(1)
+	}
+
+	result, err := service.NewSalesSummaryService(database.NewResolver(db, replicas)).Rebuild(context.Background(), *apply)
+	if err != nil {
+		slog.Error("Rebuild failed", "error", err)
+		os.Exit(1)
+	}
+
+	for _, m := range result.Mismatches {
+		fmt.Printf("%s\t%s\tstored=%d\trebuilt=%d\n", m.Scope, m.Metric, m.Stored, m.Rebuilt)
+	}
+
+	switch {
+	case len(result.Mismatches) == 0:
+		slog.Info("Stored aggregates match the transactions.")
+	case result.Applied:
+		slog.Info("Stored aggregates replaced.", "mismatches", len(result.Mismatches))
+	default:
+		slog.Warn("Stored aggregates differ; rerun with -apply to replace them.", "mismatches", len(result.Mismatches))
+		os.Exit(2)
+	}
+}
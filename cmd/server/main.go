@@ -1,10 +1,13 @@
 package main
 
 import (
+	"flag"
+	"fmt"
 	"log/slog"
 	"os"
 	"os/signal"
 	"syscall"
+	"venturo-core/configs"
 	_ "venturo-core/docs"
 	"venturo-core/internal/server"
 	"venturo-core/pkg/logger"
@@ -30,17 +33,26 @@ import (
 //	@name						Authorization
 //	@description				Type "Bearer" followed by a space and a JWT.
 func main() {
-	// Get the app and the shared WaitGroup from our server setup
-	app, wg := server.NewServer()
-	logger.InitLogger()
+	flags := configs.BindFlags(flag.CommandLine)
+	flag.Parse()
+
+	config, err := configs.LoadConfig(flags)
+	if err != nil {
+		slog.Error("Failed to load configuration", "error", err)
+		os.Exit(1)
+	}
+	logger.InitLogger(config.Log.Level, config.Log.Format)
+
+	// Get the app and its background workers from our server setup
+	app, workers := server.NewServer(&config)
 
 	// Create a channel to listen for OS signals
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
 
 	go func() {
-		slog.Info("Server is starting", "port", 3000)
-		if err := app.Listen(":3000"); err != nil {
+		slog.Info("Server is starting", "port", config.Server.Port)
+		if err := app.Listen(fmt.Sprintf(":%d", config.Server.Port)); err != nil {
 			slog.Error("Server failed to start", "error", err)
 		}
 	}()
@@ -48,6 +60,6 @@ func main() {
 	// Block until a signal is received
 	<-quit
 
-	// Trigger the graceful shutdown, passing the shared WaitGroup
-	server.GracefulShutdown(app, wg)
+	// Trigger the graceful shutdown, draining the background workers
+	server.GracefulShutdown(app, workers, &config)
 }
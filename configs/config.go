@@ -1,35 +1,94 @@
 package configs
 
-import (
-	"os"
+import "time"
 
-	"github.com/joho/godotenv"
-)
-
-// Config holds all configuration for the application
+// Config holds all configuration for the application.
+//
+// Every setting has a key, used in config files and as a command-line flag
+// (-server.port), and an environment variable. Tags give its default and
+// validation rules; see LoadConfig for how the sources are combined.
 type Config struct {
-	DBHost     string
-	DBPort     string
-	DBUser     string
-	DBPassword string
-	DBName     string
+	Server  ServerConfig  `key:"server"`
+	DB      DBConfig      `key:"db"`
+	Auth    AuthConfig    `key:"auth"`
+	Storage StorageConfig `key:"storage"`
+	Jobs    JobsConfig    `key:"jobs"`
+	Events  EventsConfig  `key:"events"`
+	Log     LogConfig     `key:"log"`
+}
 
-	JWTSecretKey string
+// ServerConfig configures the HTTP server.
+type ServerConfig struct {
+	Port            int           `key:"port" env:"SERVER_PORT" default:"3000" validate:"min=1,max=65535" usage:"HTTP port"`
+	ShutdownTimeout time.Duration `key:"shutdown_timeout" env:"SERVER_SHUTDOWN_TIMEOUT" default:"5s" validate:"gt=0" usage:"How long shutdown waits for open requests"`
 }
 
-// LoadConfig loads application configuration from .env file
-func LoadConfig() (config Config, err error) {
-	err = godotenv.Load()
-	if err != nil {
-		return
-	}
+// DBConfig configures the MySQL connection and its pool.
+type DBConfig struct {
+	Host     string `key:"host" env:"DB_HOST" validate:"required" usage:"MySQL host"`
+	Port     int    `key:"port" env:"DB_PORT" default:"3306" validate:"min=1,max=65535" usage:"MySQL port"`
+	User     string `key:"user" env:"DB_USER" validate:"required" usage:"MySQL user"`
+	Password string `key:"password" env:"DB_PASSWORD" usage:"MySQL password"`
+	Name     string `key:"name" env:"DB_NAME" validate:"required" usage:"Database name"`
+
+	MaxOpenConns    int           `key:"max_open_conns" env:"DB_MAX_OPEN_CONNS" default:"25" validate:"min=1" usage:"Most connections open at once"`
+	MaxIdleConns    int           `key:"max_idle_conns" env:"DB_MAX_IDLE_CONNS" default:"10" validate:"min=0,ltefield=MaxOpenConns" usage:"Most idle connections kept in the pool"`
+	ConnMaxLifetime time.Duration `key:"conn_max_lifetime" env:"DB_CONN_MAX_LIFETIME" default:"30m" validate:"gte=0" usage:"Connections are closed after this long; 0 keeps them"`
+	ConnMaxIdleTime time.Duration `key:"conn_max_idle_time" env:"DB_CONN_MAX_IDLE_TIME" default:"5m" validate:"gte=0" usage:"Idle connections are closed after this long; 0 keeps them"`
+
+	ConnectAttempts int           `key:"connect_attempts" env:"DB_CONNECT_ATTEMPTS" default:"10" validate:"min=1" usage:"Connection attempts at startup before giving up"`
+	ConnectBackoff  time.Duration `key:"connect_backoff" env:"DB_CONNECT_BACKOFF" default:"1s" validate:"gt=0" usage:"Wait after the first failed connection attempt; doubles up to 30s"`
+
+	// Read replicas serve reports and listings; they share the pool settings.
+	ReplicaDSNs []string `key:"replica_dsns" env:"DB_REPLICA_DSNS" usage:"Comma-separated read replica DSNs, e.g. user:pass@tcp(replica:3306)/venturo"`
+}
+
+// AuthConfig configures token signing.
+type AuthConfig struct {
+	JWTSecret      string        `key:"jwt_secret" env:"JWT_SECRET_KEY" validate:"required" usage:"Signs access tokens"`
+	AccessTokenTTL time.Duration `key:"access_token_ttl" env:"JWT_ACCESS_TOKEN_TTL" default:"72h" validate:"gt=0" usage:"Lifetime of access tokens"`
+}
 
-	config.DBHost = os.Getenv("DB_HOST")
-	config.DBPort = os.Getenv("DB_PORT")
-	config.DBUser = os.Getenv("DB_USER")
-	config.DBPassword = os.Getenv("DB_PASSWORD")
-	config.DBName = os.Getenv("DB_NAME")
+// StorageConfig selects where uploads are kept: local (default), s3 or gcs.
+type StorageConfig struct {
+	Driver      string `key:"driver" env:"STORAGE_DRIVER" default:"local" validate:"oneof=local s3 gcs" usage:"Upload storage: local, s3 or gcs"`
+	LocalPath   string `key:"local_path" env:"STORAGE_LOCAL_PATH" default:"./public/uploads" validate:"required" usage:"Upload directory of the local driver"`
+	StagingPath string `key:"staging_path" env:"STORAGE_STAGING_PATH" default:"./public/uploads" validate:"required" usage:"Directory holding uploads until their job runs; shared by every instance"`
+	SigningKey  string `key:"signing_key" env:"STORAGE_SIGNING_KEY" usage:"Signs private file URLs; defaults to auth.jwt_secret"`
 
-	config.JWTSecretKey = os.Getenv("JWT_SECRET_KEY")
-	return
+	// Object store settings used by the s3 and gcs drivers.
+	S3Endpoint  string `key:"s3_endpoint" env:"S3_ENDPOINT" validate:"required_if=Driver s3" usage:"Object store host[:port]; defaults to storage.googleapis.com for gcs"`
+	S3Region    string `key:"s3_region" env:"S3_REGION" usage:"Bucket region"`
+	S3Bucket    string `key:"s3_bucket" env:"S3_BUCKET" validate:"required_unless=Driver local" usage:"Bucket uploads are written to"`
+	S3AccessKey string `key:"s3_access_key" env:"S3_ACCESS_KEY" validate:"required_unless=Driver local" usage:"Access key (an HMAC key for gcs)"`
+	S3SecretKey string `key:"s3_secret_key" env:"S3_SECRET_KEY" validate:"required_unless=Driver local" usage:"Secret key"`
+	S3UseSSL    bool   `key:"s3_use_ssl" env:"S3_USE_SSL" default:"true" usage:"Use HTTPS for the object store"`
+	S3ACL       string `key:"s3_acl" env:"S3_ACL" usage:"Canned ACL applied to uploads"`
+	S3PublicURL string `key:"s3_public_url" env:"S3_PUBLIC_URL" validate:"omitempty,url" usage:"Base URL uploads are served from, e.g. a CDN"`
+}
+
+// JobsConfig tunes the background job queue.
+type JobsConfig struct {
+	Workers           int           `key:"workers" env:"JOBS_WORKERS" default:"4" validate:"min=1" usage:"Jobs processed at the same time"`
+	PollInterval      time.Duration `key:"poll_interval" env:"JOBS_POLL_INTERVAL" default:"1s" validate:"gt=0" usage:"Wait between polls when the queue is empty"`
+	VisibilityTimeout time.Duration `key:"visibility_timeout" env:"JOBS_VISIBILITY_TIMEOUT" default:"10m" validate:"gt=0" usage:"How long a job may run before it is retried elsewhere"`
+	DrainTimeout      time.Duration `key:"drain_timeout" env:"JOBS_DRAIN_TIMEOUT" default:"30s" validate:"gt=0" usage:"How long shutdown waits for running jobs"`
+}
+
+// EventsConfig configures domain event delivery.
+type EventsConfig struct {
+	LogSink bool `key:"log_sink" env:"EVENT_LOG_SINK" usage:"Log every domain event"`
+}
+
+// LogConfig configures the structured logger.
+type LogConfig struct {
+	Level  string `key:"level" env:"LOG_LEVEL" default:"debug" validate:"oneof=debug info warn error" usage:"Lowest level logged: debug, info, warn or error"`
+	Format string `key:"format" env:"LOG_FORMAT" default:"json" validate:"oneof=json text" usage:"Log format: json or text"`
+}
+
+// applyDerivedDefaults fills settings whose default depends on another one.
+func (c *Config) applyDerivedDefaults() {
+	if c.Storage.SigningKey == "" {
+		c.Storage.SigningKey = c.Auth.JWTSecret
+	}
 }
@@ -0,0 +1,329 @@
+package configs
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/go-playground/validator/v10"
+	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
+)
+
+// fileEnv names the config file when no -config flag is given.
+const fileEnv = "CONFIG_FILE"
+
+// ValidationError lists every setting that is missing or invalid.
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid configuration:\n  " + strings.Join(e.Problems, "\n  ")
+}
+
+// Flags holds the settings given on the command line.
+type Flags struct {
+	set  *flag.FlagSet
+	file *string
+}
+
+// BindFlags registers -config and one flag per setting on fs. Parse fs before
+// passing the result to LoadConfig.
+func BindFlags(fs *flag.FlagSet) *Flags {
+	flags := &Flags{set: fs, file: fs.String("config", "", "YAML or TOML config file (or $"+fileEnv+")")}
+	for _, s := range settings(&Config{}) {
+		usage := s.usage
+		if s.env != "" {
+			usage += " ($" + s.env + ")"
+		}
+		fs.String(s.key, "", usage)
+	}
+	return flags
+}
+
+// LoadConfig builds the configuration from, in increasing precedence: tag
+// defaults, the config file, a .env file, environment variables and flags.
+// The config file and .env are optional, and flags may be nil. Every missing
+// or invalid setting is reported in one *ValidationError.
+func LoadConfig(flags *Flags) (Config, error) {
+	var config Config
+	all := settings(&config)
+	byKey := make(map[string]setting, len(all))
+	for _, s := range all {
+		byKey[s.key] = s
+	}
+
+	var problems []string
+	set := func(s setting, raw, source string) {
+		if err := setValue(s.value, raw); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %q from %s is not a valid %s", s.name(), raw, source, s.kind()))
+		}
+	}
+
+	for _, s := range all {
+		if s.def != "" {
+			set(s, s.def, "the default")
+		}
+	}
+
+	path := os.Getenv(fileEnv)
+	if flags != nil && *flags.file != "" {
+		path = *flags.file
+	}
+	if path != "" {
+		values, err := readFile(path)
+		if err != nil {
+			return config, err
+		}
+		for _, key := range sortedKeys(values) {
+			s, ok := byKey[key]
+			if !ok {
+				problems = append(problems, fmt.Sprintf("%s: unknown key in %s", key, path))
+				continue
+			}
+			set(s, values[key], path)
+		}
+	}
+
+	// A missing .env is fine; containers pass real environment variables.
+	if err := godotenv.Load(); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return config, fmt.Errorf("could not read .env: %w", err)
+	}
+	// Empty variables count as unset, so FOO= in .env keeps the default.
+	for _, s := range all {
+		if raw := os.Getenv(s.env); s.env != "" && raw != "" {
+			set(s, raw, "$"+s.env)
+		}
+	}
+
+	if flags != nil {
+		flags.set.Visit(func(f *flag.Flag) {
+			if s, ok := byKey[f.Name]; ok {
+				set(s, f.Value.String(), "-"+f.Name)
+			}
+		})
+	}
+
+	config.applyDerivedDefaults()
+	problems = append(problems, validate(&config, all)...)
+	if len(problems) > 0 {
+		return config, &ValidationError{Problems: problems}
+	}
+	return config, nil
+}
+
+// setting is one configurable field of Config.
+type setting struct {
+	key       string // Dotted path, e.g. db.host
+	env       string
+	def       string
+	usage     string
+	namespace string // Struct path reported by the validator, e.g. Config.DB.Host
+	value     reflect.Value
+}
+
+// name identifies a setting in error messages.
+func (s setting) name() string {
+	if s.env == "" {
+		return s.key
+	}
+	return s.key + " ($" + s.env + ")"
+}
+
+// kind describes the expected value in error messages.
+func (s setting) kind() string {
+	switch s.value.Type() {
+	case durationType:
+		return "duration such as 30s or 5m"
+	case listType:
+		return "comma-separated list"
+	}
+	return s.value.Kind().String()
+}
+
+var (
+	durationType = reflect.TypeOf(time.Duration(0))
+	listType     = reflect.TypeOf([]string(nil))
+)
+
+// settings lists the fields of a Config, addressing the given instance.
+func settings(config *Config) []setting {
+	var all []setting
+	var walk func(v reflect.Value, keyPrefix, namespace string)
+	walk = func(v reflect.Value, keyPrefix, namespace string) {
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			key := keyPrefix + field.Tag.Get("key")
+			if field.Type.Kind() == reflect.Struct {
+				walk(v.Field(i), key+".", namespace+"."+field.Name)
+				continue
+			}
+			all = append(all, setting{
+				key:       key,
+				env:       field.Tag.Get("env"),
+				def:       field.Tag.Get("default"),
+				usage:     field.Tag.Get("usage"),
+				namespace: namespace + "." + field.Name,
+				value:     v.Field(i),
+			})
+		}
+	}
+	walk(reflect.ValueOf(config).Elem(), "", "Config")
+	return all
+}
+
+// setValue parses raw into a string, bool, int, time.Duration or
+// comma-separated []string field.
+func setValue(v reflect.Value, raw string) error {
+	raw = strings.TrimSpace(raw)
+	switch v.Type() {
+	case durationType:
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	case listType:
+		var list []string
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+		v.Set(reflect.ValueOf(list))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(raw)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int:
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(n))
+	default:
+		return fmt.Errorf("unsupported setting type %s", v.Type())
+	}
+	return nil
+}
+
+// readFile reads a YAML or TOML config file, chosen by its extension, into
+// dotted keys.
+func readFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read config file: %w", err)
+	}
+
+	tree := map[string]interface{}{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &tree)
+	case ".toml":
+		err = toml.Unmarshal(data, &tree)
+	default:
+		return nil, fmt.Errorf("config file %s must be .yaml, .yml or .toml", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not parse config file %s: %w", path, err)
+	}
+
+	values := map[string]string{}
+	flatten(tree, "", values)
+	return values, nil
+}
+
+// flatten turns nested sections into dotted keys and lists into
+// comma-separated values.
+func flatten(tree map[string]interface{}, prefix string, values map[string]string) {
+	for key, value := range tree {
+		switch value := value.(type) {
+		case map[string]interface{}:
+			flatten(value, prefix+key+".", values)
+		case []interface{}:
+			items := make([]string, len(value))
+			for i, item := range value {
+				items[i] = fmt.Sprint(item)
+			}
+			values[prefix+key] = strings.Join(items, ",")
+		default:
+			values[prefix+key] = fmt.Sprint(value)
+		}
+	}
+}
+
+func sortedKeys(values map[string]string) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// validate checks the validate tags and describes every failure by key and
+// environment variable.
+func validate(config *Config, all []setting) []string {
+	err := validator.New().Struct(config)
+	var validationErrors validator.ValidationErrors
+	if !errors.As(err, &validationErrors) {
+		if err != nil {
+			return []string{err.Error()}
+		}
+		return nil
+	}
+
+	byNamespace := make(map[string]setting, len(all))
+	for _, s := range all {
+		byNamespace[s.namespace] = s
+	}
+
+	problems := make([]string, 0, len(validationErrors))
+	for _, fieldErr := range validationErrors {
+		s := byNamespace[fieldErr.StructNamespace()]
+		var message string
+		switch fieldErr.Tag() {
+		case "required":
+			message = "is required"
+		case "required_if", "required_unless":
+			message = "is required for this storage driver"
+		case "oneof":
+			message = "must be one of: " + strings.ReplaceAll(fieldErr.Param(), " ", ", ")
+		case "min", "gte":
+			message = "must be at least " + fieldErr.Param()
+		case "max":
+			message = "must be at most " + fieldErr.Param()
+		case "gt":
+			message = "must be greater than " + fieldErr.Param()
+		case "ltefield":
+			// Param names a field of the same section.
+			section := strings.TrimSuffix(fieldErr.StructNamespace(), fieldErr.StructField())
+			message = "must not exceed " + byNamespace[section+fieldErr.Param()].key
+		case "url":
+			message = "must be a URL"
+		default:
+			message = "is invalid"
+		}
+		problems = append(problems, s.name()+" "+message)
+	}
+	return problems
+}
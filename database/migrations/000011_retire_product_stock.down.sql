@@ -0,0 +1,15 @@
+ALTER TABLE `products`
+ADD COLUMN `stock` SMALLINT NOT NULL DEFAULT 0 AFTER `price`;
+
+UPDATE products p
+JOIN (
+  SELECT item_id, SUM(quantity_change) AS qty
+  FROM inventory_ledgers
+  WHERE entry_type = 'opening'
+  GROUP BY item_id
+) l ON l.item_id = p.id
+SET p.stock = l.qty;
+
+DELETE FROM `inventory_ledgers` WHERE `entry_type` = 'opening';
+
+ALTER TABLE `inventory_ledgers` DROP COLUMN `entry_type`;
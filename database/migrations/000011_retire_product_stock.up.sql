@@ -0,0 +1,37 @@
+-- Aborts the migration with message unless ok holds.
+DROP PROCEDURE IF EXISTS `retire_stock_assert`;
+CREATE PROCEDURE `retire_stock_assert`(IN ok BOOLEAN, IN message VARCHAR(128))
+BEGIN
+    IF NOT ok THEN
+        SIGNAL SQLSTATE '45000' SET MESSAGE_TEXT = message;
+    END IF;
+END;
+
+-- Abort before changing anything when there is stock but no outlet to hold it.
+CALL `retire_stock_assert`(
+    NOT EXISTS (SELECT 1 FROM products WHERE stock <> 0) OR EXISTS (SELECT 1 FROM outlets),
+    'products have stock but there is no outlet to hold it'
+);
+
+ALTER TABLE `inventory_ledgers`
+ADD COLUMN `entry_type` VARCHAR(20) NOT NULL DEFAULT 'stock_in' AFTER `transaction_id`;
+
+UPDATE `inventory_ledgers` SET `entry_type` = 'sale' WHERE `transaction_id` IS NOT NULL;
+
+-- Existing product stock becomes an opening balance at the first outlet.
+INSERT INTO `inventory_ledgers` (id, item_id, outlet_id, transaction_id, entry_type, quantity_change)
+SELECT UUID(), p.id, o.id, NULL, 'opening', p.stock
+FROM products p
+JOIN (SELECT id FROM outlets ORDER BY name, id LIMIT 1) o
+WHERE p.stock <> 0;
+
+-- Drop the column only once every product's stock has its opening balance.
+CALL `retire_stock_assert`(
+    (SELECT COUNT(*) FROM products WHERE stock <> 0)
+        = (SELECT COUNT(*) FROM inventory_ledgers WHERE entry_type = 'opening'),
+    'not every product stock became an opening balance'
+);
+
+ALTER TABLE `products` DROP COLUMN `stock`;
+
+DROP PROCEDURE `retire_stock_assert`;
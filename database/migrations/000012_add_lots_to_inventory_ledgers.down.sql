@@ -0,0 +1,4 @@
+ALTER TABLE `inventory_ledgers`
+DROP INDEX `idx_inventory_ledgers_lot`,
+DROP COLUMN `lot_number`,
+DROP COLUMN `expiry_date`;
@@ -0,0 +1,4 @@
+ALTER TABLE `inventory_ledgers`
+ADD COLUMN `lot_number` VARCHAR(50) NOT NULL DEFAULT '' AFTER `entry_type`,
+ADD COLUMN `expiry_date` DATE NULL DEFAULT NULL AFTER `lot_number`,
+ADD INDEX `idx_inventory_ledgers_lot` (`item_id`, `outlet_id`, `expiry_date`, `lot_number`);
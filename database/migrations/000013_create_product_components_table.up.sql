@@ -0,0 +1,12 @@
+CREATE TABLE product_components (
+  id CHAR(36) PRIMARY KEY,
+  product_id CHAR(36) NOT NULL,
+  component_id CHAR(36) NOT NULL,
+  quantity INT NOT NULL,
+  unit VARCHAR(20) NOT NULL DEFAULT '',
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  UNIQUE KEY uq_product_components (product_id, component_id),
+  FOREIGN KEY (product_id) REFERENCES products(id) ON DELETE CASCADE,
+  FOREIGN KEY (component_id) REFERENCES products(id) ON DELETE RESTRICT
+);
@@ -0,0 +1,13 @@
+ALTER TABLE `inventory_ledgers`
+DROP COLUMN `unit`,
+DROP COLUMN `entered_quantity`;
+
+DROP TABLE IF EXISTS product_units;
+
+ALTER TABLE `products`
+DROP FOREIGN KEY `fk_products_base_unit`,
+DROP COLUMN `base_unit`,
+DROP COLUMN `purchase_unit`,
+DROP COLUMN `sales_unit`;
+
+DROP TABLE IF EXISTS units;
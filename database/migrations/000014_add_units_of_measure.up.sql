@@ -0,0 +1,38 @@
+CREATE TABLE units (
+  code VARCHAR(20) PRIMARY KEY,
+  name VARCHAR(100) NOT NULL
+);
+
+INSERT INTO units (code, name) VALUES
+  ('pcs', 'Pieces'),
+  ('box', 'Box'),
+  ('g', 'Gram'),
+  ('kg', 'Kilogram'),
+  ('ml', 'Millilitre'),
+  ('l', 'Litre'),
+  ('sack', 'Sack');
+
+ALTER TABLE `products`
+ADD COLUMN `base_unit` VARCHAR(20) NOT NULL DEFAULT 'pcs' AFTER `price`,
+ADD COLUMN `purchase_unit` VARCHAR(20) NULL DEFAULT NULL AFTER `base_unit`,
+ADD COLUMN `sales_unit` VARCHAR(20) NULL DEFAULT NULL AFTER `purchase_unit`,
+ADD CONSTRAINT `fk_products_base_unit` FOREIGN KEY (`base_unit`) REFERENCES units(code);
+
+CREATE TABLE product_units (
+  id CHAR(36) PRIMARY KEY,
+  product_id CHAR(36) NOT NULL,
+  unit_code VARCHAR(20) NOT NULL,
+  factor BIGINT NOT NULL, -- base units per one of this unit
+  UNIQUE KEY uq_product_units (product_id, unit_code),
+  FOREIGN KEY (product_id) REFERENCES products(id) ON DELETE CASCADE,
+  FOREIGN KEY (unit_code) REFERENCES units(code)
+);
+
+ALTER TABLE `inventory_ledgers`
+ADD COLUMN `unit` VARCHAR(20) NOT NULL DEFAULT '' AFTER `expiry_date`,
+ADD COLUMN `entered_quantity` DECIMAL(18,4) NOT NULL DEFAULT 0 AFTER `unit`;
+
+-- Every existing movement was entered in the product's base unit.
+UPDATE inventory_ledgers il
+JOIN products p ON p.id = il.item_id
+SET il.unit = p.base_unit, il.entered_quantity = il.quantity_change;
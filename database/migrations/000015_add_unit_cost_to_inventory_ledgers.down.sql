@@ -0,0 +1,3 @@
+ALTER TABLE `inventory_ledgers`
+DROP INDEX `idx_inventory_ledgers_created_at`,
+DROP COLUMN `unit_cost`;
@@ -0,0 +1,3 @@
+ALTER TABLE `inventory_ledgers`
+ADD COLUMN `unit_cost` DECIMAL(18,4) NULL DEFAULT NULL AFTER `quantity_change`,
+ADD INDEX `idx_inventory_ledgers_created_at` (`created_at`);
@@ -0,0 +1,6 @@
+ALTER TABLE `transactions`
+DROP INDEX `idx_transactions_paid_at`,
+DROP COLUMN `paid_at`;
+
+ALTER TABLE `outlets`
+DROP COLUMN `timezone`;
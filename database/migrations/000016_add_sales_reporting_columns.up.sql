@@ -0,0 +1,9 @@
+ALTER TABLE `outlets`
+ADD COLUMN `timezone` VARCHAR(64) NOT NULL DEFAULT 'UTC' AFTER `name`;
+
+ALTER TABLE `transactions`
+ADD COLUMN `paid_at` TIMESTAMP NULL DEFAULT NULL AFTER `is_paid`,
+ADD INDEX `idx_transactions_paid_at` (`is_paid`, `paid_at`);
+
+-- Before paid_at existed, the last update of a paid transaction was its payment.
+UPDATE `transactions` SET `paid_at` = `updated_at` WHERE `is_paid` = TRUE;
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS sales_daily_category_summaries;
+DROP TABLE IF EXISTS sales_daily_summaries;
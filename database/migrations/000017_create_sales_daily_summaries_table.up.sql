@@ -0,0 +1,21 @@
+CREATE TABLE sales_daily_summaries (
+  outlet_id CHAR(36) NOT NULL,
+  sales_date DATE NOT NULL,
+  revenue BIGINT NOT NULL DEFAULT 0,
+  paid_transactions BIGINT NOT NULL DEFAULT 0,
+  products_sold BIGINT NOT NULL DEFAULT 0,
+  updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (outlet_id, sales_date),
+  FOREIGN KEY (outlet_id) REFERENCES outlets(id) ON DELETE CASCADE
+);
+
+CREATE TABLE sales_daily_category_summaries (
+  outlet_id CHAR(36) NOT NULL,
+  sales_date DATE NOT NULL,
+  category TINYINT NOT NULL,
+  revenue BIGINT NOT NULL DEFAULT 0,
+  products_sold BIGINT NOT NULL DEFAULT 0,
+  updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (outlet_id, sales_date, category),
+  FOREIGN KEY (outlet_id) REFERENCES outlets(id) ON DELETE CASCADE
+);
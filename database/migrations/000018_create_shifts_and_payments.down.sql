@@ -0,0 +1,10 @@
+ALTER TABLE `transactions`
+DROP FOREIGN KEY `fk_transactions_shift_id`,
+DROP COLUMN `shift_id`;
+
+DROP TABLE IF EXISTS payments;
+DROP TABLE IF EXISTS shift_cash_movements;
+DROP TABLE IF EXISTS shifts;
+
+ALTER TABLE `outlets`
+DROP COLUMN `require_open_shift`;
@@ -0,0 +1,55 @@
+ALTER TABLE `outlets`
+ADD COLUMN `require_open_shift` BOOLEAN NOT NULL DEFAULT FALSE AFTER `timezone`;
+
+CREATE TABLE shifts (
+  id CHAR(36) PRIMARY KEY,
+  outlet_id CHAR(36) NOT NULL,
+  user_id CHAR(36) NOT NULL,
+  status VARCHAR(10) NOT NULL DEFAULT 'open',
+  opening_float BIGINT NOT NULL DEFAULT 0,
+  expected_cash BIGINT NULL,
+  counted_cash BIGINT NULL,
+  variance BIGINT NULL,
+  opening_note TEXT,
+  closing_note TEXT,
+  opened_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  closed_at TIMESTAMP NULL DEFAULT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  -- Only one open shift per cashier per outlet; closed shifts leave the key NULL.
+  open_key VARCHAR(73) AS (IF(status = 'open', CONCAT(outlet_id, ':', user_id), NULL)) STORED,
+  UNIQUE KEY uq_shifts_open_key (open_key),
+  -- MySQL forbids cascading actions on the base columns of a stored generated column.
+  FOREIGN KEY (outlet_id) REFERENCES outlets(id),
+  FOREIGN KEY (user_id) REFERENCES users(id)
+);
+
+CREATE TABLE shift_cash_movements (
+  id CHAR(36) PRIMARY KEY,
+  shift_id CHAR(36) NOT NULL,
+  user_id CHAR(36) NOT NULL,
+  type VARCHAR(10) NOT NULL,
+  amount BIGINT NOT NULL,
+  reason VARCHAR(255) NOT NULL DEFAULT '',
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  FOREIGN KEY (shift_id) REFERENCES shifts(id) ON DELETE CASCADE,
+  FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE TABLE payments (
+  id CHAR(36) PRIMARY KEY,
+  transaction_id CHAR(36) NOT NULL,
+  shift_id CHAR(36) NULL,
+  method VARCHAR(20) NOT NULL,
+  amount BIGINT NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  INDEX idx_payments_shift_id (shift_id),
+  FOREIGN KEY (transaction_id) REFERENCES transactions(id) ON DELETE CASCADE,
+  FOREIGN KEY (shift_id) REFERENCES shifts(id) ON DELETE SET NULL
+);
+
+ALTER TABLE `transactions`
+ADD COLUMN `shift_id` CHAR(36) NULL DEFAULT NULL AFTER `outlet_id`,
+ADD CONSTRAINT `fk_transactions_shift_id` FOREIGN KEY (`shift_id`) REFERENCES `shifts`(`id`) ON DELETE SET NULL;
@@ -0,0 +1,2 @@
+ALTER TABLE `outlets`
+DROP COLUMN `locale`;
@@ -0,0 +1,2 @@
+ALTER TABLE `outlets`
+ADD COLUMN `locale` VARCHAR(10) NOT NULL DEFAULT 'en-US' AFTER `timezone`;
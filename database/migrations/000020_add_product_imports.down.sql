@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS import_jobs;
+
+ALTER TABLE `products`
+DROP INDEX `uq_products_sku`,
+DROP COLUMN `sku`;
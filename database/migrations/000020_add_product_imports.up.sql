@@ -0,0 +1,24 @@
+ALTER TABLE `products`
+ADD COLUMN `sku` VARCHAR(64) NULL DEFAULT NULL AFTER `id`,
+ADD UNIQUE KEY `uq_products_sku` (`sku`);
+
+CREATE TABLE import_jobs (
+  id CHAR(36) PRIMARY KEY,
+  type VARCHAR(30) NOT NULL,
+  mode VARCHAR(10) NOT NULL,
+  status VARCHAR(20) NOT NULL DEFAULT 'pending',
+  outlet_id CHAR(36) NULL,
+  user_id CHAR(36) NOT NULL,
+  file_name VARCHAR(255) NOT NULL DEFAULT '',
+  total_rows INT NOT NULL DEFAULT 0,
+  processed_rows INT NOT NULL DEFAULT 0,
+  created_rows INT NOT NULL DEFAULT 0,
+  updated_rows INT NOT NULL DEFAULT 0,
+  row_errors JSON,
+  failure TEXT,
+  finished_at TIMESTAMP NULL DEFAULT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  FOREIGN KEY (outlet_id) REFERENCES outlets(id) ON DELETE SET NULL,
+  FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
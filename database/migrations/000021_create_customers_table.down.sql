@@ -0,0 +1,10 @@
+ALTER TABLE `transactions`
+DROP FOREIGN KEY `fk_transactions_customer`,
+DROP INDEX `idx_transactions_customer_paid`,
+DROP COLUMN `customer_id`;
+
+DROP TABLE IF EXISTS customers;
+
+UPDATE transaction_reports
+SET total_unique_customers = (SELECT COUNT(DISTINCT user_id) FROM transactions WHERE is_paid = TRUE)
+WHERE id = 1;
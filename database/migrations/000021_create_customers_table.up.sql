@@ -0,0 +1,21 @@
+CREATE TABLE customers (
+  id CHAR(36) PRIMARY KEY,
+  member_code VARCHAR(20) NOT NULL,
+  name VARCHAR(255) NOT NULL,
+  phone VARCHAR(30) NULL DEFAULT NULL,
+  email VARCHAR(255) NULL DEFAULT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  UNIQUE KEY `uq_customers_member_code` (`member_code`),
+  UNIQUE KEY `uq_customers_phone` (`phone`),
+  UNIQUE KEY `uq_customers_email` (`email`),
+  INDEX `idx_customers_name` (`name`)
+);
+
+ALTER TABLE `transactions`
+ADD COLUMN `customer_id` CHAR(36) NULL DEFAULT NULL AFTER `user_id`,
+ADD INDEX `idx_transactions_customer_paid` (`customer_id`, `paid_at`),
+ADD CONSTRAINT `fk_transactions_customer` FOREIGN KEY (`customer_id`) REFERENCES `customers`(`id`) ON DELETE SET NULL;
+
+-- Unique customers used to count cashiers; no sale has a customer yet.
+UPDATE transaction_reports SET total_unique_customers = 0 WHERE id = 1;
@@ -0,0 +1,5 @@
+ALTER TABLE `transactions`
+DROP COLUMN `refunded_at`;
+
+DROP TABLE IF EXISTS loyalty_ledgers;
+DROP TABLE IF EXISTS loyalty_rules;
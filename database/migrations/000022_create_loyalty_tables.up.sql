@@ -0,0 +1,30 @@
+-- Earn and redemption rules live in a single row with ID 1.
+CREATE TABLE loyalty_rules (
+  id TINYINT UNSIGNED PRIMARY KEY,
+  enabled BOOLEAN NOT NULL DEFAULT FALSE,
+  points_per_unit DECIMAL(18,6) NOT NULL DEFAULT 0,
+  category_multipliers JSON,
+  point_value BIGINT NOT NULL DEFAULT 0,
+  expiry_days INT NOT NULL DEFAULT 0,
+  updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+);
+
+INSERT INTO loyalty_rules (id) VALUES (1);
+
+CREATE TABLE loyalty_ledgers (
+  id CHAR(36) PRIMARY KEY,
+  customer_id CHAR(36) NOT NULL,
+  transaction_id CHAR(36) NULL,
+  source_entry_id CHAR(36) NULL,
+  entry_type VARCHAR(20) NOT NULL,
+  points BIGINT NOT NULL,
+  expires_at TIMESTAMP NULL DEFAULT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  INDEX `idx_loyalty_ledgers_customer` (`customer_id`, `created_at`),
+  INDEX `idx_loyalty_ledgers_transaction` (`transaction_id`),
+  FOREIGN KEY (customer_id) REFERENCES customers(id) ON DELETE CASCADE,
+  FOREIGN KEY (transaction_id) REFERENCES transactions(id) ON DELETE SET NULL
+);
+
+ALTER TABLE `transactions`
+ADD COLUMN `refunded_at` TIMESTAMP NULL DEFAULT NULL AFTER `paid_at`;
@@ -0,0 +1,7 @@
+ALTER TABLE `products`
+ADD COLUMN `image_key` VARCHAR(255) NOT NULL DEFAULT '' AFTER `image_url`;
+
+-- Uploaded images end with their object name; pending ones hold only the name.
+UPDATE `products`
+SET `image_key` = SUBSTRING_INDEX(`image_url`, '/', -1)
+WHERE `image_url` IS NOT NULL AND `image_url` <> '';
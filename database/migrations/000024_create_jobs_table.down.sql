@@ -0,0 +1,4 @@
+ALTER TABLE `import_jobs`
+DROP COLUMN `file_data`;
+
+DROP TABLE IF EXISTS jobs;
@@ -0,0 +1,22 @@
+CREATE TABLE jobs (
+  id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+  kind VARCHAR(100) NOT NULL,
+  payload JSON NOT NULL,
+  status VARCHAR(20) NOT NULL DEFAULT 'pending',
+  attempts INT NOT NULL DEFAULT 0,
+  max_attempts INT NOT NULL,
+  run_at TIMESTAMP(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3),
+  locked_until TIMESTAMP(3) NULL DEFAULT NULL,
+  locked_by VARCHAR(100) NOT NULL DEFAULT '',
+  last_error TEXT,
+  finished_at TIMESTAMP NULL DEFAULT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  INDEX idx_jobs_status_run_at (status, run_at),
+  INDEX idx_jobs_status_locked_until (status, locked_until)
+);
+
+-- Imports keep their file in the database so a retried job does not depend on
+-- the instance that received the upload.
+ALTER TABLE `import_jobs`
+ADD COLUMN `file_data` MEDIUMBLOB NULL AFTER `file_name`;
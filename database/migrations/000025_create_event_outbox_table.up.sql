@@ -0,0 +1,8 @@
+CREATE TABLE event_outbox (
+  id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+  type VARCHAR(100) NOT NULL,
+  payload JSON NOT NULL,
+  occurred_at TIMESTAMP(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3),
+  dispatched_at TIMESTAMP NULL DEFAULT NULL,
+  INDEX idx_event_outbox_dispatched_at (dispatched_at, id)
+);
@@ -0,0 +1,35 @@
+ALTER TABLE `products`
+ADD COLUMN `low_stock_threshold` INT NULL DEFAULT NULL AFTER `sales_unit`;
+
+CREATE TABLE webhook_subscriptions (
+  id CHAR(36) PRIMARY KEY,
+  outlet_id CHAR(36) NULL,
+  url VARCHAR(2048) NOT NULL,
+  secret VARCHAR(255) NOT NULL,
+  event_types JSON NOT NULL,
+  is_active BOOLEAN NOT NULL DEFAULT TRUE,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  FOREIGN KEY (outlet_id) REFERENCES outlets(id) ON DELETE CASCADE
+);
+
+CREATE TABLE webhook_deliveries (
+  id CHAR(36) PRIMARY KEY,
+  subscription_id CHAR(36) NOT NULL,
+  event_id BIGINT UNSIGNED NULL,
+  event_type VARCHAR(100) NOT NULL,
+  payload JSON NOT NULL,
+  status VARCHAR(20) NOT NULL DEFAULT 'pending',
+  attempts INT NOT NULL DEFAULT 0,
+  response_status INT NULL,
+  response_body TEXT,
+  last_error TEXT,
+  duration_ms INT NULL,
+  last_attempt_at TIMESTAMP NULL DEFAULT NULL,
+  delivered_at TIMESTAMP NULL DEFAULT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  UNIQUE KEY uq_webhook_deliveries_event (subscription_id, event_id),
+  INDEX idx_webhook_deliveries_created_at (subscription_id, created_at),
+  FOREIGN KEY (subscription_id) REFERENCES webhook_subscriptions(id) ON DELETE CASCADE
+);
@@ -0,0 +1,11 @@
+UPDATE `products` SET `image_status` = 'done' WHERE `image_status` = 'ready';
+UPDATE `products` SET `image_status` = 'uploading' WHERE `image_status` = 'processing';
+UPDATE `users` SET `image_status` = 'cloud' WHERE `image_status` = 'ready';
+UPDATE `users` SET `image_status` = 'local' WHERE `image_status` = 'processing';
+
+ALTER TABLE `users`
+DROP COLUMN `avatar_variants`,
+DROP COLUMN `avatar_key`;
+
+ALTER TABLE `products`
+DROP COLUMN `image_variants`;
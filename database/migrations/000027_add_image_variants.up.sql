@@ -0,0 +1,12 @@
+ALTER TABLE `products`
+ADD COLUMN `image_variants` JSON NULL AFTER `image_key`;
+
+ALTER TABLE `users`
+ADD COLUMN `avatar_key` VARCHAR(255) NOT NULL DEFAULT '' AFTER `avatar_url`,
+ADD COLUMN `avatar_variants` JSON NULL AFTER `avatar_key`;
+
+-- Image statuses now describe processing: processing, ready or failed.
+UPDATE `products` SET `image_status` = 'ready' WHERE `image_status` = 'done';
+UPDATE `products` SET `image_status` = 'processing' WHERE `image_status` = 'uploading';
+UPDATE `users` SET `image_status` = 'ready' WHERE `image_status` IN ('cloud', 'local');
+UPDATE `users` SET `image_status` = 'processing' WHERE `image_status` = 'uploading';
@@ -0,0 +1,21 @@
+CREATE TABLE uploads (
+  id CHAR(36) PRIMARY KEY,
+  user_id CHAR(36) NOT NULL,
+  purpose VARCHAR(30) NOT NULL,
+  target_id CHAR(36) NOT NULL,
+  mode VARCHAR(10) NOT NULL,
+  status VARCHAR(20) NOT NULL DEFAULT 'pending',
+  object_key VARCHAR(255) NOT NULL,
+  file_name VARCHAR(255) NOT NULL DEFAULT '',
+  content_type VARCHAR(100) NOT NULL,
+  size BIGINT NOT NULL,
+  checksum CHAR(64) NOT NULL,
+  received_bytes BIGINT NOT NULL DEFAULT 0,
+  failure TEXT,
+  expires_at TIMESTAMP NOT NULL,
+  completed_at TIMESTAMP NULL DEFAULT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  INDEX idx_uploads_user_id (user_id),
+  FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
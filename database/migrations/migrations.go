@@ -0,0 +1,10 @@
+// Package migrations embeds the SQL migrations so the binaries can apply and
+// check them wherever they run.
+package migrations
+
+import "embed"
+
+// FS holds every *.up.sql and *.down.sql file of this directory.
+//
+//go:embed *.sql
+var FS embed.FS
@@ -2,43 +2,60 @@ package storage
 
 import (
 	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"io"
+	"io/fs"
 	"log/slog"
-	"mime/multipart"
+	"net/url"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 )
 
+// SignedFilesPath is the route that serves the local driver's signed URLs.
+const SignedFilesPath = "/files/"
+
+// ErrInvalidSignature is returned for a signed URL that was tampered with or has expired.
+var ErrInvalidSignature = errors.New("invalid or expired signature")
+
 // LocalUploaderAdapter saves files to the local disk.
 type LocalUploaderAdapter struct {
-	basePath string
+	basePath   string
+	signingKey []byte
 }
 
-// NewLocalUploaderAdapter creates a new local uploader.
-func NewLocalUploaderAdapter(basePath string) *LocalUploaderAdapter {
+// NewLocalUploaderAdapter creates a new local uploader. signingKey signs the
+// URLs returned by SignedURL.
+func NewLocalUploaderAdapter(basePath, signingKey string) *LocalUploaderAdapter {
 	if err := os.MkdirAll(basePath, os.ModePerm); err != nil {
 		slog.Error("could not create local upload directory", "error", err)
 		os.Exit(1)
 	}
-	return &LocalUploaderAdapter{basePath: basePath}
+	return &LocalUploaderAdapter{basePath: basePath, signingKey: []byte(signingKey)}
 }
 
 // Upload implements the StorageAdapter interface.
-func (a *LocalUploaderAdapter) Upload(ctx context.Context, file *multipart.FileHeader, objectName string) (string, error) {
-	src, err := file.Open()
+func (a *LocalUploaderAdapter) Upload(ctx context.Context, name string, r io.Reader, size int64, contentType string) (string, error) {
+	dstPath, err := a.path(name)
 	if err != nil {
 		return "", err
 	}
-	defer src.Close()
+	if err := os.MkdirAll(filepath.Dir(dstPath), os.ModePerm); err != nil {
+		return "", err
+	}
 
-	dstPath := filepath.Join(a.basePath, objectName)
 	dst, err := os.Create(dstPath)
 	if err != nil {
 		return "", err
 	}
 	defer dst.Close()
 
-	if _, err = io.Copy(dst, src); err != nil {
+	if _, err = io.Copy(dst, r); err != nil {
 		return "", err
 	}
 
@@ -46,3 +63,107 @@ func (a *LocalUploaderAdapter) Upload(ctx context.Context, file *multipart.FileH
 	publicURL := "/" + filepath.ToSlash(dstPath)
 	return publicURL, nil
 }
+
+// Delete implements the StorageAdapter interface.
+func (a *LocalUploaderAdapter) Delete(ctx context.Context, name string) error {
+	path, err := a.path(name)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// Open implements the StorageAdapter interface.
+func (a *LocalUploaderAdapter) Open(ctx context.Context, name string) (io.ReadCloser, *ObjectInfo, error) {
+	info, err := a.Stat(ctx, name)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	path, _ := a.path(name)
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	return file, info, nil
+}
+
+// Stat implements the StorageAdapter interface.
+func (a *LocalUploaderAdapter) Stat(ctx context.Context, name string) (*ObjectInfo, error) {
+	path, err := a.path(name)
+	if err != nil {
+		return nil, err
+	}
+
+	stat, err := os.Stat(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, ErrObjectNotFound
+		}
+		return nil, err
+	}
+	if stat.IsDir() {
+		return nil, ErrObjectNotFound
+	}
+
+	return &ObjectInfo{
+		Name:        name,
+		Size:        stat.Size(),
+		ContentType: contentTypeByName(name),
+		ModTime:     stat.ModTime(),
+	}, nil
+}
+
+// SignedURL implements the StorageAdapter interface. The URL points at
+// SignedFilesPath and carries an HMAC-SHA256 of the name and expiry time.
+func (a *LocalUploaderAdapter) SignedURL(ctx context.Context, name string, expiry time.Duration) (string, error) {
+	if _, err := a.path(name); err != nil {
+		return "", err
+	}
+
+	expires := time.Now().Add(expiry).Unix()
+	query := url.Values{}
+	query.Set("expires", strconv.FormatInt(expires, 10))
+	query.Set("signature", a.sign(name, expires))
+
+	escaped := (&url.URL{Path: name}).EscapedPath()
+	return SignedFilesPath + escaped + "?" + query.Encode(), nil
+}
+
+// SignedUploadURL implements the StorageAdapter interface. The local driver
+// has no upload endpoint of its own, so uploads go through the app.
+func (a *LocalUploaderAdapter) SignedUploadURL(ctx context.Context, name string, expiry time.Duration) (string, error) {
+	return "", ErrDirectUploadUnsupported
+}
+
+// VerifySignedURL checks the expiry and signature of a URL made by SignedURL.
+func (a *LocalUploaderAdapter) VerifySignedURL(name, expires, signature string) error {
+	expiresAt, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || time.Now().Unix() > expiresAt {
+		return ErrInvalidSignature
+	}
+	if !hmac.Equal([]byte(signature), []byte(a.sign(name, expiresAt))) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// sign returns the hex HMAC of an object name and expiry time.
+func (a *LocalUploaderAdapter) sign(name string, expires int64) string {
+	mac := hmac.New(sha256.New, a.signingKey)
+	mac.Write([]byte(name + "\n" + strconv.FormatInt(expires, 10)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// path maps an object name to a file under basePath, rejecting names that
+// would escape it.
+func (a *LocalUploaderAdapter) path(name string) (string, error) {
+	cleaned := filepath.Clean(filepath.FromSlash(name))
+	if name == "" || filepath.IsAbs(cleaned) || cleaned == ".." || strings.HasPrefix(cleaned, ".."+string(filepath.Separator)) {
+		return "", errors.New("invalid object name " + strconv.Quote(name))
+	}
+	return filepath.Join(a.basePath, cleaned), nil
+}
@@ -0,0 +1,141 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// s3PartSize is the part size of multipart uploads. Files larger than one part
+// are uploaded in parts, several at a time.
+const s3PartSize = 16 << 20 // 16 MB
+
+// S3Adapter stores files in an S3-compatible object store such as AWS S3,
+// MinIO or Google Cloud Storage.
+type S3Adapter struct {
+	client    *minio.Client
+	bucket    string
+	acl       string
+	publicURL string
+}
+
+// NewS3Adapter connects to the object store and checks that the bucket exists.
+func NewS3Adapter(conf Config) (*S3Adapter, error) {
+	if conf.Endpoint == "" || conf.Bucket == "" {
+		return nil, errors.New("object storage needs an endpoint and a bucket")
+	}
+
+	client, err := minio.New(conf.Endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(conf.AccessKey, conf.SecretKey, ""),
+		Secure: conf.UseSSL,
+		Region: conf.Region,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	exists, err := client.BucketExists(ctx, conf.Bucket)
+	if err != nil {
+		return nil, fmt.Errorf("could not reach bucket %s: %w", conf.Bucket, err)
+	}
+	if !exists {
+		return nil, fmt.Errorf("bucket %s does not exist", conf.Bucket)
+	}
+
+	publicURL := strings.TrimRight(conf.PublicURL, "/")
+	if publicURL == "" {
+		publicURL = client.EndpointURL().String() + "/" + conf.Bucket
+	}
+
+	return &S3Adapter{client: client, bucket: conf.Bucket, acl: conf.ACL, publicURL: publicURL}, nil
+}
+
+// Upload implements the StorageAdapter interface.
+func (a *S3Adapter) Upload(ctx context.Context, name string, r io.Reader, size int64, contentType string) (string, error) {
+	opts := minio.PutObjectOptions{
+		ContentType: contentType,
+		PartSize:    s3PartSize,
+	}
+	if a.acl != "" {
+		opts.UserMetadata = map[string]string{"x-amz-acl": a.acl}
+	}
+
+	info, err := a.client.PutObject(ctx, a.bucket, name, r, size, opts)
+	if err != nil {
+		return "", err
+	}
+
+	slog.Info("Successfully uploaded file to object storage", "bucket", a.bucket, "key", info.Key, "size", info.Size)
+	return a.publicURL + "/" + name, nil
+}
+
+// Delete implements the StorageAdapter interface.
+func (a *S3Adapter) Delete(ctx context.Context, name string) error {
+	// S3 reports success for a missing key, so there is nothing to map.
+	return a.client.RemoveObject(ctx, a.bucket, name, minio.RemoveObjectOptions{})
+}
+
+// Open implements the StorageAdapter interface.
+func (a *S3Adapter) Open(ctx context.Context, name string) (io.ReadCloser, *ObjectInfo, error) {
+	object, err := a.client.GetObject(ctx, a.bucket, name, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, nil, s3Error(err)
+	}
+
+	// GetObject is lazy; Stat issues the request and surfaces a missing key.
+	stat, err := object.Stat()
+	if err != nil {
+		object.Close()
+		return nil, nil, s3Error(err)
+	}
+	return object, objectInfo(stat), nil
+}
+
+// Stat implements the StorageAdapter interface.
+func (a *S3Adapter) Stat(ctx context.Context, name string) (*ObjectInfo, error) {
+	stat, err := a.client.StatObject(ctx, a.bucket, name, minio.StatObjectOptions{})
+	if err != nil {
+		return nil, s3Error(err)
+	}
+	return objectInfo(stat), nil
+}
+
+// SignedURL implements the StorageAdapter interface with a presigned GET URL.
+func (a *S3Adapter) SignedURL(ctx context.Context, name string, expiry time.Duration) (string, error) {
+	signed, err := a.client.PresignedGetObject(ctx, a.bucket, name, expiry, nil)
+	if err != nil {
+		return "", err
+	}
+	return signed.String(), nil
+}
+
+// SignedUploadURL implements the StorageAdapter interface with a presigned PUT URL.
+func (a *S3Adapter) SignedUploadURL(ctx context.Context, name string, expiry time.Duration) (string, error) {
+	signed, err := a.client.PresignedPutObject(ctx, a.bucket, name, expiry)
+	if err != nil {
+		return "", err
+	}
+	return signed.String(), nil
+}
+
+// objectInfo converts minio's object metadata.
+func objectInfo(stat minio.ObjectInfo) *ObjectInfo {
+	return &ObjectInfo{Name: stat.Key, Size: stat.Size, ContentType: stat.ContentType, ModTime: stat.LastModified}
+}
+
+// s3Error maps a missing key to ErrObjectNotFound.
+func s3Error(err error) error {
+	if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+		return ErrObjectNotFound
+	}
+	return err
+}
@@ -0,0 +1,408 @@
+package storage
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const testBucket = "venturo"
+
+// fakeObject is an object held by fakeS3.
+type fakeObject struct {
+	data        []byte
+	contentType string
+	acl         string
+	modTime     time.Time
+}
+
+// fakeS3 is an in-process S3 endpoint serving one bucket with path-style
+// requests. It checks no signatures; minio-go signs, the fake stores.
+type fakeS3 struct {
+	mu      sync.Mutex
+	objects map[string]fakeObject
+}
+
+func newFakeS3(t *testing.T) (*fakeS3, *httptest.Server) {
+	t.Helper()
+	fake := &fakeS3{objects: map[string]fakeObject{}}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+	return fake, server
+}
+
+func (f *fakeS3) object(key string) (fakeObject, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	object, ok := f.objects[key]
+	return object, ok
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	bucket, key, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	if bucket != testBucket {
+		writeS3Error(w, r, http.StatusNotFound, "NoSuchBucket")
+		return
+	}
+	if key == "" {
+		// BucketExists
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPut:
+		data, err := readS3Body(r)
+		if err != nil {
+			writeS3Error(w, r, http.StatusBadRequest, "IncompleteBody")
+			return
+		}
+		f.mu.Lock()
+		f.objects[key] = fakeObject{
+			data:        data,
+			contentType: r.Header.Get("Content-Type"),
+			acl:         r.Header.Get("X-Amz-Acl"),
+			modTime:     time.Now().UTC().Truncate(time.Second),
+		}
+		f.mu.Unlock()
+		sum := md5.Sum(data)
+		w.Header().Set("ETag", `"`+hex.EncodeToString(sum[:])+`"`)
+		w.WriteHeader(http.StatusOK)
+	case http.MethodGet, http.MethodHead:
+		object, ok := f.object(key)
+		if !ok {
+			writeS3Error(w, r, http.StatusNotFound, "NoSuchKey")
+			return
+		}
+		sum := md5.Sum(object.data)
+		w.Header().Set("ETag", `"`+hex.EncodeToString(sum[:])+`"`)
+		w.Header().Set("Content-Type", object.contentType)
+		w.Header().Set("Content-Length", strconv.Itoa(len(object.data)))
+		w.Header().Set("Last-Modified", object.modTime.Format(http.TimeFormat))
+		w.WriteHeader(http.StatusOK)
+		if r.Method == http.MethodGet {
+			w.Write(object.data)
+		}
+	case http.MethodDelete:
+		f.mu.Lock()
+		delete(f.objects, key)
+		f.mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeS3Error(w, r, http.StatusMethodNotAllowed, "MethodNotAllowed")
+	}
+}
+
+// writeS3Error answers with an S3 error document.
+func writeS3Error(w http.ResponseWriter, r *http.Request, status int, code string) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	if r.Method != http.MethodHead {
+		fmt.Fprintf(w, `<?xml version="1.0" encoding="UTF-8"?><Error><Code>%s</Code><Message>%s</Message><Resource>%s</Resource></Error>`,
+			code, code, r.URL.Path)
+	}
+}
+
+// readS3Body reads a request body, decoding the aws-chunked encoding minio-go
+// uses for streaming signatures over plain HTTP.
+func readS3Body(r *http.Request) ([]byte, error) {
+	if !strings.HasPrefix(r.Header.Get("X-Amz-Content-Sha256"), "STREAMING-") {
+		return io.ReadAll(r.Body)
+	}
+
+	var data bytes.Buffer
+	body := bufio.NewReader(r.Body)
+	for {
+		line, err := body.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		sizeHex, _, _ := strings.Cut(strings.TrimSpace(line), ";")
+		size, err := strconv.ParseInt(sizeHex, 16, 64)
+		if err != nil {
+			return nil, err
+		}
+		if size == 0 {
+			return data.Bytes(), nil
+		}
+		if _, err := io.CopyN(&data, body, size); err != nil {
+			return nil, err
+		}
+		if _, err := body.Discard(2); err != nil { // CRLF after the chunk
+			return nil, err
+		}
+	}
+}
+
+// newTestS3Adapter connects an adapter to the fake.
+func newTestS3Adapter(t *testing.T, server *httptest.Server, acl, publicURL string) *S3Adapter {
+	t.Helper()
+	adapter, err := NewS3Adapter(Config{
+		Endpoint:  strings.TrimPrefix(server.URL, "http://"),
+		Region:    "us-east-1",
+		Bucket:    testBucket,
+		AccessKey: "test-access",
+		SecretKey: "test-secret",
+		ACL:       acl,
+		PublicURL: publicURL,
+	})
+	if err != nil {
+		t.Fatalf("NewS3Adapter: %v", err)
+	}
+	return adapter
+}
+
+func TestS3AdapterUpload(t *testing.T) {
+	fake, server := newFakeS3(t)
+	adapter := newTestS3Adapter(t, server, "public-read", "")
+
+	data := []byte("hello object storage")
+	url, err := adapter.Upload(context.Background(), "products/a.png", bytes.NewReader(data), int64(len(data)), "image/png")
+	if err != nil {
+		t.Fatalf("Upload: %v", err)
+	}
+	if want := server.URL + "/" + testBucket + "/products/a.png"; url != want {
+		t.Errorf("URL = %q, want %q", url, want)
+	}
+
+	object, ok := fake.object("products/a.png")
+	if !ok {
+		t.Fatal("object was not stored")
+	}
+	if !bytes.Equal(object.data, data) {
+		t.Errorf("stored %q, want %q", object.data, data)
+	}
+	if object.contentType != "image/png" {
+		t.Errorf("content type = %q, want image/png", object.contentType)
+	}
+	if object.acl != "public-read" {
+		t.Errorf("x-amz-acl = %q, want public-read", object.acl)
+	}
+}
+
+func TestS3AdapterUploadWithoutACL(t *testing.T) {
+	fake, server := newFakeS3(t)
+	adapter := newTestS3Adapter(t, server, "", "https://cdn.example.com/")
+
+	url, err := adapter.Upload(context.Background(), "a.txt", strings.NewReader("x"), 1, "text/plain")
+	if err != nil {
+		t.Fatalf("Upload: %v", err)
+	}
+	if url != "https://cdn.example.com/a.txt" {
+		t.Errorf("URL = %q, want the public URL", url)
+	}
+	if object, _ := fake.object("a.txt"); object.acl != "" {
+		t.Errorf("x-amz-acl = %q, want none", object.acl)
+	}
+}
+
+func TestS3AdapterOpenAndStat(t *testing.T) {
+	_, server := newFakeS3(t)
+	adapter := newTestS3Adapter(t, server, "", "")
+	ctx := context.Background()
+
+	data := []byte("report contents")
+	if _, err := adapter.Upload(ctx, "reports/r.csv", bytes.NewReader(data), int64(len(data)), "text/csv"); err != nil {
+		t.Fatalf("Upload: %v", err)
+	}
+
+	object, info, err := adapter.Open(ctx, "reports/r.csv")
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	got, err := io.ReadAll(object)
+	object.Close()
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Errorf("Open read %q, want %q", got, data)
+	}
+	if info.Name != "reports/r.csv" || info.Size != int64(len(data)) || info.ContentType != "text/csv" || info.ModTime.IsZero() {
+		t.Errorf("Open info = %+v", info)
+	}
+
+	info, err = adapter.Stat(ctx, "reports/r.csv")
+	if err != nil {
+		t.Fatalf("Stat: %v", err)
+	}
+	if info.Size != int64(len(data)) || info.ContentType != "text/csv" {
+		t.Errorf("Stat info = %+v", info)
+	}
+}
+
+func TestS3AdapterMissingKey(t *testing.T) {
+	_, server := newFakeS3(t)
+	adapter := newTestS3Adapter(t, server, "", "")
+	ctx := context.Background()
+
+	if _, _, err := adapter.Open(ctx, "missing.png"); !errors.Is(err, ErrObjectNotFound) {
+		t.Errorf("Open error = %v, want ErrObjectNotFound", err)
+	}
+	if _, err := adapter.Stat(ctx, "missing.png"); !errors.Is(err, ErrObjectNotFound) {
+		t.Errorf("Stat error = %v, want ErrObjectNotFound", err)
+	}
+}
+
+func TestS3AdapterDelete(t *testing.T) {
+	fake, server := newFakeS3(t)
+	adapter := newTestS3Adapter(t, server, "", "")
+	ctx := context.Background()
+
+	if _, err := adapter.Upload(ctx, "a.txt", strings.NewReader("x"), 1, "text/plain"); err != nil {
+		t.Fatalf("Upload: %v", err)
+	}
+	if err := adapter.Delete(ctx, "a.txt"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, ok := fake.object("a.txt"); ok {
+		t.Error("object still stored after Delete")
+	}
+	if _, err := adapter.Stat(ctx, "a.txt"); !errors.Is(err, ErrObjectNotFound) {
+		t.Errorf("Stat after Delete = %v, want ErrObjectNotFound", err)
+	}
+	// Deleting a missing key is not an error.
+	if err := adapter.Delete(ctx, "a.txt"); err != nil {
+		t.Errorf("Delete of a missing key: %v", err)
+	}
+}
+
+func TestS3AdapterSignedURLs(t *testing.T) {
+	fake, server := newFakeS3(t)
+	adapter := newTestS3Adapter(t, server, "", "")
+	ctx := context.Background()
+
+	uploadURL, err := adapter.SignedUploadURL(ctx, "uploads/u.bin", 15*time.Minute)
+	if err != nil {
+		t.Fatalf("SignedUploadURL: %v", err)
+	}
+	assertPresigned(t, uploadURL, "/"+testBucket+"/uploads/u.bin", "900")
+
+	// Clients PUT the file to the presigned URL as is.
+	req, _ := http.NewRequest(http.MethodPut, uploadURL, strings.NewReader("direct upload"))
+	req.Header.Set("Content-Type", "application/octet-stream")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("PUT to presigned URL: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("PUT to presigned URL: status %d", resp.StatusCode)
+	}
+	if object, ok := fake.object("uploads/u.bin"); !ok || string(object.data) != "direct upload" {
+		t.Fatalf("presigned PUT stored %q", object.data)
+	}
+
+	downloadURL, err := adapter.SignedURL(ctx, "uploads/u.bin", time.Hour)
+	if err != nil {
+		t.Fatalf("SignedURL: %v", err)
+	}
+	assertPresigned(t, downloadURL, "/"+testBucket+"/uploads/u.bin", "3600")
+
+	resp, err = http.Get(downloadURL)
+	if err != nil {
+		t.Fatalf("GET presigned URL: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != "direct upload" {
+		t.Errorf("GET presigned URL = %q", body)
+	}
+}
+
+// assertPresigned checks that raw is a SigV4 presigned URL for path.
+func assertPresigned(t *testing.T, raw, path, expires string) {
+	t.Helper()
+	u, err := url.Parse(raw)
+	if err != nil {
+		t.Fatalf("parse %q: %v", raw, err)
+	}
+	if u.Path != path {
+		t.Errorf("path = %q, want %q", u.Path, path)
+	}
+	query := u.Query()
+	if query.Get("X-Amz-Algorithm") != "AWS4-HMAC-SHA256" || query.Get("X-Amz-Signature") == "" {
+		t.Errorf("URL is not SigV4 presigned: %s", raw)
+	}
+	if query.Get("X-Amz-Expires") != expires {
+		t.Errorf("X-Amz-Expires = %q, want %s", query.Get("X-Amz-Expires"), expires)
+	}
+}
+
+// TestS3AdapterMinIO runs against a real MinIO, such as the one in
+// docker-compose.yml, when S3_TEST_ENDPOINT is set:
+//
+//	S3_TEST_ENDPOINT=localhost:9000 go test ./internal/adapter/storage -run MinIO
+//
+// The bucket (S3_TEST_BUCKET, default venturo) must exist; the credentials
+// default to MinIO's minioadmin.
+func TestS3AdapterMinIO(t *testing.T) {
+	endpoint := os.Getenv("S3_TEST_ENDPOINT")
+	if endpoint == "" {
+		t.Skip("S3_TEST_ENDPOINT not set")
+	}
+	env := func(key, fallback string) string {
+		if value := os.Getenv(key); value != "" {
+			return value
+		}
+		return fallback
+	}
+
+	adapter, err := NewS3Adapter(Config{
+		Endpoint:  endpoint,
+		Region:    "us-east-1",
+		Bucket:    env("S3_TEST_BUCKET", testBucket),
+		AccessKey: env("S3_TEST_ACCESS_KEY", "minioadmin"),
+		SecretKey: env("S3_TEST_SECRET_KEY", "minioadmin"),
+	})
+	if err != nil {
+		t.Fatalf("NewS3Adapter: %v", err)
+	}
+	ctx := context.Background()
+	key := fmt.Sprintf("storage-test/%d.txt", time.Now().UnixNano())
+	t.Cleanup(func() { adapter.Delete(ctx, key) })
+
+	data := []byte("minio round trip")
+	if _, err := adapter.Upload(ctx, key, bytes.NewReader(data), int64(len(data)), "text/plain"); err != nil {
+		t.Fatalf("Upload: %v", err)
+	}
+	info, err := adapter.Stat(ctx, key)
+	if err != nil || info.Size != int64(len(data)) {
+		t.Fatalf("Stat = %+v, %v", info, err)
+	}
+
+	signed, err := adapter.SignedURL(ctx, key, time.Minute)
+	if err != nil {
+		t.Fatalf("SignedURL: %v", err)
+	}
+	resp, err := http.Get(signed)
+	if err != nil {
+		t.Fatalf("GET presigned URL: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if !bytes.Equal(body, data) {
+		t.Errorf("GET presigned URL = %q (status %d)", body, resp.StatusCode)
+	}
+
+	if err := adapter.Delete(ctx, key); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, _, err := adapter.Open(ctx, key); !errors.Is(err, ErrObjectNotFound) {
+		t.Errorf("Open after Delete = %v, want ErrObjectNotFound", err)
+	}
+}
@@ -2,28 +2,107 @@ package storage
 
 import (
 	"context"
-	"log/slog"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
 	"mime/multipart"
+	"path/filepath"
+	"time"
 )
 
+// ErrObjectNotFound is returned when an object does not exist in the store.
+var ErrObjectNotFound = errors.New("object not found")
+
+// ErrDirectUploadUnsupported is returned by SignedUploadURL when clients cannot
+// upload to the store directly.
+var ErrDirectUploadUnsupported = errors.New("storage does not accept direct uploads")
+
+// ObjectInfo describes a stored object.
+type ObjectInfo struct {
+	Name        string
+	Size        int64
+	ContentType string
+	ModTime     time.Time
+}
+
 // StorageAdapter defines the interface for any cloud storage service.
 type StorageAdapter interface {
-	Upload(ctx context.Context, file *multipart.FileHeader, fileName string) (string, error)
+	// Upload stores size bytes from r under name and returns its public URL.
+	Upload(ctx context.Context, name string, r io.Reader, size int64, contentType string) (string, error)
+	// Delete removes an object. Deleting a missing object is not an error.
+	Delete(ctx context.Context, name string) error
+	// Open returns the object's content; the caller must close it.
+	Open(ctx context.Context, name string) (io.ReadCloser, *ObjectInfo, error)
+	Stat(ctx context.Context, name string) (*ObjectInfo, error)
+	// SignedURL returns a URL that grants read access to a private object until expiry.
+	SignedURL(ctx context.Context, name string, expiry time.Duration) (string, error)
+	// SignedUploadURL returns a URL a client can PUT the object to until expiry.
+	SignedUploadURL(ctx context.Context, name string, expiry time.Duration) (string, error)
+}
+
+// ContentType returns the type the client sent for an uploaded file, falling
+// back to its extension.
+func ContentType(file *multipart.FileHeader) string {
+	if ct := file.Header.Get("Content-Type"); ct != "" && ct != "application/octet-stream" {
+		return ct
+	}
+	return contentTypeByName(file.Filename)
 }
 
-// GCSAdapter is the placeholder implementation for Google Cloud Storage.
-type GCSAdapter struct {
-	bucketName string
+// contentTypeByName guesses a content type from a file extension.
+func contentTypeByName(name string) string {
+	if ct := mime.TypeByExtension(filepath.Ext(name)); ct != "" {
+		return ct
+	}
+	return "application/octet-stream"
 }
 
-// NewGCSAdapter creates a new instance of our GCS placeholder.
-func NewGCSAdapter(bucketName string) *GCSAdapter {
-	return &GCSAdapter{bucketName: bucketName}
+// Storage drivers selectable in Config.
+const (
+	DriverLocal = "local"
+	DriverS3    = "s3"
+	DriverGCS   = "gcs"
+)
+
+// gcsEndpoint is the S3-compatible XML API of Google Cloud Storage.
+const gcsEndpoint = "storage.googleapis.com"
+
+// Config selects and configures the storage adapter.
+type Config struct {
+	Driver     string // local (default), s3 or gcs
+	LocalPath  string // Directory used by the local driver
+	SigningKey string // Signs the local driver's private URLs
+
+	// Object store settings used by the s3 and gcs drivers. For gcs, the keys
+	// are HMAC keys of a service account.
+	Endpoint  string // host[:port]; defaults to storage.googleapis.com for gcs
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	UseSSL    bool
+	ACL       string // Canned ACL applied to uploads, e.g. public-read
+	PublicURL string // Base URL objects are served from; defaults to the endpoint and bucket
 }
 
-// Upload simulates uploading a file to GCS.
-func (a *GCSAdapter) Upload(ctx context.Context, file *multipart.FileHeader, fileName string) (string, error) {
-	slog.Info("Simulating upload of file to GCS bucket", "file", fileName, "bucket", a.bucketName)
-	dummyURL := "https://storage.googleapis.com/" + a.bucketName + "/" + fileName
-	return dummyURL, nil
+// New creates the storage adapter selected by conf.Driver.
+func New(conf Config) (StorageAdapter, error) {
+	switch conf.Driver {
+	case "", DriverLocal:
+		if conf.SigningKey == "" {
+			return nil, errors.New("local storage needs a signing key")
+		}
+		return NewLocalUploaderAdapter(conf.LocalPath, conf.SigningKey), nil
+	case DriverS3:
+		return NewS3Adapter(conf)
+	case DriverGCS:
+		if conf.Endpoint == "" {
+			conf.Endpoint = gcsEndpoint
+			conf.UseSSL = true
+		}
+		return NewS3Adapter(conf)
+	default:
+		return nil, fmt.Errorf("unknown storage driver %q", conf.Driver)
+	}
 }
@@ -1,55 +1,195 @@
 package database
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"log/slog"
 	"os"
+	"time"
 	"venturo-core/configs"
+	"venturo-core/database/migrations"
 
+	mysqlDriver "github.com/go-sql-driver/mysql"
 	"github.com/golang-migrate/migrate/v4"
 	mysqlMigrate "github.com/golang-migrate/migrate/v4/database/mysql"
-	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
 
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
 )
 
-var DB *gorm.DB
+// maxConnectBackoff caps the wait between connection attempts.
+const maxConnectBackoff = 30 * time.Second
 
-// ConnectDB connects to the database using the provided configuration.
-func ConnectDB(config *configs.Config) {
-	var err error
+// migrationsTable is where golang-migrate records the schema version.
+var migrationsTable = mysqlMigrate.DefaultMigrationsTable
+
+// ConnectDB connects to the database using the provided configuration and
+// sizes its connection pool. A database that is still starting up is retried
+// with exponential backoff, up to DB.ConnectAttempts times.
+func ConnectDB(config *configs.Config) (*gorm.DB, error) {
+	return connect(config, primaryDSN(config, false))
+}
+
+// ConnectMigrationDB connects like ConnectDB for cmd/migrate. Its connection
+// allows several statements per query so a migration file can hold a schema
+// change plus its backfill; the application's connections never do.
+func ConnectMigrationDB(config *configs.Config) (*gorm.DB, error) {
+	return connect(config, primaryDSN(config, true))
+}
 
-	credentials := config.DBUser
-	if config.DBPassword != "" {
-		credentials = fmt.Sprintf("%s:%s", config.DBUser, config.DBPassword)
+// primaryDSN builds the DSN of the primary.
+func primaryDSN(config *configs.Config, multiStatements bool) string {
+	credentials := config.DB.User
+	if config.DB.Password != "" {
+		credentials = fmt.Sprintf("%s:%s", config.DB.User, config.DB.Password)
 	}
 
-	dsn := fmt.Sprintf("%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=Local",
+	dsn := fmt.Sprintf("%s@tcp(%s:%d)/%s?charset=utf8mb4&parseTime=True&loc=Local",
 		credentials,
-		config.DBHost,
-		config.DBPort,
-		config.DBName,
+		config.DB.Host,
+		config.DB.Port,
+		config.DB.Name,
 	)
+	if multiStatements {
+		dsn += "&multiStatements=true"
+	}
+	return dsn
+}
 
-	DB, err = gorm.Open(mysql.Open(dsn), &gorm.Config{})
-	if err != nil {
-		slog.Error("Failed to connect to database", "error", err)
-		os.Exit(1)
+// connect opens dsn, retrying with backoff, and sizes the pool.
+func connect(config *configs.Config, dsn string) (*gorm.DB, error) {
+	var db *gorm.DB
+	var err error
+	backoff := config.DB.ConnectBackoff
+	for attempt := 1; ; attempt++ {
+		// Opening pings the server, so an unreachable database fails here.
+		db, err = gorm.Open(mysql.Open(dsn), &gorm.Config{})
+		if err == nil {
+			break
+		}
+		if attempt >= config.DB.ConnectAttempts {
+			return nil, fmt.Errorf("could not connect to database after %d attempts: %w", attempt, err)
+		}
+		slog.Warn("Database not reachable, retrying", "attempt", attempt, "retry_in", backoff, "error", err)
+		time.Sleep(backoff)
+		backoff = min(backoff*2, maxConnectBackoff)
+	}
+
+	if err := configurePool(db, config); err != nil {
+		return nil, err
 	}
 
 	slog.Info("Database connection successful.")
+	return db, nil
+}
+
+// ConnectReplicas opens the read replicas listed in DB.ReplicaDSNs. They
+// connect lazily, so a replica that is down does not hold up startup; queries
+// routed to it fail until it is back.
+func ConnectReplicas(config *configs.Config) ([]*gorm.DB, error) {
+	replicas := make([]*gorm.DB, 0, len(config.DB.ReplicaDSNs))
+	for i, raw := range config.DB.ReplicaDSNs {
+		dsn, err := mysqlDriver.ParseDSN(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid replica DSN #%d: %w", i+1, err)
+		}
+		// Scan rows the same way as on the primary, one statement per query.
+		dsn.MultiStatements = false
+		dsn.ParseTime = true
+		dsn.Loc = time.Local
+		if dsn.Params == nil {
+			dsn.Params = map[string]string{}
+		}
+		if _, ok := dsn.Params["charset"]; !ok {
+			dsn.Params["charset"] = "utf8mb4"
+		}
+
+		// Skipping the version query and the ping keeps Open offline. The
+		// version only tunes DDL and locking, which replicas never run.
+		dialector := mysql.New(mysql.Config{DSN: dsn.FormatDSN(), SkipInitializeWithVersion: true})
+		db, err := gorm.Open(dialector, &gorm.Config{DisableAutomaticPing: true})
+		if err != nil {
+			return nil, fmt.Errorf("could not open replica #%d: %w", i+1, err)
+		}
+		if err := configurePool(db, config); err != nil {
+			return nil, err
+		}
+		replicas = append(replicas, db)
+	}
+
+	if len(replicas) > 0 {
+		slog.Info("Read replicas configured.", "replicas", len(replicas))
+	}
+	return replicas, nil
+}
+
+// configurePool applies the pool settings to a connection.
+func configurePool(db *gorm.DB, config *configs.Config) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	sqlDB.SetMaxOpenConns(config.DB.MaxOpenConns)
+	sqlDB.SetMaxIdleConns(config.DB.MaxIdleConns)
+	sqlDB.SetConnMaxLifetime(config.DB.ConnMaxLifetime)
+	sqlDB.SetConnMaxIdleTime(config.DB.ConnMaxIdleTime)
+	return nil
+}
+
+// LatestMigration returns the version of the newest embedded migration, the
+// schema version this build expects.
+func LatestMigration() (uint, error) {
+	source, err := iofs.New(migrations.FS, ".")
+	if err != nil {
+		return 0, err
+	}
+	defer source.Close()
+
+	version, err := source.First()
+	if err != nil {
+		return 0, err
+	}
+	for {
+		next, err := source.Next(version)
+		if errors.Is(err, os.ErrNotExist) {
+			return version, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+		version = next
+	}
+}
+
+// SchemaVersion reads the applied schema version. dirty reports a migration
+// that failed halfway and needs fixing by hand. A database that was never
+// migrated is at version 0.
+func SchemaVersion(ctx context.Context, db *gorm.DB) (version uint, dirty bool, err error) {
+	if !db.WithContext(ctx).Migrator().HasTable(migrationsTable) {
+		return 0, false, nil
+	}
+
+	var row struct {
+		Version uint
+		Dirty   bool
+	}
+	result := db.WithContext(ctx).Table(migrationsTable).Select("version", "dirty").Limit(1).Scan(&row)
+	if result.Error != nil {
+		return 0, false, result.Error
+	}
+	return row.Version, row.Dirty, nil
 }
 
 // newMigrate creates a new migrate instance.
-func newMigrate() (*migrate.Migrate, error) {
-	if DB == nil {
+func newMigrate(db *gorm.DB) (*migrate.Migrate, error) {
+	if db == nil {
 		return nil, errors.New("database connection is not initialized")
 	}
 
 	// Call the DB() method to get the underlying *sql.DB instance
-	sqlDB, err := DB.DB()
+	sqlDB, err := db.DB()
 	if err != nil {
 		return nil, err
 	}
@@ -58,12 +198,16 @@ func newMigrate() (*migrate.Migrate, error) {
 	if err != nil {
 		return nil, err
 	}
-	return migrate.NewWithDatabaseInstance("file://database/migrations", "mysql", driver)
+	source, err := iofs.New(migrations.FS, ".")
+	if err != nil {
+		return nil, err
+	}
+	return migrate.NewWithInstance("iofs", source, "mysql", driver)
 }
 
 // MigrateUp applies all available up migrations.
-func MigrateUp() {
-	m, err := newMigrate()
+func MigrateUp(db *gorm.DB) {
+	m, err := newMigrate(db)
 	if err != nil {
 		slog.Error("Migration failed", "error", err)
 		os.Exit(1)
@@ -76,8 +220,8 @@ func MigrateUp() {
 }
 
 // MigrateDown rolls back the last applied migration.
-func MigrateDown() {
-	m, err := newMigrate()
+func MigrateDown(db *gorm.DB) {
+	m, err := newMigrate(db)
 	if err != nil {
 		slog.Error("Migration failed", "error", err)
 		os.Exit(1)
@@ -90,8 +234,8 @@ func MigrateDown() {
 }
 
 // Drop deletes everything in the database.
-func Drop() {
-	m, err := newMigrate()
+func Drop(db *gorm.DB) {
+	m, err := newMigrate(db)
 	if err != nil {
 		slog.Error("Migration failed", "error", err)
 		os.Exit(1)
@@ -0,0 +1,71 @@
+package database
+
+import (
+	"context"
+	"sync/atomic"
+
+	"gorm.io/gorm"
+)
+
+// PrimaryKey is the context key that sends a request's reads to the primary,
+// for read-after-write paths that cannot tolerate replica lag. Set it with
+// fiber's Locals, which handlers' c.Context() exposes, or with WithPrimary.
+type PrimaryKey struct{}
+
+// WithPrimary returns a context whose reads go to the primary.
+func WithPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, PrimaryKey{}, true)
+}
+
+// Detach returns a background context that keeps ctx's choice of database,
+// for work that outlives the request, such as a streamed export.
+func Detach(ctx context.Context) context.Context {
+	if primaryForced(ctx) {
+		return WithPrimary(context.Background())
+	}
+	return context.Background()
+}
+
+// primaryForced reports whether ctx asks for the primary.
+func primaryForced(ctx context.Context) bool {
+	forced, _ := ctx.Value(PrimaryKey{}).(bool)
+	return forced
+}
+
+// Resolver routes read-only queries to the read replicas, in turn, and
+// everything else to the primary. Without replicas every query goes to the
+// primary.
+//
+// Replicas lag behind the primary, so only queries that can show slightly
+// stale data should use Reader: reports and listings. Writes, transactions
+// that write, and reads whose result feeds a write stay on Primary.
+type Resolver struct {
+	primary  *gorm.DB
+	replicas []*gorm.DB
+	next     atomic.Uint64
+}
+
+// NewResolver creates a resolver over a primary and its replicas.
+func NewResolver(primary *gorm.DB, replicas []*gorm.DB) *Resolver {
+	return &Resolver{primary: primary, replicas: replicas}
+}
+
+// Primary returns the primary connection.
+func (r *Resolver) Primary() *gorm.DB {
+	return r.primary
+}
+
+// Replicas returns the replica connections.
+func (r *Resolver) Replicas() []*gorm.DB {
+	return r.replicas
+}
+
+// Reader returns a session for read-only queries bound to ctx: the next
+// replica, or the primary when there are none or ctx asks for it.
+func (r *Resolver) Reader(ctx context.Context) *gorm.DB {
+	if len(r.replicas) == 0 || primaryForced(ctx) {
+		return r.primary.WithContext(ctx)
+	}
+	i := r.next.Add(1) % uint64(len(r.replicas))
+	return r.replicas[i].WithContext(ctx)
+}
@@ -0,0 +1,228 @@
+package http
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"venturo-core/internal/service"
+	"venturo-core/pkg/response"
+	"venturo-core/pkg/validator"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type CustomerHandler struct {
+	customerService *service.CustomerService
+}
+
+// NewCustomerHandler creates a new customer handler.
+func NewCustomerHandler(s *service.CustomerService) *CustomerHandler {
+	return &CustomerHandler{customerService: s}
+}
+
+// CreateCustomerPayload defines the expected JSON for registering a member.
+type CreateCustomerPayload struct {
+	MemberCode string `json:"member_code" validate:"max=20"` // Generated when empty
+	Name       string `json:"name" validate:"required,max=255"`
+	Phone      string `json:"phone" validate:"max=30"`
+	Email      string `json:"email" validate:"omitempty,email,max=255"`
+}
+
+// UpdateCustomerPayload defines the expected JSON for changing a member; omitted fields are kept.
+type UpdateCustomerPayload struct {
+	MemberCode *string `json:"member_code" validate:"omitempty,max=20"`
+	Name       *string `json:"name" validate:"omitempty,min=1,max=255"`
+	Phone      *string `json:"phone" validate:"omitempty,max=30"`        // Empty clears it
+	Email      *string `json:"email" validate:"omitempty,email,max=255"` // Empty clears it
+}
+
+// customerError maps customer service errors to HTTP responses.
+func customerError(c *fiber.Ctx, err error) error {
+	switch {
+	case strings.Contains(err.Error(), "not found"):
+		return response.Error(c, fiber.StatusNotFound, err)
+	case errors.Is(err, service.ErrCustomerExists):
+		return response.Error(c, fiber.StatusConflict, err)
+	default:
+		return response.Error(c, fiber.StatusInternalServerError, err)
+	}
+}
+
+// CreateCustomer handles the POST /api/v1/customers request.
+// @Summary      Register a customer
+// @Description  Adds a member to the customer directory. A member code is generated when none is given.
+// @Tags         Customers
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        payload  body      CreateCustomerPayload  true  "Customer details"
+// @Success      201  {object}  response.ApiResponse{data=model.Customer} "Successfully registered customer"
+// @Failure      400  {object}  response.ApiResponse "Bad Request"
+// @Failure      401  {object}  response.ApiResponse "Unauthorized"
+// @Failure      409  {object}  response.ApiResponse "Member code, phone or email already registered"
+// @Router       /customers [post]
+func (h *CustomerHandler) CreateCustomer(c *fiber.Ctx) error {
+	payload := new(CreateCustomerPayload)
+	if err := c.BodyParser(payload); err != nil {
+		return response.Error(c, fiber.StatusBadRequest, errors.New("cannot parse JSON"))
+	}
+	if errs := validator.ValidateStruct(payload); errs != nil {
+		return response.ValidationError(c, errs)
+	}
+
+	customer, err := h.customerService.CreateCustomer(c.Context(), service.CustomerInput{
+		MemberCode: &payload.MemberCode,
+		Name:       &payload.Name,
+		Phone:      &payload.Phone,
+		Email:      &payload.Email,
+	})
+	if err != nil {
+		return customerError(c, err)
+	}
+
+	return response.Success(c, fiber.StatusCreated, customer)
+}
+
+// GetCustomers handles the GET /api/v1/customers request.
+// @Summary      List customers
+// @Description  Retrieves a paginated list of members, optionally searching the start of their name, member code, phone or email.
+// @Tags         Customers
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        q      query     string  false  "Search term"
+// @Param        page   query     int     false  "Page number for pagination" default(1)
+// @Param        limit  query     int     false  "Number of items per page" default(10)
+// @Success      200    {object}  response.ApiResponse{data=[]model.Customer} "Successfully retrieved customers"
+// @Failure      401    {object}  response.ApiResponse "Unauthorized"
+// @Router       /customers [get]
+func (h *CustomerHandler) GetCustomers(c *fiber.Ctx) error {
+	page, limit := pageParams(c)
+
+	customers, total, err := h.customerService.ListCustomers(c.Context(), c.Query("q"), page, limit)
+	if err != nil {
+		return response.Error(c, fiber.StatusInternalServerError, errors.New("could not retrieve customers"))
+	}
+
+	return response.Pagination(c, customers, page, limit, total)
+}
+
+// GetCustomer handles the GET /api/v1/customers/:id request.
+// @Summary      Get a customer
+// @Description  Retrieves a member with their number of paid transactions, total spent and first and last purchase.
+// @Tags         Customers
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id   path      string  true  "Customer ID"
+// @Success      200  {object}  response.ApiResponse{data=service.CustomerProfile} "Successfully retrieved customer"
+// @Failure      400  {object}  response.ApiResponse "Bad Request"
+// @Failure      404  {object}  response.ApiResponse "Customer not found"
+// @Router       /customers/{id} [get]
+func (h *CustomerHandler) GetCustomer(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, errors.New("invalid ID format"))
+	}
+
+	profile, err := h.customerService.GetCustomer(c.Context(), id)
+	if err != nil {
+		return customerError(c, err)
+	}
+
+	return response.Success(c, fiber.StatusOK, profile)
+}
+
+// UpdateCustomer handles the PUT /api/v1/customers/:id request.
+// @Summary      Update a customer
+// @Description  Changes a member's details. Omitted fields are kept; an empty phone or email clears it.
+// @Tags         Customers
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id       path      string                 true  "Customer ID"
+// @Param        payload  body      UpdateCustomerPayload  true  "Customer details"
+// @Success      200  {object}  response.ApiResponse{data=model.Customer} "Successfully updated customer"
+// @Failure      400  {object}  response.ApiResponse "Bad Request"
+// @Failure      404  {object}  response.ApiResponse "Customer not found"
+// @Failure      409  {object}  response.ApiResponse "Member code, phone or email already registered"
+// @Router       /customers/{id} [put]
+func (h *CustomerHandler) UpdateCustomer(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, errors.New("invalid ID format"))
+	}
+
+	payload := new(UpdateCustomerPayload)
+	if err := c.BodyParser(payload); err != nil {
+		return response.Error(c, fiber.StatusBadRequest, errors.New("cannot parse JSON"))
+	}
+	// An empty email clears it and must not fail the email rule.
+	clearEmail := payload.Email != nil && *payload.Email == ""
+	if clearEmail {
+		payload.Email = nil
+	}
+	if errs := validator.ValidateStruct(payload); errs != nil {
+		return response.ValidationError(c, errs)
+	}
+	if clearEmail {
+		payload.Email = new(string)
+	}
+
+	customer, err := h.customerService.UpdateCustomer(c.Context(), id, service.CustomerInput{
+		MemberCode: payload.MemberCode,
+		Name:       payload.Name,
+		Phone:      payload.Phone,
+		Email:      payload.Email,
+	})
+	if err != nil {
+		return customerError(c, err)
+	}
+
+	return response.Success(c, fiber.StatusOK, customer)
+}
+
+// GetPurchaseHistory handles the GET /api/v1/customers/:id/transactions request.
+// @Summary      Get a customer's purchase history
+// @Description  Retrieves a paginated list of the member's paid transactions, newest first, with their items and payments.
+// @Tags         Customers
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id     path      string  true   "Customer ID"
+// @Param        page   query     int     false  "Page number for pagination" default(1)
+// @Param        limit  query     int     false  "Number of items per page" default(10)
+// @Success      200    {object}  response.ApiResponse{data=[]model.Transaction} "Successfully retrieved purchase history"
+// @Failure      400    {object}  response.ApiResponse "Bad Request"
+// @Failure      404    {object}  response.ApiResponse "Customer not found"
+// @Router       /customers/{id}/transactions [get]
+func (h *CustomerHandler) GetPurchaseHistory(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, errors.New("invalid ID format"))
+	}
+
+	page, limit := pageParams(c)
+	transactions, total, err := h.customerService.GetPurchaseHistory(c.Context(), id, page, limit)
+	if err != nil {
+		return customerError(c, err)
+	}
+
+	return response.Pagination(c, transactions, page, limit, total)
+}
+
+// pageParams reads the page and limit query parameters, defaulting to the
+// first page of 10 and capping the limit at 100.
+func pageParams(c *fiber.Ctx) (page, limit int) {
+	page, err := strconv.Atoi(c.Query("page", "1"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+
+	limit, err = strconv.Atoi(c.Query("limit", "10"))
+	if err != nil || limit < 1 {
+		limit = 10
+	}
+	if limit > 100 {
+		limit = 100
+	}
+	return page, limit
+}
@@ -0,0 +1,125 @@
+package http
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+	"venturo-core/internal/database"
+	"venturo-core/internal/service"
+	"venturo-core/pkg/export"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// exportFormat returns the spreadsheet format the client asked for with
+// ?format= or the Accept header, or "" for a regular JSON response.
+func exportFormat(c *fiber.Ctx) string {
+	switch c.Query("format") {
+	case export.FormatCSV:
+		return export.FormatCSV
+	case export.FormatXLSX:
+		return export.FormatXLSX
+	}
+
+	switch c.Accepts(fiber.MIMEApplicationJSON, export.MIMECSV, export.MIMEXLSX) {
+	case export.MIMECSV:
+		return export.FormatCSV
+	case export.MIMEXLSX:
+		return export.FormatXLSX
+	}
+	return ""
+}
+
+// exportLocales picks the locales of an export: the header locale comes from
+// ?locale=, then the filtered outlet, then Accept-Language; each row is
+// formatted with the locale of its own outlet.
+type exportLocales struct {
+	header  export.Locale
+	outlets map[uuid.UUID]export.Locale
+}
+
+func resolveExportLocales(c *fiber.Ctx, outletService *service.OutletService, outletID *uuid.UUID) (*exportLocales, error) {
+	tags, err := outletService.Locales(c.Context())
+	if err != nil {
+		return nil, err
+	}
+
+	locales := &exportLocales{outlets: make(map[uuid.UUID]export.Locale, len(tags))}
+	for id, tag := range tags {
+		locales.outlets[id] = export.LocaleOrDefault(tag)
+	}
+
+	switch {
+	case c.Query("locale") != "":
+		locales.header = export.LocaleOrDefault(c.Query("locale"))
+	case outletID != nil:
+		locales.header = locales.forOutlet(*outletID)
+	default:
+		locales.header = export.LocaleOrDefault(export.DefaultLocale)
+		for _, tag := range strings.Split(c.Get(fiber.HeaderAcceptLanguage), ",") {
+			tag, _, _ = strings.Cut(strings.TrimSpace(tag), ";")
+			if locale, ok := export.LookupLocale(tag); ok {
+				locales.header = locale
+				break
+			}
+		}
+	}
+
+	return locales, nil
+}
+
+// forOutlet returns the locale rows of an outlet are formatted with.
+func (l *exportLocales) forOutlet(outletID uuid.UUID) export.Locale {
+	if locale, ok := l.outlets[outletID]; ok {
+		return locale
+	}
+	return export.LocaleOrDefault(export.DefaultLocale)
+}
+
+// streamExport sends a spreadsheet attachment, writing the header row and then
+// whatever rows write emits. Rows reach the client in small batches, so the
+// export is never held in memory whole. write runs after the handler has
+// returned, so it gets its own context; a failure can only be logged because
+// the status is already sent.
+func streamExport(c *fiber.Ctx, format, name string, locale export.Locale, columns []string, write func(ctx context.Context, emit func(cells ...export.Cell) error) error) error {
+	filename := fmt.Sprintf("%s-%s.%s", name, time.Now().Format("20060102-150405"), format)
+	c.Set(fiber.HeaderContentType, export.MIMEType(format))
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s"`, filename))
+
+	ctx := database.Detach(c.Context())
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		writer, err := export.NewWriter(format, w, locale.CSVSeparator, name)
+		if err != nil {
+			slog.Error("Failed to start export", "export", name, "error", err)
+			return
+		}
+
+		err = writer.WriteRow(locale.Headers(columns...)...)
+		if err == nil {
+			rows := 0
+			err = write(ctx, func(cells ...export.Cell) error {
+				if err := writer.WriteRow(cells...); err != nil {
+					return err
+				}
+				// Each flush is a write and an HTTP chunk, plus a compressor flush
+				// for XLSX; batching rows keeps that overhead low.
+				if rows++; rows%200 == 0 {
+					return writer.Flush()
+				}
+				return nil
+			})
+		}
+		if err != nil {
+			slog.Error("Failed to stream export", "export", name, "error", err)
+		}
+
+		if err := writer.Close(); err != nil {
+			slog.Error("Failed to finish export", "export", name, "error", err)
+		}
+	})
+	return nil
+}
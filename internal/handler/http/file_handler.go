@@ -0,0 +1,62 @@
+package http
+
+import (
+	"errors"
+	"net/url"
+	"strconv"
+	"time"
+	"venturo-core/internal/adapter/storage"
+	"venturo-core/pkg/response"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// FileHandler serves private files of the local storage driver through signed URLs.
+type FileHandler struct {
+	storage *storage.LocalUploaderAdapter
+}
+
+// NewFileHandler creates a new file handler.
+func NewFileHandler(s *storage.LocalUploaderAdapter) *FileHandler {
+	return &FileHandler{storage: s}
+}
+
+// ServeSigned handles the GET /files/* request made from a signed URL.
+// @Summary      Download a file
+// @Description  Serves a stored file through a URL signed by the local storage driver. The URL stops working once it expires.
+// @Tags         Files
+// @Produce      octet-stream
+// @Param        expires    query     int     true  "Expiry as a Unix timestamp"
+// @Param        signature  query     string  true  "HMAC signature"
+// @Success      200  {file}    binary
+// @Failure      403  {object}  response.ApiResponse "Invalid or expired signature"
+// @Failure      404  {object}  response.ApiResponse "File not found"
+// @Router       /files/{name} [get]
+func (h *FileHandler) ServeSigned(c *fiber.Ctx) error {
+	name, err := url.PathUnescape(c.Params("*"))
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, errors.New("invalid file name"))
+	}
+
+	if err := h.storage.VerifySignedURL(name, c.Query("expires"), c.Query("signature")); err != nil {
+		return response.Error(c, fiber.StatusForbidden, err)
+	}
+
+	file, info, err := h.storage.Open(c.Context(), name)
+	if err != nil {
+		if errors.Is(err, storage.ErrObjectNotFound) {
+			return response.Error(c, fiber.StatusNotFound, errors.New("file not found"))
+		}
+		return response.Error(c, fiber.StatusInternalServerError, err)
+	}
+
+	c.Set(fiber.HeaderContentType, info.ContentType)
+	c.Set(fiber.HeaderCacheControl, "private, max-age="+strconv.FormatInt(maxAge(c.Query("expires")), 10))
+	return c.SendStream(file, int(info.Size))
+}
+
+// maxAge is how many seconds remain until a signed URL expires.
+func maxAge(expires string) int64 {
+	expiresAt, _ := strconv.ParseInt(expires, 10, 64)
+	return max(expiresAt-time.Now().Unix(), 0)
+}
@@ -0,0 +1,43 @@
+package http
+
+import (
+	"venturo-core/internal/service"
+	"venturo-core/pkg/response"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type HealthHandler struct {
+	healthService *service.HealthService
+}
+
+// NewHealthHandler creates a new health handler.
+func NewHealthHandler(s *service.HealthService) *HealthHandler {
+	return &HealthHandler{healthService: s}
+}
+
+// Livez handles the GET /livez request. It checks no dependencies, so a
+// database outage does not get the app restarted.
+func (h *HealthHandler) Livez(c *fiber.Ctx) error {
+	return response.Success(c, fiber.StatusOK, fiber.Map{"status": "ok"})
+}
+
+// Readyz handles the GET /readyz request. The app is ready when the database
+// answers and every migration of this build has been applied cleanly.
+func (h *HealthHandler) Readyz(c *fiber.Ctx) error {
+	report := h.healthService.Readiness(c.Context())
+	if !report.Ready {
+		return response.Success(c, fiber.StatusServiceUnavailable, report)
+	}
+	return response.Success(c, fiber.StatusOK, report)
+}
+
+// GetPoolStats handles the GET /metrics/db request, returning the connection
+// pool counters for monitoring. It requires authentication.
+func (h *HealthHandler) GetPoolStats(c *fiber.Ctx) error {
+	stats, err := h.healthService.PoolStats()
+	if err != nil {
+		return response.Error(c, fiber.StatusInternalServerError, err)
+	}
+	return response.Success(c, fiber.StatusOK, stats)
+}
@@ -0,0 +1,115 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"venturo-core/internal/model"
+	"venturo-core/internal/service"
+	"venturo-core/pkg/response"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type ImportHandler struct {
+	importService *service.ImportService
+}
+
+// NewImportHandler creates a new import handler.
+func NewImportHandler(s *service.ImportService) *ImportHandler {
+	return &ImportHandler{importService: s}
+}
+
+// ImportProducts handles the POST /api/v1/imports/products request.
+// @Summary      Import products from CSV
+// @Description  Starts a background import of a product CSV with the columns sku, name, price and optionally base_unit, opening_stock and unit_cost. The dry_run mode (default) validates every row and reports errors by line; commit upserts products by SKU and posts opening stock at the outlet in one transaction, only if no row has errors. Poll GET /imports/{id} for progress.
+// @Tags         Imports
+// @Accept       multipart/form-data
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        file       formData  file    true   "CSV file"
+// @Param        mode       formData  string  false  "dry_run or commit" default(dry_run)
+// @Param        outlet_id  formData  string  false  "Outlet receiving the opening stock"
+// @Success      202  {object}  response.ApiResponse{data=model.ImportJob} "Import started"
+// @Failure      400  {object}  response.ApiResponse "Bad Request"
+// @Failure      401  {object}  response.ApiResponse "Unauthorized"
+// @Failure      404  {object}  response.ApiResponse "Outlet not found"
+// @Router       /imports/products [post]
+func (h *ImportHandler) ImportProducts(c *fiber.Ctx) error {
+	userID, ok := c.Locals("current_user_id").(uuid.UUID)
+	if !ok {
+		return response.Error(c, fiber.StatusUnauthorized, errors.New("unauthorized"))
+	}
+
+	mode := c.FormValue("mode", model.ImportModeDryRun)
+	if mode != model.ImportModeDryRun && mode != model.ImportModeCommit {
+		return response.Error(c, fiber.StatusBadRequest, errors.New("mode must be dry_run or commit"))
+	}
+
+	input := service.StartProductImportInput{UserID: userID, DryRun: mode == model.ImportModeDryRun}
+
+	if outletIDStr := c.FormValue("outlet_id"); outletIDStr != "" {
+		outletID, err := uuid.Parse(outletIDStr)
+		if err != nil {
+			return response.Error(c, fiber.StatusBadRequest, errors.New("invalid outlet_id format"))
+		}
+		input.OutletID = &outletID
+	}
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, errors.New("file is required"))
+	}
+	if file.Size > service.MaxImportFileSize {
+		return response.Error(c, fiber.StatusBadRequest, fmt.Errorf("file must be at most %d bytes", service.MaxImportFileSize))
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, errors.New("cannot read file"))
+	}
+	defer src.Close()
+
+	input.FileName = file.Filename
+	input.Data, err = io.ReadAll(src)
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, errors.New("cannot read file"))
+	}
+
+	job, err := h.importService.StartProductImport(c.Context(), input)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return response.Error(c, fiber.StatusNotFound, err)
+		}
+		return response.Error(c, fiber.StatusInternalServerError, err)
+	}
+
+	return response.Success(c, fiber.StatusAccepted, job)
+}
+
+// GetImportJob handles the GET /api/v1/imports/:id request.
+// @Summary      Get import progress
+// @Description  Retrieves an import job with its status, row counters and the errors found so far.
+// @Tags         Imports
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id   path      string  true  "Import job ID"
+// @Success      200  {object}  response.ApiResponse{data=model.ImportJob} "Successfully retrieved import job"
+// @Failure      400  {object}  response.ApiResponse "Bad Request"
+// @Failure      404  {object}  response.ApiResponse "Import job not found"
+// @Router       /imports/{id} [get]
+func (h *ImportHandler) GetImportJob(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, errors.New("invalid ID format"))
+	}
+
+	job, err := h.importService.GetJob(c.Context(), id)
+	if err != nil {
+		return response.Error(c, fiber.StatusNotFound, err)
+	}
+
+	return response.Success(c, fiber.StatusOK, job)
+}
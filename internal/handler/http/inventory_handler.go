@@ -2,6 +2,7 @@ package http
 
 import (
 	"errors"
+	"time"
 	"venturo-core/internal/service"
 	"venturo-core/pkg/response"
 	"venturo-core/pkg/validator"
@@ -24,6 +25,16 @@ type StockInPayload struct {
 	ItemID   uuid.UUID `json:"item_id" validate:"required"`
 	OutletID uuid.UUID `json:"outlet_id" validate:"required"`
 	Quantity int       `json:"quantity" validate:"required,min=1"`
+	Unit     string    `json:"unit" validate:"max=20"`               // Defaults to the product's purchase unit
+	UnitCost *float64  `json:"unit_cost" validate:"omitempty,min=0"` // Cost of one unit, used for valuation
+	// Optional lot tracking for perishable stock.
+	LotNumber  string `json:"lot_number" validate:"max=50"`
+	ExpiryDate string `json:"expiry_date" validate:"omitempty,datetime=2006-01-02"`
+}
+
+// WriteOffExpiredPayload defines the optional filter for writing off expired lots.
+type WriteOffExpiredPayload struct {
+	OutletID *uuid.UUID `json:"outlet_id"`
 }
 
 // StockIn handles the POST /api/v1/inventory/stock-in request.
@@ -52,9 +63,20 @@ func (h *InventoryHandler) StockIn(c *fiber.Ctx) error {
 
 	// Map payload to service input
 	serviceInput := service.StockInInput{
-		ItemID:   payload.ItemID,
-		OutletID: payload.OutletID,
-		Quantity: payload.Quantity,
+		ItemID:    payload.ItemID,
+		OutletID:  payload.OutletID,
+		Quantity:  payload.Quantity,
+		Unit:      payload.Unit,
+		UnitCost:  payload.UnitCost,
+		LotNumber: payload.LotNumber,
+	}
+
+	if payload.ExpiryDate != "" {
+		expiryDate, err := time.Parse(time.DateOnly, payload.ExpiryDate)
+		if err != nil {
+			return response.Error(c, fiber.StatusBadRequest, errors.New("invalid expiry_date format, expected YYYY-MM-DD"))
+		}
+		serviceInput.ExpiryDate = &expiryDate
 	}
 
 	// Call the service
@@ -65,3 +87,34 @@ func (h *InventoryHandler) StockIn(c *fiber.Ctx) error {
 
 	return response.Success(c, fiber.StatusCreated, ledger)
 }
+
+// WriteOffExpired handles the POST /api/v1/inventory/write-off-expired request.
+// @Summary      Write Off Expired Lots
+// @Description  Posts an adjustment entry that zeroes every lot whose expiry date has passed
+// @Tags         Inventory
+// @Accept       json
+// @Produce      json
+// @Param        Authorization header string true "Bearer JWT token"
+// @Param        payload body WriteOffExpiredPayload false "Optional outlet filter"
+// @Success      200      {object}  response.ApiResponse{data=[]model.InventoryLedger} "Expired lots written off"
+// @Failure      400      {object}  response.ApiResponse "Bad Request"
+// @Failure      401      {object}  response.ApiResponse "Unauthorized"
+// @Failure      500      {object}  response.ApiResponse "Internal Server Error"
+// @Router       /inventory/write-off-expired [post]
+func (h *InventoryHandler) WriteOffExpired(c *fiber.Ctx) error {
+	payload := new(WriteOffExpiredPayload)
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(payload); err != nil {
+			return response.Error(c, fiber.StatusBadRequest, errors.New("cannot parse JSON"))
+		}
+	}
+
+	adjustments, err := h.inventoryService.WriteOffExpired(c.Context(), service.WriteOffExpiredInput{
+		OutletID: payload.OutletID,
+	})
+	if err != nil {
+		return response.Error(c, fiber.StatusInternalServerError, err)
+	}
+
+	return response.Success(c, fiber.StatusOK, adjustments)
+}
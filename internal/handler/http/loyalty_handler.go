@@ -0,0 +1,146 @@
+package http
+
+import (
+	"errors"
+	"strings"
+	"venturo-core/internal/model"
+	"venturo-core/internal/service"
+	"venturo-core/pkg/response"
+	"venturo-core/pkg/validator"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type LoyaltyHandler struct {
+	loyaltyService *service.LoyaltyService
+}
+
+// NewLoyaltyHandler creates a new loyalty handler.
+func NewLoyaltyHandler(s *service.LoyaltyService) *LoyaltyHandler {
+	return &LoyaltyHandler{loyaltyService: s}
+}
+
+// UpdateLoyaltyRulesPayload defines the expected JSON for changing the loyalty rules.
+type UpdateLoyaltyRulesPayload struct {
+	Enabled             *bool              `json:"enabled"`
+	PointsPerUnit       *float64           `json:"points_per_unit" validate:"omitempty,min=0"`
+	CategoryMultipliers map[string]float64 `json:"category_multipliers"` // e.g. {"Goods": 1, "Service": 2}; replaces all multipliers
+	PointValue          *int64             `json:"point_value" validate:"omitempty,min=0"`
+	ExpiryDays          *int               `json:"expiry_days" validate:"omitempty,min=0"` // 0 keeps points forever
+}
+
+// GetRules handles the GET /api/v1/loyalty/rules request.
+// @Summary      Get loyalty rules
+// @Description  Retrieves the points earn rate, category multipliers, point value and expiry period.
+// @Tags         Loyalty
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Success      200  {object}  response.ApiResponse{data=model.LoyaltyRules} "Successfully retrieved loyalty rules"
+// @Failure      401  {object}  response.ApiResponse "Unauthorized"
+// @Router       /loyalty/rules [get]
+func (h *LoyaltyHandler) GetRules(c *fiber.Ctx) error {
+	rules, err := h.loyaltyService.GetRules(c.Context())
+	if err != nil {
+		return response.Error(c, fiber.StatusInternalServerError, err)
+	}
+
+	return response.Success(c, fiber.StatusOK, rules)
+}
+
+// UpdateRules handles the PUT /api/v1/loyalty/rules request.
+// @Summary      Update loyalty rules
+// @Description  Changes the loyalty rules. Sales earn points_per_unit points per currency unit, times their category multiplier; a point redeems for point_value. New rules apply to sales paid from now on.
+// @Tags         Loyalty
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        payload  body      UpdateLoyaltyRulesPayload  true  "Rules to change"
+// @Success      200  {object}  response.ApiResponse{data=model.LoyaltyRules} "Successfully updated loyalty rules"
+// @Failure      400  {object}  response.ApiResponse "Bad Request"
+// @Failure      401  {object}  response.ApiResponse "Unauthorized"
+// @Router       /loyalty/rules [put]
+func (h *LoyaltyHandler) UpdateRules(c *fiber.Ctx) error {
+	payload := new(UpdateLoyaltyRulesPayload)
+	if err := c.BodyParser(payload); err != nil {
+		return response.Error(c, fiber.StatusBadRequest, errors.New("cannot parse JSON"))
+	}
+	if errs := validator.ValidateStruct(payload); errs != nil {
+		return response.ValidationError(c, errs)
+	}
+
+	rules, err := h.loyaltyService.UpdateRules(c.Context(), service.UpdateLoyaltyRulesInput{
+		Enabled:             payload.Enabled,
+		PointsPerUnit:       payload.PointsPerUnit,
+		CategoryMultipliers: model.CategoryMultipliers(payload.CategoryMultipliers),
+		PointValue:          payload.PointValue,
+		ExpiryDays:          payload.ExpiryDays,
+	})
+	if err != nil {
+		if strings.Contains(err.Error(), "category") || strings.Contains(err.Error(), "multiplier") {
+			return response.Error(c, fiber.StatusBadRequest, err)
+		}
+		return response.Error(c, fiber.StatusInternalServerError, err)
+	}
+
+	return response.Success(c, fiber.StatusOK, rules)
+}
+
+// GetBalance handles the GET /api/v1/customers/:id/points request.
+// @Summary      Get a customer's points balance
+// @Description  Retrieves the customer's loyalty points, their value and the next points to expire. Points past their expiry are expired first.
+// @Tags         Loyalty
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id   path      string  true  "Customer ID"
+// @Success      200  {object}  response.ApiResponse{data=service.PointsBalance} "Successfully retrieved balance"
+// @Failure      400  {object}  response.ApiResponse "Bad Request"
+// @Failure      404  {object}  response.ApiResponse "Customer not found"
+// @Router       /customers/{id}/points [get]
+func (h *LoyaltyHandler) GetBalance(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, errors.New("invalid ID format"))
+	}
+
+	balance, err := h.loyaltyService.GetBalance(c.Context(), id)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return response.Error(c, fiber.StatusNotFound, err)
+		}
+		return response.Error(c, fiber.StatusInternalServerError, err)
+	}
+
+	return response.Success(c, fiber.StatusOK, balance)
+}
+
+// GetHistory handles the GET /api/v1/customers/:id/points/history request.
+// @Summary      Get a customer's points history
+// @Description  Retrieves a paginated list of the customer's points ledger entries, newest first.
+// @Tags         Loyalty
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id     path      string  true   "Customer ID"
+// @Param        page   query     int     false  "Page number for pagination" default(1)
+// @Param        limit  query     int     false  "Number of items per page" default(10)
+// @Success      200    {object}  response.ApiResponse{data=[]model.LoyaltyLedger} "Successfully retrieved points history"
+// @Failure      400    {object}  response.ApiResponse "Bad Request"
+// @Failure      404    {object}  response.ApiResponse "Customer not found"
+// @Router       /customers/{id}/points/history [get]
+func (h *LoyaltyHandler) GetHistory(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, errors.New("invalid ID format"))
+	}
+
+	page, limit := pageParams(c)
+	entries, total, err := h.loyaltyService.GetHistory(c.Context(), id, page, limit)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return response.Error(c, fiber.StatusNotFound, err)
+		}
+		return response.Error(c, fiber.StatusInternalServerError, err)
+	}
+
+	return response.Pagination(c, entries, page, limit, total)
+}
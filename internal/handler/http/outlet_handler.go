@@ -0,0 +1,72 @@
+package http
+
+import (
+	"errors"
+	"strings"
+	"venturo-core/internal/service"
+	"venturo-core/pkg/response"
+	"venturo-core/pkg/validator"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type OutletHandler struct {
+	outletService *service.OutletService
+}
+
+// NewOutletHandler creates a new outlet handler.
+func NewOutletHandler(s *service.OutletService) *OutletHandler {
+	return &OutletHandler{outletService: s}
+}
+
+// UpdateOutletSettingsPayload defines the expected JSON for changing outlet settings.
+type UpdateOutletSettingsPayload struct {
+	Timezone         *string `json:"timezone" validate:"omitempty,max=64"` // IANA name, e.g. Asia/Jakarta
+	Locale           *string `json:"locale" validate:"omitempty,max=10"`   // e.g. id-ID
+	RequireOpenShift *bool   `json:"require_open_shift"`
+}
+
+// UpdateSettings handles the PATCH /api/v1/outlets/:id request.
+// @Summary      Update outlet settings
+// @Description  Changes an outlet's time zone, export locale and whether cashiers need an open shift to sell.
+// @Tags         Outlets
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id       path      string                       true  "Outlet ID"
+// @Param        payload  body      UpdateOutletSettingsPayload  true  "Settings Payload"
+// @Success      200      {object}  response.ApiResponse{data=model.Outlet} "Successfully updated outlet"
+// @Failure      400      {object}  response.ApiResponse "Bad Request"
+// @Failure      401      {object}  response.ApiResponse "Unauthorized"
+// @Failure      404      {object}  response.ApiResponse "Outlet not found"
+// @Router       /outlets/{id} [patch]
+func (h *OutletHandler) UpdateSettings(c *fiber.Ctx) error {
+	outletID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, errors.New("invalid ID format"))
+	}
+
+	payload := new(UpdateOutletSettingsPayload)
+	if err := c.BodyParser(payload); err != nil {
+		return response.Error(c, fiber.StatusBadRequest, errors.New("cannot parse JSON"))
+	}
+
+	if errs := validator.ValidateStruct(payload); errs != nil {
+		return response.ValidationError(c, errs)
+	}
+
+	outlet, err := h.outletService.UpdateSettings(c.Context(), outletID, service.UpdateOutletSettingsInput{
+		Timezone:         payload.Timezone,
+		Locale:           payload.Locale,
+		RequireOpenShift: payload.RequireOpenShift,
+	})
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return response.Error(c, fiber.StatusNotFound, err)
+		}
+		return response.Error(c, fiber.StatusBadRequest, err)
+	}
+
+	return response.Success(c, fiber.StatusOK, outlet)
+}
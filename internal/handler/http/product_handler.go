@@ -3,10 +3,14 @@ package http
 import (
 	"errors"
 	"strconv"
+	"strings"
 	"venturo-core/internal/service"
+	"venturo-core/pkg/imaging"
 	"venturo-core/pkg/response"
+	"venturo-core/pkg/validator"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
 )
 
 type ProductHandler struct {
@@ -26,8 +30,10 @@ func NewProductHandler(s *service.ProductService) *ProductHandler {
 // @Security     ApiKeyAuth
 // @Param        name   formData  string  true  "Product Name"
 // @Param        price  formData  int     true  "Product Price"
-// @Param        stock  formData  int     true  "Product Stock"
-// @Param        image  formData  file    false "Product Image"
+// @Param        base_unit      formData  string  false "Unit stock is counted in" default(pcs)
+// @Param        initial_stock  formData  int     false "Opening stock posted to the ledger, in the base unit"
+// @Param        outlet_id      formData  string  false "Outlet receiving the opening stock (required with initial_stock)"
+// @Param        image  formData  file    false "Product image (JPEG, PNG or WebP, up to 4 MB and 6000x6000); processed into variants in the background"
 // @Success      201    {object}  response.ApiResponse{data=model.Product} "Successfully created product"
 // @Failure      400    {object}  response.ApiResponse "Bad Request"
 // @Failure      401    {object}  response.ApiResponse "Unauthorized"
@@ -35,23 +41,33 @@ func NewProductHandler(s *service.ProductService) *ProductHandler {
 // @Router       /products [post]
 // CreateProduct godoc
 // CreateProduct handles the creation of a new product.
-// It expects a multipart/form-data request with fields for name, price, stock, and an optional image file.
-// It validates the price and stock fields, and returns an error if they are not in the correct format.
+// It expects a multipart/form-data request with fields for name, price, an optional opening stock
+// with its outlet, and an optional image file.
+// It validates the numeric and ID fields, and returns an error if they are not in the correct format.
 // If successful, it returns the created product with a 201 status code.
 func (h *ProductHandler) CreateProduct(c *fiber.Ctx) error {
 	price, err := strconv.Atoi(c.FormValue("price"))
 	if err != nil {
 		return response.Error(c, fiber.StatusBadRequest, errors.New("invalid price format"))
 	}
-	stock, err := strconv.Atoi(c.FormValue("stock"))
+	initialStock, err := strconv.Atoi(c.FormValue("initial_stock", "0"))
 	if err != nil {
-		return response.Error(c, fiber.StatusBadRequest, errors.New("invalid stock format"))
+		return response.Error(c, fiber.StatusBadRequest, errors.New("invalid initial_stock format"))
 	}
 
 	input := service.CreateProductInput{
-		Name:  c.FormValue("name"),
-		Price: int32(price),
-		Stock: int16(stock),
+		Name:         c.FormValue("name"),
+		Price:        int32(price),
+		BaseUnit:     c.FormValue("base_unit"),
+		InitialStock: initialStock,
+	}
+
+	if outletIDStr := c.FormValue("outlet_id"); outletIDStr != "" {
+		outletID, err := uuid.Parse(outletIDStr)
+		if err != nil {
+			return response.Error(c, fiber.StatusBadRequest, errors.New("invalid outlet_id format"))
+		}
+		input.OutletID = &outletID
 	}
 
 	file, err := c.FormFile("image")
@@ -61,8 +77,251 @@ func (h *ProductHandler) CreateProduct(c *fiber.Ctx) error {
 
 	product, err := h.productService.CreateProduct(c.Context(), input)
 	if err != nil {
+		if errors.Is(err, imaging.ErrInvalidImage) {
+			return response.Error(c, fiber.StatusBadRequest, err)
+		}
 		return response.Error(c, fiber.StatusInternalServerError, err)
 	}
 
 	return response.Success(c, fiber.StatusCreated, product)
 }
+
+// GetProductByID retrieves a product with its per-outlet stock breakdown.
+// @Summary      Get a single product
+// @Description  Retrieves a product and its on-hand quantity at every outlet, computed from the inventory ledger.
+// @Tags         Products
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id   path      string  true  "Product ID"
+// @Success      200  {object}  response.ApiResponse{data=model.Product} "Successfully retrieved product"
+// @Failure      400  {object}  response.ApiResponse "Bad Request"
+// @Failure      404  {object}  response.ApiResponse "Product not found"
+// @Router       /products/{id} [get]
+func (h *ProductHandler) GetProductByID(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, errors.New("invalid ID format"))
+	}
+
+	product, err := h.productService.GetProduct(c.Context(), id)
+	if err != nil {
+		return response.Error(c, fiber.StatusNotFound, errors.New("product not found"))
+	}
+
+	return response.Success(c, fiber.StatusOK, product)
+}
+
+// UpdateProductPayload defines the expected JSON for changing a product; omitted fields are kept.
+type UpdateProductPayload struct {
+	Name              *string `json:"name" validate:"omitempty,min=1,max=255"`
+	Price             *int32  `json:"price" validate:"omitempty,min=0"`
+	LowStockThreshold *int    `json:"low_stock_threshold"` // Negative turns the alert off
+}
+
+// UpdateProduct handles the PUT /api/v1/products/:id request.
+// @Summary      Update a product
+// @Description  Changes a product's name, price or low-stock threshold. Omitted fields are kept; a negative threshold turns the low-stock alert off.
+// @Tags         Products
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id       path      string                true  "Product ID"
+// @Param        payload  body      UpdateProductPayload  true  "Product details"
+// @Success      200      {object}  response.ApiResponse{data=model.Product} "Successfully updated product"
+// @Failure      400      {object}  response.ApiResponse "Bad Request"
+// @Failure      401      {object}  response.ApiResponse "Unauthorized"
+// @Failure      404      {object}  response.ApiResponse "Product not found"
+// @Router       /products/{id} [put]
+func (h *ProductHandler) UpdateProduct(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, errors.New("invalid ID format"))
+	}
+
+	payload := new(UpdateProductPayload)
+	if err := c.BodyParser(payload); err != nil {
+		return response.Error(c, fiber.StatusBadRequest, errors.New("cannot parse JSON"))
+	}
+
+	if errs := validator.ValidateStruct(payload); errs != nil {
+		return response.ValidationError(c, errs)
+	}
+
+	product, err := h.productService.UpdateProduct(c.Context(), id, service.UpdateProductInput{
+		Name:              payload.Name,
+		Price:             payload.Price,
+		LowStockThreshold: payload.LowStockThreshold,
+	})
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return response.Error(c, fiber.StatusNotFound, err)
+		}
+		return response.Error(c, fiber.StatusInternalServerError, err)
+	}
+
+	return response.Success(c, fiber.StatusOK, product)
+}
+
+// ReplaceImage handles the PUT /api/v1/products/:id/image request.
+// @Summary      Replace a product image
+// @Description  Checks the image, then renders its thumbnail, medium and large variants in the background. ImageStatus is processing until they are stored, then ready or failed. The previous image is deleted from storage once the new one is ready.
+// @Tags         Products
+// @Accept       multipart/form-data
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id     path      string  true  "Product ID"
+// @Param        image  formData  file    true  "Product image (JPEG, PNG or WebP, up to 4 MB and 6000x6000)"
+// @Success      202  {object}  response.ApiResponse{data=model.Product} "Image upload started"
+// @Failure      400  {object}  response.ApiResponse "Bad Request"
+// @Failure      401  {object}  response.ApiResponse "Unauthorized"
+// @Failure      404  {object}  response.ApiResponse "Product not found"
+// @Router       /products/{id}/image [put]
+func (h *ProductHandler) ReplaceImage(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, errors.New("invalid ID format"))
+	}
+
+	file, err := c.FormFile("image")
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, errors.New("image is required"))
+	}
+
+	product, err := h.productService.ReplaceImage(c.Context(), id, file)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return response.Error(c, fiber.StatusNotFound, err)
+		}
+		if errors.Is(err, imaging.ErrInvalidImage) {
+			return response.Error(c, fiber.StatusBadRequest, err)
+		}
+		return response.Error(c, fiber.StatusInternalServerError, err)
+	}
+
+	return response.Success(c, fiber.StatusAccepted, product)
+}
+
+// SetComponentsPayload defines the bill of materials of a composite product.
+type SetComponentsPayload struct {
+	Components []ComponentPayload `json:"components" validate:"dive"`
+}
+
+// ComponentPayload is one bill-of-materials line.
+type ComponentPayload struct {
+	ComponentID uuid.UUID `json:"component_id" validate:"required"`
+	Quantity    int       `json:"quantity" validate:"required,min=1"`
+	Unit        string    `json:"unit" validate:"max=20"`
+}
+
+// SetComponents replaces the bill of materials of a product.
+// @Summary      Set product components
+// @Description  Replaces the bill of materials of a composite product. Selling the product deducts its components from inventory. An empty list makes it a stocked item again.
+// @Tags         Products
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id       path      string                true  "Product ID"
+// @Param        payload  body      SetComponentsPayload  true  "Bill of materials"
+// @Success      200      {object}  response.ApiResponse{data=model.Product} "Successfully updated components"
+// @Failure      400      {object}  response.ApiResponse "Bad Request"
+// @Failure      401      {object}  response.ApiResponse "Unauthorized"
+// @Failure      404      {object}  response.ApiResponse "Product not found"
+// @Router       /products/{id}/components [put]
+func (h *ProductHandler) SetComponents(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, errors.New("invalid ID format"))
+	}
+
+	payload := new(SetComponentsPayload)
+	if err := c.BodyParser(payload); err != nil {
+		return response.Error(c, fiber.StatusBadRequest, errors.New("cannot parse JSON"))
+	}
+
+	if errs := validator.ValidateStruct(payload); errs != nil {
+		return response.ValidationError(c, errs)
+	}
+
+	inputs := make([]service.ComponentInput, 0, len(payload.Components))
+	for _, component := range payload.Components {
+		inputs = append(inputs, service.ComponentInput{
+			ComponentID: component.ComponentID,
+			Quantity:    component.Quantity,
+			Unit:        component.Unit,
+		})
+	}
+
+	product, err := h.productService.SetComponents(c.Context(), id, inputs)
+	if err != nil {
+		if strings.Contains(err.Error(), "product not found") {
+			return response.Error(c, fiber.StatusNotFound, err)
+		}
+		return response.Error(c, fiber.StatusBadRequest, err)
+	}
+
+	return response.Success(c, fiber.StatusOK, product)
+}
+
+// SetUnitsPayload defines the unit conversions of a product.
+type SetUnitsPayload struct {
+	PurchaseUnit string                  `json:"purchase_unit" validate:"max=20"`
+	SalesUnit    string                  `json:"sales_unit" validate:"max=20"`
+	Conversions  []UnitConversionPayload `json:"conversions" validate:"dive"`
+}
+
+// UnitConversionPayload says how many base units one of Unit is worth.
+type UnitConversionPayload struct {
+	Unit   string `json:"unit" validate:"required,max=20"`
+	Factor int64  `json:"factor" validate:"required,min=1"`
+}
+
+// SetUnits replaces the unit conversions of a product.
+// @Summary      Set product units
+// @Description  Replaces the unit conversions of a product and picks its purchase and sales units. Conversions are expressed in the product's base unit.
+// @Tags         Products
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id       path      string           true  "Product ID"
+// @Param        payload  body      SetUnitsPayload  true  "Unit conversions"
+// @Success      200      {object}  response.ApiResponse{data=model.Product} "Successfully updated units"
+// @Failure      400      {object}  response.ApiResponse "Bad Request"
+// @Failure      401      {object}  response.ApiResponse "Unauthorized"
+// @Failure      404      {object}  response.ApiResponse "Product not found"
+// @Router       /products/{id}/units [put]
+func (h *ProductHandler) SetUnits(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, errors.New("invalid ID format"))
+	}
+
+	payload := new(SetUnitsPayload)
+	if err := c.BodyParser(payload); err != nil {
+		return response.Error(c, fiber.StatusBadRequest, errors.New("cannot parse JSON"))
+	}
+
+	if errs := validator.ValidateStruct(payload); errs != nil {
+		return response.ValidationError(c, errs)
+	}
+
+	input := service.SetUnitsInput{
+		PurchaseUnit: payload.PurchaseUnit,
+		SalesUnit:    payload.SalesUnit,
+	}
+	for _, conversion := range payload.Conversions {
+		input.Conversions = append(input.Conversions, service.UnitConversionInput{
+			Unit:   conversion.Unit,
+			Factor: conversion.Factor,
+		})
+	}
+
+	product, err := h.productService.SetUnits(c.Context(), id, input)
+	if err != nil {
+		if strings.Contains(err.Error(), "product not found") {
+			return response.Error(c, fiber.StatusNotFound, err)
+		}
+		return response.Error(c, fiber.StatusBadRequest, err)
+	}
+
+	return response.Success(c, fiber.StatusOK, product)
+}
@@ -1,7 +1,16 @@
 package http
 
 import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strconv"
+	"time"
+	"venturo-core/internal/database"
 	"venturo-core/internal/service"
+	"venturo-core/pkg/export"
 	"venturo-core/pkg/response"
 
 	"github.com/gofiber/fiber/v2"
@@ -10,30 +19,45 @@ import (
 
 type ReportHandler struct {
 	reportService *service.ReportService
+	outletService *service.OutletService
 }
 
-// NewReportHandler creates a new report handler.
-func NewReportHandler(s *service.ReportService) *ReportHandler {
-	return &ReportHandler{reportService: s}
+// NewReportHandler creates a new report handler. The outlet service supplies
+// the outlet locales used by spreadsheet exports.
+func NewReportHandler(s *service.ReportService, outletService *service.OutletService) *ReportHandler {
+	return &ReportHandler{reportService: s, outletService: outletService}
 }
 
 // GetInventoryReport handles the GET /api/v1/reports/inventory request.
 // @Summary      Get Inventory Report
-// @Description  Generate a comprehensive inventory report with on-hand quantities and transaction history
+// @Description  Generate a cursor-paginated inventory report with on-hand quantities and recent transaction history. Send format=ndjson (or Accept: application/x-ndjson) to stream every page as newline-delimited JSON, or format=csv|xlsx (or Accept: text/csv) for a spreadsheet without history.
 // @Tags         Reports
 // @Accept       json
 // @Produce      json
+// @Produce      application/x-ndjson
+// @Produce      text/csv
 // @Param        Authorization header string false "Bearer JWT token"
 // @Param        item_id query string false "Filter by specific item ID"
 // @Param        outlet_id query string false "Filter by specific outlet ID"
-// @Success      200      {object}  response.ApiResponse{data=[]service.InventoryReportItem} "Inventory report generated successfully"
+// @Param        unit query string false "Render quantities in this unit where the product defines it"
+// @Param        cursor query string false "Cursor from the previous page's meta.next_cursor"
+// @Param        limit query int false "Item/outlet pairs per page" default(50)
+// @Param        history_limit query int false "Most recent movements per pair, 0 to omit history" default(10)
+// @Param        from query string false "History window start (YYYY-MM-DD)"
+// @Param        to query string false "History window end, inclusive (YYYY-MM-DD)"
+// @Param        format query string false "ndjson, csv or xlsx to stream the full report"
+// @Param        locale query string false "Locale of the spreadsheet headers, e.g. id-ID"
+// @Success      200      {object}  response.ApiResponse{data=[]service.InventoryReportItem,meta=response.CursorMeta} "Inventory report generated successfully"
 // @Failure      400      {object}  response.ApiResponse "Bad Request"
 // @Failure      401      {object}  response.ApiResponse "Unauthorized"
 // @Failure      500      {object}  response.ApiResponse "Internal Server Error"
 // @Router       /reports/inventory [get]
 func (h *ReportHandler) GetInventoryReport(c *fiber.Ctx) error {
 	// Parse query parameters
-	var input service.InventoryReportInput
+	input := service.InventoryReportInput{
+		Unit:   c.Query("unit"),
+		Cursor: c.Query("cursor"),
+	}
 
 	// Parse item_id if provided
 	if itemIDStr := c.Query("item_id"); itemIDStr != "" {
@@ -53,11 +77,607 @@ func (h *ReportHandler) GetInventoryReport(c *fiber.Ctx) error {
 		input.OutletID = &outletID
 	}
 
+	limit, err := strconv.Atoi(c.Query("limit", strconv.Itoa(service.DefaultInventoryReportLimit)))
+	if err != nil || limit < 1 {
+		limit = service.DefaultInventoryReportLimit
+	}
+	input.Limit = min(limit, service.MaxInventoryReportLimit)
+
+	historyLimit, err := strconv.Atoi(c.Query("history_limit", strconv.Itoa(service.DefaultHistoryLimit)))
+	if err != nil || historyLimit < 0 {
+		return response.Error(c, fiber.StatusBadRequest, errors.New("history_limit must be a non-negative integer"))
+	}
+	input.HistoryLimit = min(historyLimit, service.MaxHistoryLimit)
+
+	input.From, input.To, err = parseDateRange(c)
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, err)
+	}
+
+	if c.Query("format") == "ndjson" || c.Accepts(fiber.MIMEApplicationJSON, mimeNDJSON) == mimeNDJSON {
+		return h.streamInventoryReport(c, input)
+	}
+	if format := exportFormat(c); format != "" {
+		return h.exportInventoryReport(c, format, input)
+	}
+
 	// Generate the report
 	report, err := h.reportService.GenerateInventoryReport(c.Context(), input)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidCursor) {
+			return response.Error(c, fiber.StatusBadRequest, err)
+		}
+		return response.Error(c, fiber.StatusInternalServerError, err)
+	}
+
+	return response.CursorPagination(c, report.Items, report.NextCursor, input.Limit)
+}
+
+// mimeNDJSON is the content type of newline-delimited JSON exports.
+const mimeNDJSON = "application/x-ndjson"
+
+// streamInventoryReport writes every page of the inventory report as one JSON
+// object per line. A failure after streaming has begun is reported as a final
+// {"error": ...} line because the status code has already been sent.
+func (h *ReportHandler) streamInventoryReport(c *fiber.Ctx, input service.InventoryReportInput) error {
+	input.Cursor = ""
+	c.Set(fiber.HeaderContentType, mimeNDJSON)
+	// The request context is finished once the handler returns, so the stream uses its own.
+	ctx := database.Detach(c.Context())
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		encoder := json.NewEncoder(w)
+		err := h.reportService.StreamInventoryReport(ctx, input, func(item service.InventoryReportItem) error {
+			if err := encoder.Encode(item); err != nil {
+				return err
+			}
+			return w.Flush()
+		})
+		if err != nil {
+			slog.Error("Failed to stream inventory report", "error", err)
+			encoder.Encode(fiber.Map{"error": err.Error()})
+			w.Flush()
+		}
+	})
+	return nil
+}
+
+// exportInventoryReport streams every page of the inventory report as a spreadsheet.
+func (h *ReportHandler) exportInventoryReport(c *fiber.Ctx, format string, input service.InventoryReportInput) error {
+	locales, err := resolveExportLocales(c, h.outletService, input.OutletID)
+	if err != nil {
+		return response.Error(c, fiber.StatusInternalServerError, err)
+	}
+
+	input.Cursor = ""
+	input.HistoryLimit = 0
+	columns := []string{"item_name", "outlet_name", "on_hand_quantity", "base_unit", "quantity", "unit"}
+	return streamExport(c, format, "inventory", locales.header, columns, func(ctx context.Context, emit func(...export.Cell) error) error {
+		return h.reportService.StreamInventoryReport(ctx, input, func(item service.InventoryReportItem) error {
+			l := locales.forOutlet(item.OutletID)
+			return emit(
+				export.Text(item.ItemName),
+				export.Text(item.OutletName),
+				l.Integer(int64(item.OnHandQty)),
+				export.Text(item.BaseUnit),
+				l.Number(item.Quantity, 2),
+				export.Text(item.Unit),
+			)
+		})
+	})
+}
+
+// parseDateRange reads the optional from/to query parameters (YYYY-MM-DD).
+// to is inclusive, so it is returned as the start of the following day.
+func parseDateRange(c *fiber.Ctx) (from, to *time.Time, err error) {
+	if fromStr := c.Query("from"); fromStr != "" {
+		parsed, err := time.Parse(time.DateOnly, fromStr)
+		if err != nil {
+			return nil, nil, errors.New("invalid from date, expected YYYY-MM-DD")
+		}
+		from = &parsed
+	}
+
+	if toStr := c.Query("to"); toStr != "" {
+		parsed, err := time.Parse(time.DateOnly, toStr)
+		if err != nil {
+			return nil, nil, errors.New("invalid to date, expected YYYY-MM-DD")
+		}
+		parsed = parsed.AddDate(0, 0, 1)
+		to = &parsed
+	}
+
+	if from != nil && to != nil && !from.Before(*to) {
+		return nil, nil, errors.New("from must not be after to")
+	}
+	return from, to, nil
+}
+
+// GetExpiringReport handles the GET /api/v1/reports/inventory/expiring request.
+// @Summary      Get Expiring Lots Report
+// @Description  List lots with stock left that expire within the given number of days, including already expired lots
+// @Tags         Reports
+// @Accept       json
+// @Produce      json
+// @Param        Authorization header string false "Bearer JWT token"
+// @Param        days query int false "Horizon in days" default(7)
+// @Param        item_id query string false "Filter by specific item ID"
+// @Param        outlet_id query string false "Filter by specific outlet ID"
+// @Param        unit query string false "Render quantities in this unit where the product defines it"
+// @Param        format query string false "csv or xlsx for a spreadsheet"
+// @Param        locale query string false "Locale of the spreadsheet headers, e.g. id-ID"
+// @Success      200      {object}  response.ApiResponse{data=[]service.ExpiringLotItem} "Expiring report generated successfully"
+// @Failure      400      {object}  response.ApiResponse "Bad Request"
+// @Failure      401      {object}  response.ApiResponse "Unauthorized"
+// @Failure      500      {object}  response.ApiResponse "Internal Server Error"
+// @Router       /reports/inventory/expiring [get]
+func (h *ReportHandler) GetExpiringReport(c *fiber.Ctx) error {
+	days, err := strconv.Atoi(c.Query("days", "7"))
+	if err != nil || days < 0 {
+		return response.Error(c, fiber.StatusBadRequest, errors.New("days must be a non-negative integer"))
+	}
+
+	input := service.ExpiringReportInput{Days: days, Unit: c.Query("unit")}
+
+	if itemIDStr := c.Query("item_id"); itemIDStr != "" {
+		itemID, err := uuid.Parse(itemIDStr)
+		if err != nil {
+			return response.Error(c, fiber.StatusBadRequest, err)
+		}
+		input.ItemID = &itemID
+	}
+
+	if outletIDStr := c.Query("outlet_id"); outletIDStr != "" {
+		outletID, err := uuid.Parse(outletIDStr)
+		if err != nil {
+			return response.Error(c, fiber.StatusBadRequest, err)
+		}
+		input.OutletID = &outletID
+	}
+
+	report, err := h.reportService.GenerateExpiringReport(c.Context(), input)
+	if err != nil {
+		return response.Error(c, fiber.StatusInternalServerError, err)
+	}
+
+	if format := exportFormat(c); format != "" {
+		locales, err := resolveExportLocales(c, h.outletService, input.OutletID)
+		if err != nil {
+			return response.Error(c, fiber.StatusInternalServerError, err)
+		}
+		columns := []string{"item_name", "outlet_name", "lot_number", "expiry_date", "days_until_expiry", "on_hand_quantity", "quantity", "unit"}
+		return streamExport(c, format, "expiring-lots", locales.header, columns, func(_ context.Context, emit func(...export.Cell) error) error {
+			for _, item := range report {
+				l := locales.forOutlet(item.OutletID)
+				err := emit(
+					export.Text(item.ItemName),
+					export.Text(item.OutletName),
+					export.Text(item.LotNumber),
+					l.Date(item.ExpiryDate),
+					l.Integer(int64(item.DaysUntilExpiry)),
+					l.Integer(int64(item.OnHandQty)),
+					l.Number(item.Quantity, 2),
+					export.Text(item.Unit),
+				)
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	}
+
+	return response.Success(c, fiber.StatusOK, report)
+}
+
+// GetStockAsOf handles the GET /api/v1/reports/inventory/as-of request.
+// @Summary      Get Stock As Of Date
+// @Description  Compute on-hand quantities per item and outlet as of a point in time, optionally valued at weighted average cost. A bare date means the end of that day.
+// @Tags         Reports
+// @Accept       json
+// @Produce      json
+// @Param        Authorization header string false "Bearer JWT token"
+// @Param        date query string true "Point in time (YYYY-MM-DD or RFC3339)"
+// @Param        item_id query string false "Filter by specific item ID"
+// @Param        outlet_id query string false "Filter by specific outlet ID"
+// @Param        unit query string false "Render quantities in this unit where the product defines it"
+// @Param        include_valuation query bool false "Include valuation at cost"
+// @Param        format query string false "csv or xlsx for a spreadsheet"
+// @Param        locale query string false "Locale of the spreadsheet headers, e.g. id-ID"
+// @Success      200      {object}  response.ApiResponse{data=service.StockAsOfReport} "Stock as of date generated successfully"
+// @Failure      400      {object}  response.ApiResponse "Bad Request"
+// @Failure      401      {object}  response.ApiResponse "Unauthorized"
+// @Failure      500      {object}  response.ApiResponse "Internal Server Error"
+// @Router       /reports/inventory/as-of [get]
+func (h *ReportHandler) GetStockAsOf(c *fiber.Ctx) error {
+	dateStr := c.Query("date")
+	if dateStr == "" {
+		return response.Error(c, fiber.StatusBadRequest, errors.New("date is required"))
+	}
+
+	asOf, err := time.Parse(time.RFC3339, dateStr)
+	if err != nil {
+		day, err := time.ParseInLocation(time.DateOnly, dateStr, time.Local)
+		if err != nil {
+			return response.Error(c, fiber.StatusBadRequest, errors.New("invalid date, expected YYYY-MM-DD or RFC3339"))
+		}
+		// A bare date covers the whole day.
+		asOf = day.AddDate(0, 0, 1).Add(-time.Second)
+	}
+
+	input := service.StockAsOfInput{
+		AsOf:             asOf,
+		Unit:             c.Query("unit"),
+		IncludeValuation: c.QueryBool("include_valuation"),
+	}
+
+	if itemIDStr := c.Query("item_id"); itemIDStr != "" {
+		itemID, err := uuid.Parse(itemIDStr)
+		if err != nil {
+			return response.Error(c, fiber.StatusBadRequest, err)
+		}
+		input.ItemID = &itemID
+	}
+
+	if outletIDStr := c.Query("outlet_id"); outletIDStr != "" {
+		outletID, err := uuid.Parse(outletIDStr)
+		if err != nil {
+			return response.Error(c, fiber.StatusBadRequest, err)
+		}
+		input.OutletID = &outletID
+	}
+
+	report, err := h.reportService.GenerateStockAsOf(c.Context(), input)
+	if err != nil {
+		return response.Error(c, fiber.StatusInternalServerError, err)
+	}
+
+	if format := exportFormat(c); format != "" {
+		locales, err := resolveExportLocales(c, h.outletService, input.OutletID)
+		if err != nil {
+			return response.Error(c, fiber.StatusInternalServerError, err)
+		}
+		columns := []string{"item_name", "outlet_name", "on_hand_quantity", "base_unit", "quantity", "unit"}
+		if input.IncludeValuation {
+			columns = append(columns, "average_unit_cost", "value")
+		}
+		return streamExport(c, format, "stock-as-of", locales.header, columns, func(_ context.Context, emit func(...export.Cell) error) error {
+			for _, item := range report.Items {
+				l := locales.forOutlet(item.OutletID)
+				cells := []export.Cell{
+					export.Text(item.ItemName),
+					export.Text(item.OutletName),
+					l.Integer(int64(item.OnHandQty)),
+					export.Text(item.BaseUnit),
+					l.Number(item.Quantity, 2),
+					export.Text(item.Unit),
+				}
+				if input.IncludeValuation {
+					cells = append(cells, optionalMoney(l, item.AverageUnitCost, 4), optionalMoney(l, item.Value, 2))
+				}
+				if err := emit(cells...); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	}
+
+	return response.Success(c, fiber.StatusOK, report)
+}
+
+// optionalMoney renders an amount that may be unknown as an empty cell.
+func optionalMoney(l export.Locale, amount *float64, decimals int) export.Cell {
+	if amount == nil {
+		return export.Text("")
+	}
+	return l.MoneyDecimal(*amount, decimals)
+}
+
+// GetSalesReport handles the GET /api/v1/reports/sales request.
+// @Summary      Get Sales Report
+// @Description  Bucket paid sales per outlet by day, week (starting Monday) or month. Dates are read in each outlet's time zone. Defaults to the last 30 days.
+// @Tags         Reports
+// @Accept       json
+// @Produce      json
+// @Param        Authorization header string false "Bearer JWT token"
+// @Param        granularity query string false "Bucket size" Enums(day, week, month) default(day)
+// @Param        outlet_id query string false "Filter by specific outlet ID"
+// @Param        from query string false "First day (YYYY-MM-DD)"
+// @Param        to query string false "Last day, inclusive (YYYY-MM-DD)"
+// @Param        format query string false "csv or xlsx for a spreadsheet"
+// @Param        locale query string false "Locale of the spreadsheet headers, e.g. id-ID"
+// @Success      200      {object}  response.ApiResponse{data=[]service.SalesBucket} "Sales report generated successfully"
+// @Failure      400      {object}  response.ApiResponse "Bad Request"
+// @Failure      401      {object}  response.ApiResponse "Unauthorized"
+// @Failure      500      {object}  response.ApiResponse "Internal Server Error"
+// @Router       /reports/sales [get]
+func (h *ReportHandler) GetSalesReport(c *fiber.Ctx) error {
+	input := service.SalesReportInput{Granularity: c.Query("granularity", service.GranularityDay)}
+	switch input.Granularity {
+	case service.GranularityDay, service.GranularityWeek, service.GranularityMonth:
+	default:
+		return response.Error(c, fiber.StatusBadRequest, errors.New("granularity must be one of day, week, month"))
+	}
+
+	if outletIDStr := c.Query("outlet_id"); outletIDStr != "" {
+		outletID, err := uuid.Parse(outletIDStr)
+		if err != nil {
+			return response.Error(c, fiber.StatusBadRequest, err)
+		}
+		input.OutletID = &outletID
+	}
+
+	from, to, err := parseDateRange(c)
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, err)
+	}
+	if to == nil {
+		now := time.Now()
+		tomorrow := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC).AddDate(0, 0, 1)
+		to = &tomorrow
+	}
+	if from == nil {
+		monthAgo := to.AddDate(0, 0, -30)
+		from = &monthAgo
+	}
+	input.From, input.To = *from, *to
+
+	report, err := h.reportService.GenerateSalesReport(c.Context(), input)
+	if err != nil {
+		return response.Error(c, fiber.StatusInternalServerError, err)
+	}
+
+	if format := exportFormat(c); format != "" {
+		locales, err := resolveExportLocales(c, h.outletService, input.OutletID)
+		if err != nil {
+			return response.Error(c, fiber.StatusInternalServerError, err)
+		}
+		columns := []string{"outlet_name", "period_start", "revenue", "transaction_count", "average_basket", "items_sold"}
+		return streamExport(c, format, "sales", locales.header, columns, func(_ context.Context, emit func(...export.Cell) error) error {
+			for _, bucket := range report {
+				l := locales.forOutlet(bucket.OutletID)
+				periodStart, err := time.Parse(time.DateOnly, bucket.PeriodStart)
+				if err != nil {
+					return err
+				}
+				err = emit(
+					export.Text(bucket.OutletName),
+					l.Date(periodStart),
+					l.Money(bucket.Revenue),
+					l.Integer(bucket.TransactionCount),
+					l.MoneyDecimal(bucket.AverageBasket, 2),
+					l.Integer(bucket.ItemsSold),
+				)
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	}
+
+	return response.Success(c, fiber.StatusOK, report)
+}
+
+// GetSummary handles the GET /api/v1/reports/summary request.
+// @Summary      Get Sales Summary
+// @Description  Lifetime totals of paid transactions with products sold per category. updated_at tells when the totals last changed.
+// @Tags         Reports
+// @Accept       json
+// @Produce      json
+// @Param        Authorization header string false "Bearer JWT token"
+// @Success      200      {object}  response.ApiResponse{data=model.TransactionReport} "Sales summary retrieved successfully"
+// @Failure      401      {object}  response.ApiResponse "Unauthorized"
+// @Failure      500      {object}  response.ApiResponse "Internal Server Error"
+// @Router       /reports/summary [get]
+func (h *ReportHandler) GetSummary(c *fiber.Ctx) error {
+	report, err := h.reportService.GetSummary(c.Context())
 	if err != nil {
 		return response.Error(c, fiber.StatusInternalServerError, err)
 	}
 
 	return response.Success(c, fiber.StatusOK, report)
 }
+
+// parseProductReportFilter reads the outlet_id, from, to and limit query parameters.
+func parseProductReportFilter(c *fiber.Ctx) (service.ProductReportFilter, error) {
+	var filter service.ProductReportFilter
+
+	if outletIDStr := c.Query("outlet_id"); outletIDStr != "" {
+		outletID, err := uuid.Parse(outletIDStr)
+		if err != nil {
+			return filter, err
+		}
+		filter.OutletID = &outletID
+	}
+
+	from, to, err := parseDateRange(c)
+	if err != nil {
+		return filter, err
+	}
+	filter.From, filter.To = from, to
+
+	limit, err := strconv.Atoi(c.Query("limit", strconv.Itoa(service.DefaultProductReportLimit)))
+	if err != nil || limit < 1 {
+		limit = service.DefaultProductReportLimit
+	}
+	filter.Limit = min(limit, service.MaxProductReportLimit)
+
+	return filter, nil
+}
+
+// GetTopProducts handles the GET /api/v1/reports/products/top request.
+// @Summary      Get Top Products
+// @Description  Rank products by quantity sold or revenue over paid transactions
+// @Tags         Reports
+// @Accept       json
+// @Produce      json
+// @Param        Authorization header string false "Bearer JWT token"
+// @Param        by query string false "Ranking" Enums(quantity, revenue) default(quantity)
+// @Param        outlet_id query string false "Filter by specific outlet ID"
+// @Param        from query string false "First day (YYYY-MM-DD)"
+// @Param        to query string false "Last day, inclusive (YYYY-MM-DD)"
+// @Param        limit query int false "Number of products" default(10)
+// @Param        format query string false "csv or xlsx for a spreadsheet"
+// @Param        locale query string false "Locale of the spreadsheet headers, e.g. id-ID"
+// @Success      200      {object}  response.ApiResponse{data=[]service.ProductSales} "Top products generated successfully"
+// @Failure      400      {object}  response.ApiResponse "Bad Request"
+// @Failure      401      {object}  response.ApiResponse "Unauthorized"
+// @Failure      500      {object}  response.ApiResponse "Internal Server Error"
+// @Router       /reports/products/top [get]
+func (h *ReportHandler) GetTopProducts(c *fiber.Ctx) error {
+	rankBy := c.Query("by", service.RankByQuantity)
+	if rankBy != service.RankByQuantity && rankBy != service.RankByRevenue {
+		return response.Error(c, fiber.StatusBadRequest, errors.New("by must be quantity or revenue"))
+	}
+
+	filter, err := parseProductReportFilter(c)
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, err)
+	}
+
+	report, err := h.reportService.GenerateTopProducts(c.Context(), filter, rankBy)
+	if err != nil {
+		return response.Error(c, fiber.StatusInternalServerError, err)
+	}
+
+	if format := exportFormat(c); format != "" {
+		locales, err := resolveExportLocales(c, h.outletService, filter.OutletID)
+		if err != nil {
+			return response.Error(c, fiber.StatusInternalServerError, err)
+		}
+		columns := []string{"product_name", "quantity_sold", "revenue", "transaction_count"}
+		return streamExport(c, format, "top-products", locales.header, columns, func(_ context.Context, emit func(...export.Cell) error) error {
+			l := locales.header
+			for _, sale := range report {
+				if err := emit(export.Text(sale.ProductName), l.Integer(sale.QuantitySold), l.Money(sale.Revenue), l.Integer(sale.Transactions)); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	}
+
+	return response.Success(c, fiber.StatusOK, report)
+}
+
+// GetSlowMovers handles the GET /api/v1/reports/products/slow-movers request.
+// @Summary      Get Slow Movers
+// @Description  List products without paid sales in the last N days, never-sold products first
+// @Tags         Reports
+// @Accept       json
+// @Produce      json
+// @Param        Authorization header string false "Bearer JWT token"
+// @Param        days query int false "Days without sales" default(30)
+// @Param        outlet_id query string false "Filter by specific outlet ID"
+// @Param        limit query int false "Number of products" default(10)
+// @Param        format query string false "csv or xlsx for a spreadsheet"
+// @Param        locale query string false "Locale of the spreadsheet headers, e.g. id-ID"
+// @Success      200      {object}  response.ApiResponse{data=[]service.SlowMover} "Slow movers generated successfully"
+// @Failure      400      {object}  response.ApiResponse "Bad Request"
+// @Failure      401      {object}  response.ApiResponse "Unauthorized"
+// @Failure      500      {object}  response.ApiResponse "Internal Server Error"
+// @Router       /reports/products/slow-movers [get]
+func (h *ReportHandler) GetSlowMovers(c *fiber.Ctx) error {
+	days, err := strconv.Atoi(c.Query("days", "30"))
+	if err != nil || days < 1 {
+		return response.Error(c, fiber.StatusBadRequest, errors.New("days must be a positive integer"))
+	}
+
+	filter, err := parseProductReportFilter(c)
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, err)
+	}
+
+	report, err := h.reportService.GenerateSlowMovers(c.Context(), filter, days)
+	if err != nil {
+		return response.Error(c, fiber.StatusInternalServerError, err)
+	}
+
+	if format := exportFormat(c); format != "" {
+		locales, err := resolveExportLocales(c, h.outletService, filter.OutletID)
+		if err != nil {
+			return response.Error(c, fiber.StatusInternalServerError, err)
+		}
+		columns := []string{"product_name", "last_sold_at", "days_since_last_sale", "on_hand_quantity", "base_unit"}
+		return streamExport(c, format, "slow-movers", locales.header, columns, func(_ context.Context, emit func(...export.Cell) error) error {
+			l := locales.header
+			for _, mover := range report {
+				lastSold, idle := export.Text(""), export.Text("")
+				if mover.LastSoldAt != nil {
+					lastSold = l.Date(*mover.LastSoldAt)
+					idle = l.Integer(int64(*mover.DaysSinceLastSale))
+				}
+				if err := emit(export.Text(mover.ProductName), lastSold, idle, l.Integer(mover.OnHandQty), export.Text(mover.BaseUnit)); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	}
+
+	return response.Success(c, fiber.StatusOK, report)
+}
+
+// GetABCAnalysis handles the GET /api/v1/reports/products/abc request.
+// @Summary      Get ABC Analysis
+// @Description  Classify products per outlet by revenue share: A for the first 80%, B for the next 15%, C for the last 5%
+// @Tags         Reports
+// @Accept       json
+// @Produce      json
+// @Param        Authorization header string false "Bearer JWT token"
+// @Param        outlet_id query string false "Filter by specific outlet ID"
+// @Param        from query string false "First day (YYYY-MM-DD)"
+// @Param        to query string false "Last day, inclusive (YYYY-MM-DD)"
+// @Param        limit query int false "Products listed per outlet after classification"
+// @Param        format query string false "csv or xlsx for a spreadsheet"
+// @Param        locale query string false "Locale of the spreadsheet headers, e.g. id-ID"
+// @Success      200      {object}  response.ApiResponse{data=[]service.ABCOutlet} "ABC analysis generated successfully"
+// @Failure      400      {object}  response.ApiResponse "Bad Request"
+// @Failure      401      {object}  response.ApiResponse "Unauthorized"
+// @Failure      500      {object}  response.ApiResponse "Internal Server Error"
+// @Router       /reports/products/abc [get]
+func (h *ReportHandler) GetABCAnalysis(c *fiber.Ctx) error {
+	filter, err := parseProductReportFilter(c)
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, err)
+	}
+	if c.Query("limit") == "" {
+		filter.Limit = 0 // Classification lists every product unless asked otherwise
+	}
+
+	report, err := h.reportService.GenerateABCAnalysis(c.Context(), filter)
+	if err != nil {
+		return response.Error(c, fiber.StatusInternalServerError, err)
+	}
+
+	if format := exportFormat(c); format != "" {
+		locales, err := resolveExportLocales(c, h.outletService, filter.OutletID)
+		if err != nil {
+			return response.Error(c, fiber.StatusInternalServerError, err)
+		}
+		columns := []string{"outlet_name", "product_name", "class", "revenue", "revenue_share", "cumulative_share", "quantity_sold"}
+		return streamExport(c, format, "abc-analysis", locales.header, columns, func(_ context.Context, emit func(...export.Cell) error) error {
+			for _, outlet := range report {
+				l := locales.forOutlet(outlet.OutletID)
+				for _, item := range outlet.Items {
+					err := emit(
+						export.Text(outlet.OutletName),
+						export.Text(item.ProductName),
+						export.Text(item.Class),
+						l.Money(item.Revenue),
+						l.Percent(item.RevenueShare),
+						l.Percent(item.CumulativeShare),
+						l.Integer(item.QuantitySold),
+					)
+					if err != nil {
+						return err
+					}
+				}
+			}
+			return nil
+		})
+	}
+
+	return response.Success(c, fiber.StatusOK, report)
+}
@@ -0,0 +1,253 @@
+package http
+
+import (
+	"errors"
+	"strings"
+	"venturo-core/internal/service"
+	"venturo-core/pkg/response"
+	"venturo-core/pkg/validator"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type ShiftHandler struct {
+	shiftService *service.ShiftService
+}
+
+// NewShiftHandler creates a new shift handler.
+func NewShiftHandler(s *service.ShiftService) *ShiftHandler {
+	return &ShiftHandler{shiftService: s}
+}
+
+// OpenShiftPayload defines the expected JSON for opening a shift.
+type OpenShiftPayload struct {
+	OutletID     uuid.UUID `json:"outlet_id" validate:"required"`
+	OpeningFloat int64     `json:"opening_float" validate:"min=0"`
+	Note         string    `json:"note"`
+}
+
+// CashMovementPayload defines the expected JSON for a pay-in or pay-out.
+type CashMovementPayload struct {
+	Type   string `json:"type" validate:"required,oneof=pay_in pay_out"`
+	Amount int64  `json:"amount" validate:"required,min=1"`
+	Reason string `json:"reason" validate:"max=255"`
+}
+
+// CloseShiftPayload defines the expected JSON for closing a shift.
+type CloseShiftPayload struct {
+	CountedCash *int64 `json:"counted_cash" validate:"required,min=0"`
+	Note        string `json:"note"`
+}
+
+// shiftError maps shift service errors to HTTP responses.
+func shiftError(c *fiber.Ctx, err error) error {
+	switch {
+	case strings.Contains(err.Error(), "not found"):
+		return response.Error(c, fiber.StatusNotFound, err)
+	case errors.Is(err, service.ErrNotShiftOwner):
+		return response.Error(c, fiber.StatusForbidden, err)
+	case errors.Is(err, service.ErrShiftAlreadyOpen), errors.Is(err, service.ErrShiftClosed):
+		return response.Error(c, fiber.StatusConflict, err)
+	default:
+		return response.Error(c, fiber.StatusInternalServerError, err)
+	}
+}
+
+// OpenShift handles the POST /api/v1/shifts request.
+// @Summary      Open a shift
+// @Description  Opens a cash drawer shift for the authenticated cashier at an outlet with an opening float.
+// @Tags         Shifts
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        payload  body      OpenShiftPayload  true  "Shift Payload"
+// @Success      201      {object}  response.ApiResponse{data=model.Shift} "Successfully opened shift"
+// @Failure      400      {object}  response.ApiResponse "Bad Request"
+// @Failure      401      {object}  response.ApiResponse "Unauthorized"
+// @Failure      404      {object}  response.ApiResponse "Outlet not found"
+// @Failure      409      {object}  response.ApiResponse "Shift already open"
+// @Router       /shifts [post]
+func (h *ShiftHandler) OpenShift(c *fiber.Ctx) error {
+	userID, ok := c.Locals("current_user_id").(uuid.UUID)
+	if !ok {
+		return response.Error(c, fiber.StatusUnauthorized, errors.New("unauthorized"))
+	}
+
+	payload := new(OpenShiftPayload)
+	if err := c.BodyParser(payload); err != nil {
+		return response.Error(c, fiber.StatusBadRequest, errors.New("cannot parse JSON"))
+	}
+
+	if errs := validator.ValidateStruct(payload); errs != nil {
+		return response.ValidationError(c, errs)
+	}
+
+	shift, err := h.shiftService.OpenShift(c.Context(), service.OpenShiftInput{
+		OutletID:     payload.OutletID,
+		UserID:       userID,
+		OpeningFloat: payload.OpeningFloat,
+		Note:         payload.Note,
+	})
+	if err != nil {
+		return shiftError(c, err)
+	}
+
+	return response.Success(c, fiber.StatusCreated, shift)
+}
+
+// GetCurrentShift handles the GET /api/v1/shifts/current request.
+// @Summary      Get the current shift
+// @Description  Retrieves the authenticated cashier's open shift at an outlet.
+// @Tags         Shifts
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        outlet_id  query     string  true  "Outlet ID"
+// @Success      200        {object}  response.ApiResponse{data=model.Shift} "Successfully retrieved shift"
+// @Failure      400        {object}  response.ApiResponse "Bad Request"
+// @Failure      401        {object}  response.ApiResponse "Unauthorized"
+// @Failure      404        {object}  response.ApiResponse "No open shift"
+// @Router       /shifts/current [get]
+func (h *ShiftHandler) GetCurrentShift(c *fiber.Ctx) error {
+	userID, ok := c.Locals("current_user_id").(uuid.UUID)
+	if !ok {
+		return response.Error(c, fiber.StatusUnauthorized, errors.New("unauthorized"))
+	}
+
+	outletID, err := uuid.Parse(c.Query("outlet_id"))
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, errors.New("outlet_id is required"))
+	}
+
+	shift, err := h.shiftService.GetCurrentShift(c.Context(), outletID, userID)
+	if err != nil {
+		return shiftError(c, err)
+	}
+
+	return response.Success(c, fiber.StatusOK, shift)
+}
+
+// RecordCashMovement handles the POST /api/v1/shifts/:id/cash-movements request.
+// @Summary      Record a pay-in or pay-out
+// @Description  Records cash put into or taken out of an open shift's drawer outside of sales.
+// @Tags         Shifts
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id       path      string               true  "Shift ID"
+// @Param        payload  body      CashMovementPayload  true  "Cash Movement Payload"
+// @Success      201      {object}  response.ApiResponse{data=model.ShiftCashMovement} "Successfully recorded cash movement"
+// @Failure      400      {object}  response.ApiResponse "Bad Request"
+// @Failure      401      {object}  response.ApiResponse "Unauthorized"
+// @Failure      403      {object}  response.ApiResponse "Shift belongs to another cashier"
+// @Failure      404      {object}  response.ApiResponse "Shift not found"
+// @Failure      409      {object}  response.ApiResponse "Shift is closed"
+// @Router       /shifts/{id}/cash-movements [post]
+func (h *ShiftHandler) RecordCashMovement(c *fiber.Ctx) error {
+	userID, ok := c.Locals("current_user_id").(uuid.UUID)
+	if !ok {
+		return response.Error(c, fiber.StatusUnauthorized, errors.New("unauthorized"))
+	}
+
+	shiftID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, errors.New("invalid ID format"))
+	}
+
+	payload := new(CashMovementPayload)
+	if err := c.BodyParser(payload); err != nil {
+		return response.Error(c, fiber.StatusBadRequest, errors.New("cannot parse JSON"))
+	}
+
+	if errs := validator.ValidateStruct(payload); errs != nil {
+		return response.ValidationError(c, errs)
+	}
+
+	movement, err := h.shiftService.RecordCashMovement(c.Context(), service.CashMovementInput{
+		ShiftID: shiftID,
+		UserID:  userID,
+		Type:    payload.Type,
+		Amount:  payload.Amount,
+		Reason:  payload.Reason,
+	})
+	if err != nil {
+		return shiftError(c, err)
+	}
+
+	return response.Success(c, fiber.StatusCreated, movement)
+}
+
+// GetShiftReport handles the GET /api/v1/shifts/:id/report request.
+// @Summary      Get a shift report
+// @Description  Returns the X report of an open shift, or the Z report of a closed one, with totals per payment method and expected cash.
+// @Tags         Shifts
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id   path      string  true  "Shift ID"
+// @Success      200  {object}  response.ApiResponse{data=service.ShiftReport} "Successfully generated shift report"
+// @Failure      400  {object}  response.ApiResponse "Bad Request"
+// @Failure      401  {object}  response.ApiResponse "Unauthorized"
+// @Failure      404  {object}  response.ApiResponse "Shift not found"
+// @Router       /shifts/{id}/report [get]
+func (h *ShiftHandler) GetShiftReport(c *fiber.Ctx) error {
+	shiftID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, errors.New("invalid ID format"))
+	}
+
+	report, err := h.shiftService.GetShiftReport(c.Context(), shiftID)
+	if err != nil {
+		return shiftError(c, err)
+	}
+
+	return response.Success(c, fiber.StatusOK, report)
+}
+
+// CloseShift handles the POST /api/v1/shifts/:id/close request.
+// @Summary      Close a shift
+// @Description  Closes a shift with the counted cash and returns the Z report with expected cash and variance.
+// @Tags         Shifts
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id       path      string             true  "Shift ID"
+// @Param        payload  body      CloseShiftPayload  true  "Close Shift Payload"
+// @Success      200      {object}  response.ApiResponse{data=service.ShiftReport} "Successfully closed shift"
+// @Failure      400      {object}  response.ApiResponse "Bad Request"
+// @Failure      401      {object}  response.ApiResponse "Unauthorized"
+// @Failure      403      {object}  response.ApiResponse "Shift belongs to another cashier"
+// @Failure      404      {object}  response.ApiResponse "Shift not found"
+// @Failure      409      {object}  response.ApiResponse "Shift is closed"
+// @Router       /shifts/{id}/close [post]
+func (h *ShiftHandler) CloseShift(c *fiber.Ctx) error {
+	userID, ok := c.Locals("current_user_id").(uuid.UUID)
+	if !ok {
+		return response.Error(c, fiber.StatusUnauthorized, errors.New("unauthorized"))
+	}
+
+	shiftID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, errors.New("invalid ID format"))
+	}
+
+	payload := new(CloseShiftPayload)
+	if err := c.BodyParser(payload); err != nil {
+		return response.Error(c, fiber.StatusBadRequest, errors.New("cannot parse JSON"))
+	}
+
+	if errs := validator.ValidateStruct(payload); errs != nil {
+		return response.ValidationError(c, errs)
+	}
+
+	report, err := h.shiftService.CloseShift(c.Context(), service.CloseShiftInput{
+		ShiftID:     shiftID,
+		UserID:      userID,
+		CountedCash: *payload.CountedCash,
+		Note:        payload.Note,
+	})
+	if err != nil {
+		return shiftError(c, err)
+	}
+
+	return response.Success(c, fiber.StatusOK, report)
+}
@@ -1,10 +1,13 @@
 package http
 
 import (
+	"context"
 	"errors"
+	"strconv"
 	"strings"
 	"venturo-core/internal/model"
 	"venturo-core/internal/service"
+	"venturo-core/pkg/export"
 	"venturo-core/pkg/response"
 	"venturo-core/pkg/validator"
 
@@ -14,21 +17,25 @@ import (
 
 type TransactionHandler struct {
 	transactionService *service.TransactionService
+	outletService      *service.OutletService
 }
 
-func NewTransactionHandler(s *service.TransactionService) *TransactionHandler {
-	return &TransactionHandler{transactionService: s}
+func NewTransactionHandler(s *service.TransactionService, outletService *service.OutletService) *TransactionHandler {
+	return &TransactionHandler{transactionService: s, outletService: outletService}
 }
 
 // generateInvoiceCode generates a unique invoice code.
 type CreateTransactionPayload struct {
-	OutletID uuid.UUID `json:"outlet_id" validate:"required"`
-	Items    []struct {
+	OutletID   uuid.UUID  `json:"outlet_id" validate:"required"`
+	CustomerID *uuid.UUID `json:"customer_id"` // Optional member making the purchase
+	Items      []struct {
 		ProductID   uuid.UUID `json:"product_id" validate:"required"`
 		ProductName string    `json:"product_name" validate:"required"`
 		Category    uint8     `json:"category" validate:"required,min=1,max=3"`
 		Qty         int8      `json:"qty" validate:"required,min=1"`
 		Price       int32     `json:"price" validate:"required,min=0"`
+		Unit        string    `json:"unit" validate:"max=20"` // Defaults to the product's sales unit
+		LotNumber   string    `json:"lot_number" validate:"max=50"`
 	} `json:"items" validate:"required,min=1"`
 	Note string `json:"note"`
 }
@@ -63,61 +70,242 @@ func (h *TransactionHandler) CreateTransaction(c *fiber.Ctx) error {
 
 	// Map payload to service input
 	serviceInput := service.CreateTransactionInput{
-		UserID:   userID,
-		OutletID: payload.OutletID,
-		Note:     payload.Note,
+		UserID:     userID,
+		OutletID:   payload.OutletID,
+		CustomerID: payload.CustomerID,
+		Note:       payload.Note,
 	}
 
 	for _, item := range payload.Items {
-		serviceInput.Items = append(serviceInput.Items, struct {
-			ProductID   uuid.UUID
-			ProductName string
-			Category    model.ProductCategory
-			Qty         int8
-			Price       int32
-		}{
+		serviceInput.Items = append(serviceInput.Items, service.CreateTransactionItem{
 			ProductID:   item.ProductID,
 			ProductName: item.ProductName,
 			Category:    model.ProductCategory(item.Category),
 			Qty:         item.Qty,
 			Price:       item.Price,
+			Unit:        item.Unit,
+			LotNumber:   item.LotNumber,
 		})
 	}
 
 	transaction, err := h.transactionService.CreateTransaction(c.Context(), serviceInput)
 	if err != nil {
+		if errors.Is(err, service.ErrNoOpenShift) {
+			return response.Error(c, fiber.StatusConflict, err)
+		}
+		if strings.Contains(err.Error(), "customer not found") || errors.Is(err, service.ErrCompositeLot) {
+			return response.Error(c, fiber.StatusBadRequest, err)
+		}
 		return response.Error(c, fiber.StatusInternalServerError, err)
 	}
 
 	return response.Success(c, fiber.StatusCreated, transaction)
 }
 
+// MarkAsPaidPayload lists the tenders used to pay. It may be omitted to pay the full total in cash.
+type MarkAsPaidPayload struct {
+	Payments []struct {
+		Method string `json:"method" validate:"required,oneof=cash card qris transfer points"`
+		Amount int64  `json:"amount" validate:"required,min=1"`
+	} `json:"payments" validate:"dive"`
+}
+
 // MarkAsPaid handles the request to mark a transaction as paid.
 // @Summary      Pay for a Transaction
-// @Description  Marks a transaction as paid and triggers a background report update.
+// @Description  Records the payments, marks the transaction as paid and adds it to the sales reports. Payments must add up to the total and are attached to the cashier's open shift. A points payment redeems the customer's loyalty points at the point value; the rest of the total earns points.
 // @Tags         Transactions
+// @Accept       json
 // @Produce      json
 // @Security     ApiKeyAuth
-// @Param        id   path      string  true  "Transaction ID"
+// @Param        id       path      string             true   "Transaction ID"
+// @Param        payload  body      MarkAsPaidPayload  false  "Payments, defaults to the full total in cash"
 // @Success      200  {object}  response.ApiResponse "Successfully paid"
+// @Failure      400  {object}  response.ApiResponse "Bad Request"
 // @Failure      401  {object}  response.ApiResponse "Unauthorized"
 // @Failure      404  {object}  response.ApiResponse "Transaction not found"
+// @Failure      409  {object}  response.ApiResponse "Transaction already paid, no open shift or not enough points"
 // @Router       /transactions/{id}/pay [post]
 func (h *TransactionHandler) MarkAsPaid(c *fiber.Ctx) error {
+	userID, ok := c.Locals("current_user_id").(uuid.UUID)
+	if !ok {
+		return response.Error(c, fiber.StatusUnauthorized, errors.New("unauthorized"))
+	}
+
 	idParam := c.Params("id")
 	transactionID, err := uuid.Parse(idParam)
 	if err != nil {
 		return response.Error(c, fiber.StatusBadRequest, errors.New("invalid ID format"))
 	}
 
-	err = h.transactionService.MarkAsPaid(c.Context(), transactionID)
+	payload := new(MarkAsPaidPayload)
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(payload); err != nil {
+			return response.Error(c, fiber.StatusBadRequest, errors.New("cannot parse JSON"))
+		}
+		if errs := validator.ValidateStruct(payload); errs != nil {
+			return response.ValidationError(c, errs)
+		}
+	}
+
+	serviceInput := service.MarkAsPaidInput{TransactionID: transactionID, UserID: userID}
+	for _, p := range payload.Payments {
+		serviceInput.Payments = append(serviceInput.Payments, service.PaymentInput{Method: p.Method, Amount: p.Amount})
+	}
+
+	err = h.transactionService.MarkAsPaid(c.Context(), serviceInput)
 	if err != nil {
 		// Differentiate between not found and other errors
 		if strings.Contains(err.Error(), "not found") {
 			return response.Error(c, fiber.StatusNotFound, err)
 		}
+		if errors.Is(err, service.ErrAlreadyPaid) || errors.Is(err, service.ErrNoOpenShift) ||
+			errors.Is(err, service.ErrLoyaltyDisabled) || errors.Is(err, service.ErrInsufficientPoints) {
+			return response.Error(c, fiber.StatusConflict, err)
+		}
+		if strings.Contains(err.Error(), "add up to") || strings.Contains(err.Error(), "points") {
+			return response.Error(c, fiber.StatusBadRequest, err)
+		}
 		return response.Error(c, fiber.StatusInternalServerError, err)
 	}
 
-	return response.Success(c, fiber.StatusOK, fiber.Map{"message": "Transaction marked as paid. Report is updating."})
+	return response.Success(c, fiber.StatusOK, fiber.Map{"message": "Transaction marked as paid."})
+}
+
+// Refund handles the POST /api/v1/transactions/:id/refund request.
+// @Summary      Refund a transaction
+// @Description  Refunds a paid transaction in full. Every payment is returned with the same method on the cashier's open shift, the stock goes back to inventory, the customer's loyalty points are reversed and the sale is taken out of the sales reports.
+// @Tags         Transactions
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id   path      string  true  "Transaction ID"
+// @Success      200  {object}  response.ApiResponse{data=model.Transaction} "Successfully refunded"
+// @Failure      400  {object}  response.ApiResponse "Bad Request"
+// @Failure      401  {object}  response.ApiResponse "Unauthorized"
+// @Failure      404  {object}  response.ApiResponse "Transaction not found"
+// @Failure      409  {object}  response.ApiResponse "Transaction not paid, already refunded or no open shift"
+// @Router       /transactions/{id}/refund [post]
+func (h *TransactionHandler) Refund(c *fiber.Ctx) error {
+	userID, ok := c.Locals("current_user_id").(uuid.UUID)
+	if !ok {
+		return response.Error(c, fiber.StatusUnauthorized, errors.New("unauthorized"))
+	}
+
+	transactionID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, errors.New("invalid ID format"))
+	}
+
+	transaction, err := h.transactionService.Refund(c.Context(), service.RefundInput{TransactionID: transactionID, UserID: userID})
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return response.Error(c, fiber.StatusNotFound, err)
+		}
+		if errors.Is(err, service.ErrNotPaid) || errors.Is(err, service.ErrAlreadyRefunded) || errors.Is(err, service.ErrNoOpenShift) {
+			return response.Error(c, fiber.StatusConflict, err)
+		}
+		return response.Error(c, fiber.StatusInternalServerError, err)
+	}
+
+	return response.Success(c, fiber.StatusOK, transaction)
+}
+
+// GetTransactions handles the GET /api/v1/transactions request.
+// @Summary      List transactions
+// @Description  Retrieves a paginated list of transactions, newest first. Send format=csv|xlsx (or Accept: text/csv) to export every matching transaction instead.
+// @Tags         Transactions
+// @Produce      json
+// @Produce      text/csv
+// @Security     ApiKeyAuth
+// @Param        outlet_id  query     string  false  "Filter by specific outlet ID"
+// @Param        is_paid    query     bool    false  "Filter by payment status"
+// @Param        from       query     string  false  "First day (YYYY-MM-DD)"
+// @Param        to         query     string  false  "Last day, inclusive (YYYY-MM-DD)"
+// @Param        page       query     int     false  "Page number for pagination" default(1)
+// @Param        limit      query     int     false  "Number of items per page" default(10)
+// @Param        format     query     string  false  "csv or xlsx for a spreadsheet"
+// @Param        locale     query     string  false  "Locale of the spreadsheet headers, e.g. id-ID"
+// @Success      200        {object}  response.ApiResponse{data=[]model.Transaction} "Successfully retrieved transactions"
+// @Failure      400        {object}  response.ApiResponse "Bad Request"
+// @Failure      401        {object}  response.ApiResponse "Unauthorized"
+// @Failure      500        {object}  response.ApiResponse "Internal Server Error"
+// @Router       /transactions [get]
+func (h *TransactionHandler) GetTransactions(c *fiber.Ctx) error {
+	var input service.ListTransactionsInput
+
+	if outletIDStr := c.Query("outlet_id"); outletIDStr != "" {
+		outletID, err := uuid.Parse(outletIDStr)
+		if err != nil {
+			return response.Error(c, fiber.StatusBadRequest, err)
+		}
+		input.OutletID = &outletID
+	}
+
+	if isPaidStr := c.Query("is_paid"); isPaidStr != "" {
+		isPaid, err := strconv.ParseBool(isPaidStr)
+		if err != nil {
+			return response.Error(c, fiber.StatusBadRequest, errors.New("is_paid must be true or false"))
+		}
+		input.IsPaid = &isPaid
+	}
+
+	var err error
+	input.From, input.To, err = parseDateRange(c)
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, err)
+	}
+
+	if format := exportFormat(c); format != "" {
+		return h.exportTransactions(c, format, input)
+	}
+
+	input.Page, err = strconv.Atoi(c.Query("page", "1"))
+	if err != nil || input.Page < 1 {
+		input.Page = 1
+	}
+
+	input.Limit, err = strconv.Atoi(c.Query("limit", "10"))
+	if err != nil || input.Limit < 1 {
+		input.Limit = 10
+	}
+	if input.Limit > 100 { // Set a max limit
+		input.Limit = 100
+	}
+
+	transactions, total, err := h.transactionService.ListTransactions(c.Context(), input)
+	if err != nil {
+		return response.Error(c, fiber.StatusInternalServerError, errors.New("could not retrieve transactions"))
+	}
+
+	return response.Pagination(c, transactions, input.Page, input.Limit, total)
+}
+
+// exportTransactions streams every matching transaction as a spreadsheet.
+// Dates are shown in each outlet's time zone.
+func (h *TransactionHandler) exportTransactions(c *fiber.Ctx, format string, input service.ListTransactionsInput) error {
+	locales, err := resolveExportLocales(c, h.outletService, input.OutletID)
+	if err != nil {
+		return response.Error(c, fiber.StatusInternalServerError, err)
+	}
+
+	columns := []string{"invoice_code", "created_at", "outlet_name", "total", "is_paid", "paid_at", "note"}
+	return streamExport(c, format, "transactions", locales.header, columns, func(ctx context.Context, emit func(...export.Cell) error) error {
+		return h.transactionService.StreamTransactions(ctx, input, func(t model.Transaction) error {
+			l := locales.forOutlet(t.OutletID)
+			loc := t.Outlet.Location()
+
+			paidAt := export.Text("")
+			if t.PaidAt != nil {
+				paidAt = l.DateTime(t.PaidAt.In(loc))
+			}
+			return emit(
+				export.Text(t.InvoiceCode),
+				l.DateTime(t.CreatedAt.In(loc)),
+				export.Text(t.Outlet.Name),
+				l.Money(t.Total),
+				l.Bool(t.IsPaid != nil && *t.IsPaid),
+				paidAt,
+				export.Text(t.Note),
+			)
+		})
+	})
 }
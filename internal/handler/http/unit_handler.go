@@ -0,0 +1,74 @@
+package http
+
+import (
+	"errors"
+	"venturo-core/internal/service"
+	"venturo-core/pkg/response"
+	"venturo-core/pkg/validator"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type UnitHandler struct {
+	unitService *service.UnitService
+}
+
+// NewUnitHandler creates a new unit handler.
+func NewUnitHandler(s *service.UnitService) *UnitHandler {
+	return &UnitHandler{unitService: s}
+}
+
+// CreateUnitPayload defines the expected JSON for adding a unit to the catalog.
+type CreateUnitPayload struct {
+	Code string `json:"code" validate:"required,max=20"`
+	Name string `json:"name" validate:"required,max=100"`
+}
+
+// GetAllUnits handles the GET /api/v1/units request.
+// @Summary      List units of measure
+// @Description  Retrieves the units-of-measure catalog.
+// @Tags         Units
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Success      200  {object}  response.ApiResponse{data=[]model.Unit} "Successfully retrieved units"
+// @Failure      401  {object}  response.ApiResponse "Unauthorized"
+// @Failure      500  {object}  response.ApiResponse "Internal Server Error"
+// @Router       /units [get]
+func (h *UnitHandler) GetAllUnits(c *fiber.Ctx) error {
+	units, err := h.unitService.GetAllUnits(c.Context())
+	if err != nil {
+		return response.Error(c, fiber.StatusInternalServerError, errors.New("could not retrieve units"))
+	}
+
+	return response.Success(c, fiber.StatusOK, units)
+}
+
+// CreateUnit handles the POST /api/v1/units request.
+// @Summary      Create a unit of measure
+// @Description  Adds a unit to the units-of-measure catalog.
+// @Tags         Units
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        payload  body      CreateUnitPayload  true  "Unit Payload"
+// @Success      201      {object}  response.ApiResponse{data=model.Unit} "Successfully created unit"
+// @Failure      400      {object}  response.ApiResponse "Bad Request"
+// @Failure      401      {object}  response.ApiResponse "Unauthorized"
+// @Router       /units [post]
+func (h *UnitHandler) CreateUnit(c *fiber.Ctx) error {
+	payload := new(CreateUnitPayload)
+	if err := c.BodyParser(payload); err != nil {
+		return response.Error(c, fiber.StatusBadRequest, errors.New("cannot parse JSON"))
+	}
+
+	if errs := validator.ValidateStruct(payload); errs != nil {
+		return response.ValidationError(c, errs)
+	}
+
+	unit, err := h.unitService.CreateUnit(c.Context(), payload.Code, payload.Name)
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, err)
+	}
+
+	return response.Success(c, fiber.StatusCreated, unit)
+}
@@ -0,0 +1,223 @@
+package http
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"venturo-core/internal/model"
+	"venturo-core/internal/service"
+	"venturo-core/pkg/imaging"
+	"venturo-core/pkg/response"
+	"venturo-core/pkg/validator"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// Headers of chunked uploads, named after the tus protocol.
+const (
+	headerUploadOffset = "Upload-Offset"
+	headerUploadLength = "Upload-Length"
+
+	// chunkContentType is the content type of a chunk request body.
+	chunkContentType = "application/offset+octet-stream"
+)
+
+type UploadHandler struct {
+	uploadService *service.UploadService
+}
+
+// NewUploadHandler creates a new upload handler.
+func NewUploadHandler(s *service.UploadService) *UploadHandler {
+	return &UploadHandler{uploadService: s}
+}
+
+// CreateUploadPayload defines the expected JSON for reserving an upload.
+type CreateUploadPayload struct {
+	Purpose     string     `json:"purpose" validate:"required,oneof=product_image avatar"`
+	ProductID   *uuid.UUID `json:"product_id"` // Required for product_image
+	FileName    string     `json:"file_name" validate:"max=255"`
+	ContentType string     `json:"content_type" validate:"required,oneof=image/jpeg image/png image/webp"`
+	Size        int64      `json:"size" validate:"required,gt=0"`
+	Checksum    string     `json:"checksum" validate:"required,len=64,hexadecimal"` // SHA-256 of the file
+}
+
+// uploadError maps upload service errors to HTTP responses.
+func uploadError(c *fiber.Ctx, err error) error {
+	switch {
+	case strings.Contains(err.Error(), "not found"):
+		return response.Error(c, fiber.StatusNotFound, err)
+	case errors.Is(err, service.ErrUploadNotPending), errors.Is(err, service.ErrUploadOffset):
+		return response.Error(c, fiber.StatusConflict, err)
+	case errors.Is(err, service.ErrUploadTooLarge):
+		return response.Error(c, fiber.StatusRequestEntityTooLarge, err)
+	case errors.Is(err, service.ErrUploadNotChunked), errors.Is(err, service.ErrUploadIncomplete),
+		errors.Is(err, service.ErrUploadMismatch), errors.Is(err, imaging.ErrInvalidImage):
+		return response.Error(c, fiber.StatusBadRequest, err)
+	default:
+		return response.Error(c, fiber.StatusInternalServerError, err)
+	}
+}
+
+// setUploadHeaders reports a chunked upload's progress the way tus clients expect.
+func setUploadHeaders(c *fiber.Ctx, upload *model.Upload) {
+	c.Set(headerUploadOffset, strconv.FormatInt(upload.ReceivedBytes, 10))
+	c.Set(headerUploadLength, strconv.FormatInt(upload.Size, 10))
+	c.Set(fiber.HeaderCacheControl, "no-store")
+}
+
+// CreateUpload handles the POST /api/v1/uploads request.
+// @Summary      Start an upload
+// @Description  Reserves an upload of a product image or the caller's avatar, valid for one hour. With object storage the response carries a presigned URL to PUT the file to (method PUT). With local storage the file is sent in chunks with PATCH /uploads/{id} (method PATCH). Finish with POST /uploads/{id}/complete.
+// @Tags         Uploads
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        payload  body      CreateUploadPayload  true  "File to upload"
+// @Success      201  {object}  response.ApiResponse{data=service.UploadSlot} "Upload reserved"
+// @Failure      400  {object}  response.ApiResponse "Bad Request"
+// @Failure      401  {object}  response.ApiResponse "Unauthorized"
+// @Failure      404  {object}  response.ApiResponse "Product not found"
+// @Router       /uploads [post]
+func (h *UploadHandler) CreateUpload(c *fiber.Ctx) error {
+	userID, ok := c.Locals("current_user_id").(uuid.UUID)
+	if !ok {
+		return response.Error(c, fiber.StatusUnauthorized, errors.New("unauthorized"))
+	}
+
+	payload := new(CreateUploadPayload)
+	if err := c.BodyParser(payload); err != nil {
+		return response.Error(c, fiber.StatusBadRequest, errors.New("cannot parse JSON"))
+	}
+	if errs := validator.ValidateStruct(payload); errs != nil {
+		return response.ValidationError(c, errs)
+	}
+	if payload.Purpose == model.UploadPurposeProductImage && payload.ProductID == nil {
+		return response.Error(c, fiber.StatusBadRequest, errors.New("product_id is required for product images"))
+	}
+
+	slot, err := h.uploadService.CreateUpload(c.Context(), userID, service.CreateUploadInput{
+		Purpose:     payload.Purpose,
+		ProductID:   payload.ProductID,
+		FileName:    payload.FileName,
+		ContentType: payload.ContentType,
+		Size:        payload.Size,
+		Checksum:    payload.Checksum,
+	})
+	if err != nil {
+		return uploadError(c, err)
+	}
+
+	if slot.URL == "" {
+		// Chunks are sent to the upload itself.
+		slot.URL = c.BaseURL() + "/api/v1/uploads/" + slot.Upload.ID.String()
+	}
+	return response.Success(c, fiber.StatusCreated, slot)
+}
+
+// GetUpload handles the GET and HEAD /api/v1/uploads/:id requests.
+// @Summary      Get an upload
+// @Description  Returns an upload and its progress. Upload-Offset tells a chunked upload where to resume.
+// @Tags         Uploads
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id   path      string  true  "Upload ID"
+// @Success      200  {object}  response.ApiResponse{data=model.Upload} "Successfully retrieved upload"
+// @Header       200  {integer}  Upload-Offset  "Bytes received so far"
+// @Header       200  {integer}  Upload-Length  "Declared file size"
+// @Failure      400  {object}  response.ApiResponse "Bad Request"
+// @Failure      401  {object}  response.ApiResponse "Unauthorized"
+// @Failure      404  {object}  response.ApiResponse "Upload not found"
+// @Router       /uploads/{id} [get]
+func (h *UploadHandler) GetUpload(c *fiber.Ctx) error {
+	userID, ok := c.Locals("current_user_id").(uuid.UUID)
+	if !ok {
+		return response.Error(c, fiber.StatusUnauthorized, errors.New("unauthorized"))
+	}
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, errors.New("invalid ID format"))
+	}
+
+	upload, err := h.uploadService.GetUpload(c.Context(), userID, id)
+	if err != nil {
+		return uploadError(c, err)
+	}
+
+	setUploadHeaders(c, upload)
+	return response.Success(c, fiber.StatusOK, upload)
+}
+
+// UploadChunk handles the PATCH /api/v1/uploads/:id request.
+// @Summary      Send a chunk
+// @Description  Appends the request body to a chunked upload. Upload-Offset must equal the bytes received so far; after an interruption, read it with GET /uploads/{id} and resend from there. Each chunk must fit the 4 MB request limit.
+// @Tags         Uploads
+// @Accept       application/offset+octet-stream
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id             path    string   true  "Upload ID"
+// @Param        Upload-Offset  header  integer  true  "Byte offset of this chunk"
+// @Success      200  {object}  response.ApiResponse{data=model.Upload} "Chunk stored"
+// @Header       200  {integer}  Upload-Offset  "Bytes received so far"
+// @Failure      400  {object}  response.ApiResponse "Bad Request"
+// @Failure      401  {object}  response.ApiResponse "Unauthorized"
+// @Failure      404  {object}  response.ApiResponse "Upload not found"
+// @Failure      409  {object}  response.ApiResponse "Offset mismatch or upload no longer pending"
+// @Failure      413  {object}  response.ApiResponse "Chunk goes past the declared size"
+// @Router       /uploads/{id} [patch]
+func (h *UploadHandler) UploadChunk(c *fiber.Ctx) error {
+	userID, ok := c.Locals("current_user_id").(uuid.UUID)
+	if !ok {
+		return response.Error(c, fiber.StatusUnauthorized, errors.New("unauthorized"))
+	}
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, errors.New("invalid ID format"))
+	}
+	if c.Get(fiber.HeaderContentType) != chunkContentType {
+		return response.Error(c, fiber.StatusUnsupportedMediaType, errors.New("content type must be "+chunkContentType))
+	}
+	offset, err := strconv.ParseInt(c.Get(headerUploadOffset), 10, 64)
+	if err != nil || offset < 0 {
+		return response.Error(c, fiber.StatusBadRequest, errors.New("invalid Upload-Offset header"))
+	}
+
+	upload, err := h.uploadService.AppendChunk(c.Context(), userID, id, offset, c.Body())
+	if err != nil {
+		return uploadError(c, err)
+	}
+
+	setUploadHeaders(c, upload)
+	return response.Success(c, fiber.StatusOK, upload)
+}
+
+// CompleteUpload handles the POST /api/v1/uploads/:id/complete request.
+// @Summary      Complete an upload
+// @Description  Verifies the uploaded file against its declared size and SHA-256 checksum and that it is a JPEG, PNG or WebP image, then attaches it to the product or avatar, whose variants are processed in the background. A file that fails verification is deleted and the upload marked failed.
+// @Tags         Uploads
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id   path      string  true  "Upload ID"
+// @Success      202  {object}  response.ApiResponse{data=model.Upload} "Upload attached; image processing started"
+// @Failure      400  {object}  response.ApiResponse "File missing, incomplete or not matching"
+// @Failure      401  {object}  response.ApiResponse "Unauthorized"
+// @Failure      404  {object}  response.ApiResponse "Upload not found"
+// @Failure      409  {object}  response.ApiResponse "Upload no longer pending"
+// @Router       /uploads/{id}/complete [post]
+func (h *UploadHandler) CompleteUpload(c *fiber.Ctx) error {
+	userID, ok := c.Locals("current_user_id").(uuid.UUID)
+	if !ok {
+		return response.Error(c, fiber.StatusUnauthorized, errors.New("unauthorized"))
+	}
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, errors.New("invalid ID format"))
+	}
+
+	upload, err := h.uploadService.CompleteUpload(c.Context(), userID, id)
+	if err != nil {
+		return uploadError(c, err)
+	}
+
+	return response.Success(c, fiber.StatusAccepted, upload)
+}
@@ -3,6 +3,7 @@ package http
 import (
 	"errors"
 	"venturo-core/internal/service"
+	"venturo-core/pkg/imaging"
 	"venturo-core/pkg/response"
 	"venturo-core/pkg/validator"
 
@@ -52,7 +53,7 @@ func (h *UserHandler) GetProfile(c *fiber.Ctx) error {
 // @Produce      json
 // @Security     ApiKeyAuth
 // @Param        name    formData  string  false  "New name for the user"
-// @Param        avatar  formData  file    false  "New avatar image file"
+// @Param        avatar  formData  file    false  "New avatar (JPEG, PNG or WebP, up to 4 MB and 6000x6000); processed into variants in the background"
 // @Success      200  {object}  response.ApiResponse{data=model.User} "Successfully updated profile"
 // @Failure      400  {object}  response.ApiResponse "Bad Request"
 // @Failure      401  {object}  response.ApiResponse "Unauthorized"
@@ -86,6 +87,9 @@ func (h *UserHandler) UpdateProfile(c *fiber.Ctx) error {
 
 	updatedUser, err := h.userService.UpdateUserProfile(c.Context(), userID, payload.Name, file)
 	if err != nil {
+		if errors.Is(err, imaging.ErrInvalidImage) {
+			return response.Error(c, fiber.StatusBadRequest, err)
+		}
 		return response.Error(c, fiber.StatusInternalServerError, err)
 	}
 
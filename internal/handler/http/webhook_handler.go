@@ -0,0 +1,292 @@
+package http
+
+import (
+	"errors"
+	"strings"
+	"venturo-core/internal/model"
+	"venturo-core/internal/service"
+	"venturo-core/pkg/response"
+	"venturo-core/pkg/validator"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type WebhookHandler struct {
+	webhookService *service.WebhookService
+}
+
+// NewWebhookHandler creates a new webhook handler.
+func NewWebhookHandler(s *service.WebhookService) *WebhookHandler {
+	return &WebhookHandler{webhookService: s}
+}
+
+// CreateWebhookPayload defines the expected JSON for subscribing an endpoint.
+type CreateWebhookPayload struct {
+	URL        string     `json:"url" validate:"required,url,max=2048"`
+	Secret     string     `json:"secret" validate:"omitempty,min=16,max=255"` // Generated when empty
+	EventTypes []string   `json:"event_types" validate:"required,min=1"`
+	OutletID   *uuid.UUID `json:"outlet_id"` // Only this outlet's events; all outlets when empty
+	IsActive   *bool      `json:"is_active"`
+}
+
+// UpdateWebhookPayload defines the expected JSON for changing a subscription; omitted fields are kept.
+type UpdateWebhookPayload struct {
+	URL        *string    `json:"url" validate:"omitempty,url,max=2048"`
+	Secret     *string    `json:"secret" validate:"omitempty,min=16,max=255"`
+	EventTypes []string   `json:"event_types" validate:"omitempty,min=1"`
+	OutletID   *uuid.UUID `json:"outlet_id"`
+	IsActive   *bool      `json:"is_active"`
+}
+
+// WebhookWithSecret is a subscription together with its signing secret,
+// which is only shown when the subscription is created.
+type WebhookWithSecret struct {
+	*model.WebhookSubscription
+	Secret string `json:"secret"`
+}
+
+// webhookError maps webhook service errors to HTTP responses.
+func webhookError(c *fiber.Ctx, err error) error {
+	switch {
+	case strings.Contains(err.Error(), "not found"):
+		return response.Error(c, fiber.StatusNotFound, err)
+	case errors.Is(err, service.ErrUnknownEventType), errors.Is(err, service.ErrWebhookURL):
+		return response.Error(c, fiber.StatusBadRequest, err)
+	case errors.Is(err, service.ErrDeliveryPending):
+		return response.Error(c, fiber.StatusConflict, err)
+	default:
+		return response.Error(c, fiber.StatusInternalServerError, err)
+	}
+}
+
+// CreateWebhook handles the POST /api/v1/webhooks request.
+// @Summary      Subscribe a webhook
+// @Description  Registers an endpoint that receives the chosen events. Every request is signed: X-Webhook-Signature is "sha256=" followed by the hex HMAC-SHA256 of X-Webhook-Timestamp, a dot and the raw body, keyed with the secret. The secret is generated when omitted and only returned here.
+// @Tags         Webhooks
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        payload  body      CreateWebhookPayload  true  "Subscription"
+// @Success      201  {object}  response.ApiResponse{data=WebhookWithSecret} "Successfully subscribed webhook"
+// @Failure      400  {object}  response.ApiResponse "Bad Request"
+// @Failure      401  {object}  response.ApiResponse "Unauthorized"
+// @Failure      404  {object}  response.ApiResponse "Outlet not found"
+// @Router       /webhooks [post]
+func (h *WebhookHandler) CreateWebhook(c *fiber.Ctx) error {
+	payload := new(CreateWebhookPayload)
+	if err := c.BodyParser(payload); err != nil {
+		return response.Error(c, fiber.StatusBadRequest, errors.New("cannot parse JSON"))
+	}
+	if errs := validator.ValidateStruct(payload); errs != nil {
+		return response.ValidationError(c, errs)
+	}
+
+	subscription, err := h.webhookService.CreateWebhook(c.Context(), service.WebhookInput{
+		URL:        &payload.URL,
+		Secret:     &payload.Secret,
+		EventTypes: payload.EventTypes,
+		OutletID:   payload.OutletID,
+		IsActive:   payload.IsActive,
+	})
+	if err != nil {
+		return webhookError(c, err)
+	}
+
+	return response.Success(c, fiber.StatusCreated, WebhookWithSecret{
+		WebhookSubscription: subscription,
+		Secret:              subscription.Secret,
+	})
+}
+
+// GetWebhooks handles the GET /api/v1/webhooks request.
+// @Summary      List webhooks
+// @Description  Retrieves every webhook subscription.
+// @Tags         Webhooks
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Success      200  {object}  response.ApiResponse{data=[]model.WebhookSubscription} "Successfully retrieved webhooks"
+// @Failure      401  {object}  response.ApiResponse "Unauthorized"
+// @Router       /webhooks [get]
+func (h *WebhookHandler) GetWebhooks(c *fiber.Ctx) error {
+	subscriptions, err := h.webhookService.ListWebhooks(c.Context())
+	if err != nil {
+		return response.Error(c, fiber.StatusInternalServerError, errors.New("could not retrieve webhooks"))
+	}
+
+	return response.Success(c, fiber.StatusOK, subscriptions)
+}
+
+// GetWebhook handles the GET /api/v1/webhooks/:id request.
+// @Summary      Get a webhook
+// @Description  Retrieves a webhook subscription.
+// @Tags         Webhooks
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id   path      string  true  "Webhook ID"
+// @Success      200  {object}  response.ApiResponse{data=model.WebhookSubscription} "Successfully retrieved webhook"
+// @Failure      400  {object}  response.ApiResponse "Bad Request"
+// @Failure      404  {object}  response.ApiResponse "Webhook not found"
+// @Router       /webhooks/{id} [get]
+func (h *WebhookHandler) GetWebhook(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, errors.New("invalid ID format"))
+	}
+
+	subscription, err := h.webhookService.GetWebhook(c.Context(), id)
+	if err != nil {
+		return webhookError(c, err)
+	}
+
+	return response.Success(c, fiber.StatusOK, subscription)
+}
+
+// UpdateWebhook handles the PUT /api/v1/webhooks/:id request.
+// @Summary      Update a webhook
+// @Description  Changes a webhook subscription. Omitted fields are kept.
+// @Tags         Webhooks
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id       path      string                true  "Webhook ID"
+// @Param        payload  body      UpdateWebhookPayload  true  "Subscription"
+// @Success      200  {object}  response.ApiResponse{data=model.WebhookSubscription} "Successfully updated webhook"
+// @Failure      400  {object}  response.ApiResponse "Bad Request"
+// @Failure      404  {object}  response.ApiResponse "Webhook not found"
+// @Router       /webhooks/{id} [put]
+func (h *WebhookHandler) UpdateWebhook(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, errors.New("invalid ID format"))
+	}
+
+	payload := new(UpdateWebhookPayload)
+	if err := c.BodyParser(payload); err != nil {
+		return response.Error(c, fiber.StatusBadRequest, errors.New("cannot parse JSON"))
+	}
+	if errs := validator.ValidateStruct(payload); errs != nil {
+		return response.ValidationError(c, errs)
+	}
+
+	subscription, err := h.webhookService.UpdateWebhook(c.Context(), id, service.WebhookInput{
+		URL:        payload.URL,
+		Secret:     payload.Secret,
+		EventTypes: payload.EventTypes,
+		OutletID:   payload.OutletID,
+		IsActive:   payload.IsActive,
+	})
+	if err != nil {
+		return webhookError(c, err)
+	}
+
+	return response.Success(c, fiber.StatusOK, subscription)
+}
+
+// DeleteWebhook handles the DELETE /api/v1/webhooks/:id request.
+// @Summary      Delete a webhook
+// @Description  Removes a webhook subscription and its delivery log.
+// @Tags         Webhooks
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id   path      string  true  "Webhook ID"
+// @Success      200  {object}  response.ApiResponse "Successfully deleted webhook"
+// @Failure      400  {object}  response.ApiResponse "Bad Request"
+// @Failure      404  {object}  response.ApiResponse "Webhook not found"
+// @Router       /webhooks/{id} [delete]
+func (h *WebhookHandler) DeleteWebhook(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, errors.New("invalid ID format"))
+	}
+
+	if err := h.webhookService.DeleteWebhook(c.Context(), id); err != nil {
+		return webhookError(c, err)
+	}
+
+	return response.Success(c, fiber.StatusOK, nil)
+}
+
+// GetDeliveries handles the GET /api/v1/webhooks/:id/deliveries request.
+// @Summary      List webhook deliveries
+// @Description  Retrieves a paginated delivery log of a webhook, newest first, with the request body and the last response.
+// @Tags         Webhooks
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id     path      string  true   "Webhook ID"
+// @Param        page   query     int     false  "Page number for pagination" default(1)
+// @Param        limit  query     int     false  "Number of items per page" default(10)
+// @Success      200    {object}  response.ApiResponse{data=[]model.WebhookDelivery} "Successfully retrieved deliveries"
+// @Failure      400    {object}  response.ApiResponse "Bad Request"
+// @Failure      404    {object}  response.ApiResponse "Webhook not found"
+// @Router       /webhooks/{id}/deliveries [get]
+func (h *WebhookHandler) GetDeliveries(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, errors.New("invalid ID format"))
+	}
+
+	page, limit := pageParams(c)
+	deliveries, total, err := h.webhookService.ListDeliveries(c.Context(), id, page, limit)
+	if err != nil {
+		return webhookError(c, err)
+	}
+
+	return response.Pagination(c, deliveries, page, limit, total)
+}
+
+// Redeliver handles the POST /api/v1/webhooks/:id/deliveries/:delivery_id/redeliver request.
+// @Summary      Redeliver a webhook
+// @Description  Sends a finished delivery again in the background with the same body, retrying on failure.
+// @Tags         Webhooks
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id           path      string  true  "Webhook ID"
+// @Param        delivery_id  path      string  true  "Delivery ID"
+// @Success      202  {object}  response.ApiResponse{data=model.WebhookDelivery} "Redelivery queued"
+// @Failure      400  {object}  response.ApiResponse "Bad Request"
+// @Failure      404  {object}  response.ApiResponse "Delivery not found"
+// @Failure      409  {object}  response.ApiResponse "Delivery is still pending"
+// @Router       /webhooks/{id}/deliveries/{delivery_id}/redeliver [post]
+func (h *WebhookHandler) Redeliver(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, errors.New("invalid ID format"))
+	}
+	deliveryID, err := uuid.Parse(c.Params("delivery_id"))
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, errors.New("invalid delivery ID format"))
+	}
+
+	delivery, err := h.webhookService.Redeliver(c.Context(), id, deliveryID)
+	if err != nil {
+		return webhookError(c, err)
+	}
+
+	return response.Success(c, fiber.StatusAccepted, delivery)
+}
+
+// Ping handles the POST /api/v1/webhooks/:id/ping request.
+// @Summary      Ping a webhook
+// @Description  Sends a signed "ping" event to the endpoint right away and returns the logged delivery with the endpoint's response. Pings are not retried.
+// @Tags         Webhooks
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        id   path      string  true  "Webhook ID"
+// @Success      200  {object}  response.ApiResponse{data=model.WebhookDelivery} "Ping sent"
+// @Failure      400  {object}  response.ApiResponse "Bad Request"
+// @Failure      404  {object}  response.ApiResponse "Webhook not found"
+// @Router       /webhooks/{id}/ping [post]
+func (h *WebhookHandler) Ping(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return response.Error(c, fiber.StatusBadRequest, errors.New("invalid ID format"))
+	}
+
+	delivery, err := h.webhookService.Ping(c.Context(), id)
+	if err != nil {
+		return webhookError(c, err)
+	}
+
+	return response.Success(c, fiber.StatusOK, delivery)
+}
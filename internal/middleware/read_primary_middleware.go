@@ -0,0 +1,23 @@
+package middleware
+
+import (
+	"strconv"
+	"venturo-core/internal/database"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ReadPrimaryHeader asks for a request's reads to go to the primary database.
+const ReadPrimaryHeader = "X-Read-Primary"
+
+// NewReadPrimaryMiddleware sends every read of a request whose X-Read-Primary
+// header is true to the primary, for clients that must see their own writes
+// in a report or listing.
+func NewReadPrimaryMiddleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if force, _ := strconv.ParseBool(c.Get(ReadPrimaryHeader)); force {
+			c.Locals(database.PrimaryKey{}, true)
+		}
+		return c.Next()
+	}
+}
@@ -0,0 +1,31 @@
+package model
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Customer is a member who can be attached to transactions.
+type Customer struct {
+	ID         uuid.UUID `gorm:"type:char(36);primary_key" json:"id"`
+	MemberCode string    `gorm:"size:20;not null;unique" json:"member_code"` // Printed on the member card
+	Name       string    `gorm:"size:255;not null" json:"name"`
+	Phone      *string   `gorm:"size:30;unique" json:"phone"`
+	Email      *string   `gorm:"size:255;unique" json:"email"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// BeforeCreate is a GORM hook.
+func (c *Customer) BeforeCreate(tx *gorm.DB) (err error) {
+	c.ID = uuid.New()
+	return
+}
+
+// Save creates or updates a customer record.
+func (c *Customer) Save(db *gorm.DB) error {
+	return db.WithContext(context.Background()).Save(c).Error
+}
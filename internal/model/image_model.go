@@ -0,0 +1,55 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+)
+
+// Image statuses shared by product images and user avatars.
+const (
+	ImageStatusDefault    = "default"    // No image uploaded
+	ImageStatusProcessing = "processing" // Staged; renditions are being produced
+	ImageStatusReady      = "ready"
+	ImageStatusFailed     = "failed" // Rejected while processing or could not be stored
+)
+
+// ImageVariant is one stored rendition of an uploaded image.
+type ImageVariant struct {
+	Key    string `json:"key"`
+	URL    string `json:"url"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+}
+
+// ImageVariants maps rendition names (thumbnail, medium, large) to their
+// stored copies. It is stored as a JSON object.
+type ImageVariants map[string]ImageVariant
+
+func (v ImageVariants) Value() (driver.Value, error) {
+	if v == nil {
+		return nil, nil
+	}
+	return json.Marshal(v)
+}
+
+func (v *ImageVariants) Scan(value interface{}) error {
+	if value == nil {
+		*v = nil
+		return nil
+	}
+	b, ok := value.([]byte)
+	if !ok {
+		return errors.New("failed to scan ImageVariants: value is not a byte slice")
+	}
+	return json.Unmarshal(b, v)
+}
+
+// Keys returns the storage keys of every rendition.
+func (v ImageVariants) Keys() []string {
+	keys := make([]string, 0, len(v))
+	for _, variant := range v {
+		keys = append(keys, variant.Key)
+	}
+	return keys
+}
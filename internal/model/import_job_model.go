@@ -0,0 +1,81 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Import job types and modes.
+const (
+	ImportTypeProducts = "products"
+
+	ImportModeDryRun = "dry_run"
+	ImportModeCommit = "commit"
+)
+
+// Import job statuses, in the order a job moves through them.
+const (
+	ImportPending    = "pending"
+	ImportValidating = "validating"
+	ImportImporting  = "importing"
+	ImportSucceeded  = "succeeded"
+	ImportFailed     = "failed"
+)
+
+// ImportRowError is a problem with one field of one line of an import file.
+type ImportRowError struct {
+	Line    int    `json:"line"`
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ImportRowErrors is stored as a JSON array.
+type ImportRowErrors []ImportRowError
+
+func (e ImportRowErrors) Value() (driver.Value, error) {
+	return json.Marshal(e)
+}
+
+func (e *ImportRowErrors) Scan(value interface{}) error {
+	if value == nil {
+		*e = nil
+		return nil
+	}
+	b, ok := value.([]byte)
+	if !ok {
+		return errors.New("failed to scan ImportRowErrors: value is not a byte slice")
+	}
+	return json.Unmarshal(b, e)
+}
+
+// ImportJob tracks a background import so clients can poll its progress.
+type ImportJob struct {
+	ID            uuid.UUID       `gorm:"type:char(36);primary_key" json:"id"`
+	Type          string          `gorm:"size:30;not null" json:"type"`
+	Mode          string          `gorm:"size:10;not null" json:"mode"`
+	Status        string          `gorm:"size:20;not null;default:'pending'" json:"status"`
+	OutletID      *uuid.UUID      `gorm:"type:char(36)" json:"outlet_id"`
+	UserID        uuid.UUID       `gorm:"type:char(36);not null" json:"user_id"`
+	FileName      string          `gorm:"size:255;not null;default:''" json:"file_name"`
+	FileData      []byte          `gorm:"type:mediumblob" json:"-"` // The uploaded file, cleared when the import finishes
+	TotalRows     int             `gorm:"not null;default:0" json:"total_rows"`
+	ProcessedRows int             `gorm:"not null;default:0" json:"processed_rows"` // Within the current status
+	CreatedRows   int             `gorm:"not null;default:0" json:"created_rows"`
+	UpdatedRows   int             `gorm:"not null;default:0" json:"updated_rows"`
+	RowErrors     ImportRowErrors `gorm:"type:json" json:"row_errors"`
+	Failure       string          `gorm:"type:text" json:"failure,omitempty"` // Set when the job failed for a reason other than row errors
+	FinishedAt    *time.Time      `json:"finished_at"`
+	CreatedAt     time.Time       `json:"created_at"`
+	UpdatedAt     time.Time       `json:"updated_at"`
+}
+
+// BeforeCreate is a GORM hook.
+func (j *ImportJob) BeforeCreate(tx *gorm.DB) (err error) {
+	j.ID = uuid.New()
+	return
+}
@@ -7,22 +7,69 @@ import (
 	"gorm.io/gorm"
 )
 
+// Ledger entry types describe why stock moved.
+const (
+	LedgerEntryOpening    = "opening"
+	LedgerEntryStockIn    = "stock_in"
+	LedgerEntrySale       = "sale"
+	LedgerEntryAdjustment = "adjustment"
+	LedgerEntryRefund     = "refund"
+)
+
 type InventoryLedger struct {
-	ID             uuid.UUID  `gorm:"type:char(36);primary_key" json:"id"`
-	ItemId         uuid.UUID  `gorm:"type:char(36);not null" json:"item_id"`
-	OutletId       uuid.UUID  `gorm:"type:char(36);not null" json:"outlet_id"`
-	TransactionId  *uuid.UUID `gorm:"type:char(36)" json:"transaction_id"` // Nullable for stock-in operations
-	QuantityChange int        `gorm:"not null" json:"quantity_change"`
-	CreatedAt      time.Time  `json:"created_at"`
-	UpdatedAt      time.Time  `json:"updated_at"`
+	ID              uuid.UUID  `gorm:"type:char(36);primary_key" json:"id"`
+	ItemId          uuid.UUID  `gorm:"type:char(36);not null" json:"item_id"`
+	OutletId        uuid.UUID  `gorm:"type:char(36);not null" json:"outlet_id"`
+	TransactionId   *uuid.UUID `gorm:"type:char(36)" json:"transaction_id"` // Nullable for stock-in operations
+	EntryType       string     `gorm:"size:20;not null;default:'stock_in'" json:"entry_type"`
+	LotNumber       string     `gorm:"size:50;not null;default:''" json:"lot_number"` // Empty for untracked stock
+	ExpiryDate      *time.Time `gorm:"type:date" json:"expiry_date"`
+	Unit            string     `gorm:"size:20;not null;default:''" json:"unit"`                       // Unit the movement was entered in
+	EnteredQuantity float64    `gorm:"type:decimal(18,4);not null;default:0" json:"entered_quantity"` // Quantity in Unit, signed like QuantityChange
+	QuantityChange  int        `gorm:"not null" json:"quantity_change"`                               // Normalized to the product's base unit
+	UnitCost        *float64   `gorm:"type:decimal(18,4)" json:"unit_cost"`                           // Cost per base unit, known for purchases only
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
 
 	Item        Product      `gorm:"foreignKey:ItemId;references:ID" json:"item,omitempty"`
 	Outlet      Outlet       `gorm:"foreignKey:OutletId;references:ID" json:"outlet,omitempty"`
 	Transaction *Transaction `gorm:"foreignKey:TransactionId;references:ID" json:"transaction,omitempty"`
 }
 
+// LotBalance is the remaining quantity of one lot of an item at an outlet.
+type LotBalance struct {
+	LotNumber  string     `json:"lot_number"`
+	ExpiryDate *time.Time `json:"expiry_date"`
+	OnHandQty  int        `json:"on_hand_quantity"`
+}
+
 // BeforeCreate is a GORM hook that runs before creating a new inventory ledger.
 func (il *InventoryLedger) BeforeCreate(tx *gorm.DB) (err error) {
 	il.ID = uuid.New()
 	return
 }
+
+// NotExpired matches ledger entries of lots that have not expired, including
+// untracked stock, which has no expiry date.
+const NotExpired = "expiry_date IS NULL OR expiry_date >= CURDATE()"
+
+// FindLotBalances returns the lots of an item at an outlet that still hold stock,
+// ordered first-expiry-first-out. Lots without an expiry date come last.
+// Expired lots are left out unless includeExpired is set; they wait for a
+// write-off instead of being sold.
+func FindLotBalances(db *gorm.DB, itemID, outletID uuid.UUID, includeExpired bool) ([]LotBalance, error) {
+	query := db.Model(&InventoryLedger{}).
+		Select("lot_number, expiry_date, SUM(quantity_change) AS on_hand_qty").
+		Where("item_id = ? AND outlet_id = ?", itemID, outletID)
+	if !includeExpired {
+		query = query.Where(NotExpired)
+	}
+
+	var lots []LotBalance
+	err := query.
+		Group("lot_number, expiry_date").
+		Having("SUM(quantity_change) > 0").
+		Order("expiry_date IS NULL, expiry_date, lot_number").
+		Scan(&lots).Error
+	return lots, err
+}
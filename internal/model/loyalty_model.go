@@ -0,0 +1,88 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Loyalty ledger entry types describe why a points balance moved.
+const (
+	LoyaltyEntryEarn           = "earn"            // Points earned on a paid sale
+	LoyaltyEntryRedeem         = "redeem"          // Points spent as a tender
+	LoyaltyEntryEarnReversal   = "earn_reversal"   // Earned points taken back on refund
+	LoyaltyEntryRedeemReversal = "redeem_reversal" // Spent points returned on refund
+	LoyaltyEntryExpire         = "expire"          // Unused earned points past their expiry
+)
+
+// CategoryMultipliers maps a product category name to its earn multiplier.
+type CategoryMultipliers map[string]float64
+
+func (cm CategoryMultipliers) Value() (driver.Value, error) {
+	return json.Marshal(cm)
+}
+
+func (cm *CategoryMultipliers) Scan(value interface{}) error {
+	if value == nil {
+		*cm = nil
+		return nil
+	}
+	b, ok := value.([]byte)
+	if !ok {
+		return errors.New("failed to scan CategoryMultipliers: value is not a byte slice")
+	}
+	return json.Unmarshal(b, cm)
+}
+
+// LoyaltyRules holds the earn and redemption rules in a single row with ID 1.
+type LoyaltyRules struct {
+	ID                  uint8               `gorm:"primary_key" json:"-"`
+	Enabled             bool                `gorm:"not null;default:false" json:"enabled"`
+	PointsPerUnit       float64             `gorm:"type:decimal(18,6);not null;default:0" json:"points_per_unit"` // Points earned per currency unit spent
+	CategoryMultipliers CategoryMultipliers `gorm:"type:json" json:"category_multipliers"`                        // Missing categories earn at 1x
+	PointValue          int64               `gorm:"not null;default:0" json:"point_value"`                        // Currency value of one point when redeemed
+	ExpiryDays          int                 `gorm:"not null;default:0" json:"expiry_days"`                        // 0 keeps points forever
+	UpdatedAt           time.Time           `json:"updated_at"`
+}
+
+// Multiplier returns the earn multiplier of a category.
+func (r *LoyaltyRules) Multiplier(category ProductCategory) float64 {
+	if m, ok := r.CategoryMultipliers[category.String()]; ok {
+		return m
+	}
+	return 1
+}
+
+// LoyaltyLedger is an append-only record of a change to a customer's points.
+// The balance is the sum of Points over all of the customer's entries.
+type LoyaltyLedger struct {
+	ID            uuid.UUID  `gorm:"type:char(36);primary_key" json:"id"`
+	CustomerID    uuid.UUID  `gorm:"type:char(36);not null" json:"customer_id"`
+	TransactionID *uuid.UUID `gorm:"type:char(36)" json:"transaction_id"`
+	SourceEntryID *uuid.UUID `gorm:"type:char(36)" json:"source_entry_id"` // Earn entry an expiry or reversal applies to
+	EntryType     string     `gorm:"size:20;not null" json:"entry_type"`
+	Points        int64      `gorm:"not null" json:"points"` // Positive for credits, negative for debits
+	ExpiresAt     *time.Time `json:"expires_at"`             // Set on earn entries when points expire
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+// BeforeCreate is a GORM hook.
+func (l *LoyaltyLedger) BeforeCreate(tx *gorm.DB) (err error) {
+	l.ID = uuid.New()
+	return
+}
+
+// PointsBalance sums a customer's loyalty ledger.
+func PointsBalance(db *gorm.DB, customerID uuid.UUID) (int64, error) {
+	var balance int64
+	err := db.Model(&LoyaltyLedger{}).
+		Where("customer_id = ?", customerID).
+		Select("COALESCE(SUM(points), 0)").
+		Row().
+		Scan(&balance)
+	return balance, err
+}
@@ -2,14 +2,20 @@ package model
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
 type Outlet struct {
-	ID   uuid.UUID `gorm:"type:char(36);primary_key" json:"id"`
-	Name string    `gorm:"size:255;not null" json:"name"`
+	ID       uuid.UUID `gorm:"type:char(36);primary_key" json:"id"`
+	Name     string    `gorm:"size:255;not null" json:"name"`
+	Timezone string    `gorm:"size:64;not null;default:'UTC'" json:"timezone"` // IANA name, e.g. Asia/Jakarta
+	Locale   string    `gorm:"size:10;not null;default:'en-US'" json:"locale"` // Formats money and dates in exports
+
+	// RequireOpenShift rejects sales and payments by cashiers without an open shift.
+	RequireOpenShift bool `gorm:"not null;default:false" json:"require_open_shift"`
 }
 
 func (o *Outlet) BeforeCReate() (err error) {
@@ -20,3 +26,12 @@ func (o *Outlet) BeforeCReate() (err error) {
 func (o *Outlet) Save(db *gorm.DB) error {
 	return db.WithContext(context.Background()).Save(0).Error
 }
+
+// Location returns the outlet's time zone, falling back to UTC when it is unknown.
+func (o *Outlet) Location() *time.Location {
+	loc, err := time.LoadLocation(o.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
@@ -0,0 +1,35 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Payment methods accepted as tenders.
+const (
+	PaymentCash     = "cash"
+	PaymentCard     = "card"
+	PaymentQRIS     = "qris"
+	PaymentTransfer = "transfer"
+	PaymentPoints   = "points" // Loyalty points redeemed at their point value
+)
+
+// Payment is one tender applied to a transaction. A transaction paid with
+// several methods has one payment per method.
+type Payment struct {
+	ID            uuid.UUID  `gorm:"type:char(36);primary_key" json:"id"`
+	TransactionID uuid.UUID  `gorm:"type:char(36);not null" json:"transaction_id"`
+	ShiftID       *uuid.UUID `gorm:"type:char(36)" json:"shift_id"` // Shift whose drawer took the payment
+	Method        string     `gorm:"size:20;not null" json:"method"`
+	Amount        int64      `gorm:"not null" json:"amount"` // Negative for refunds
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// BeforeCreate is a GORM hook.
+func (p *Payment) BeforeCreate(tx *gorm.DB) (err error) {
+	p.ID = uuid.New()
+	return
+}
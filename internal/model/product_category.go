@@ -8,3 +8,17 @@ const (
 	Service
 	Subscription
 )
+
+// String returns the display name used in reports.
+func (c ProductCategory) String() string {
+	switch c {
+	case Goods:
+		return "Goods"
+	case Service:
+		return "Service"
+	case Subscription:
+		return "Subscription"
+	default:
+		return "Other"
+	}
+}
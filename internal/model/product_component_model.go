@@ -0,0 +1,43 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ProductComponent is one bill-of-materials line of a composite product:
+// selling one unit of the product consumes Quantity of the component.
+type ProductComponent struct {
+	ID          uuid.UUID `gorm:"type:char(36);primary_key" json:"id"`
+	ProductID   uuid.UUID `gorm:"type:char(36);not null" json:"product_id"`
+	ComponentID uuid.UUID `gorm:"type:char(36);not null" json:"component_id"`
+	Quantity    int       `gorm:"not null" json:"quantity"`
+	Unit        string    `gorm:"size:20;not null;default:''" json:"unit"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+
+	Component Product `gorm:"foreignKey:ComponentID;references:ID" json:"-"`
+}
+
+// BeforeCreate is a GORM hook that runs before creating a new component line.
+func (pc *ProductComponent) BeforeCreate(tx *gorm.DB) (err error) {
+	pc.ID = uuid.New()
+	return
+}
+
+// FindComponentsByProductIDs returns the bill of materials of every composite product
+// among productIDs, keyed by product ID. Products without components are absent.
+func FindComponentsByProductIDs(db *gorm.DB, productIDs []uuid.UUID) (map[uuid.UUID][]ProductComponent, error) {
+	var components []ProductComponent
+	if err := db.Preload("Component").Where("product_id IN ?", productIDs).Find(&components).Error; err != nil {
+		return nil, err
+	}
+
+	byProduct := make(map[uuid.UUID][]ProductComponent)
+	for _, component := range components {
+		byProduct[component.ProductID] = append(byProduct[component.ProductID], component)
+	}
+	return byProduct, nil
+}
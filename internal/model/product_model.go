@@ -9,14 +9,42 @@ import (
 )
 
 type Product struct {
-	ID          uuid.UUID `gorm:"type:char(36);primary_key"`
-	Name        string    `gorm:"size:255;not null"`
-	Price       int32
-	Stock       int16
-	ImageURL    string `gorm:"size:255"`
-	ImageStatus string `gorm:"size:20;not null;default:'default'"`
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
+	ID           uuid.UUID `gorm:"type:char(36);primary_key"`
+	SKU          *string   `gorm:"size:64;unique"` // Optional; matches products on import
+	Name         string    `gorm:"size:255;not null"`
+	Price        int32
+	BaseUnit     string  `gorm:"size:20;not null;default:'pcs'"` // Unit stock is counted in
+	PurchaseUnit *string `gorm:"size:20"`                        // Defaults to BaseUnit when nil
+	SalesUnit    *string `gorm:"size:20"`                        // Defaults to BaseUnit when nil
+	ImageURL     string  `gorm:"size:255"`
+	ImageKey     string  `gorm:"size:255;not null;default:''"` // Storage prefix of the image renditions
+	ImageStatus  string  `gorm:"size:20;not null;default:'default'"`
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+
+	// LowStockThreshold raises inventory.low_stock when an outlet's on-hand
+	// quantity falls to it; nil turns the alert off.
+	LowStockThreshold *int
+
+	// ImageVariants are the stored renditions of the image; ImageKey is their
+	// storage prefix and ImageURL points at the large one.
+	ImageVariants ImageVariants `gorm:"type:json"`
+
+	// Units holds conversions from purchase and sales units into BaseUnit.
+	Units []ProductUnit `gorm:"foreignKey:ProductID"`
+
+	// Components is the bill of materials; empty for products stocked as themselves.
+	Components []ProductComponent `gorm:"foreignKey:ProductID"`
+
+	// Stocks is computed from inventory_ledgers, never stored on the product.
+	Stocks []OutletStock `gorm:"-"`
+}
+
+// OutletStock is the on-hand quantity of a product at one outlet.
+type OutletStock struct {
+	OutletID   uuid.UUID `json:"outlet_id"`
+	OutletName string    `json:"outlet_name"`
+	OnHandQty  int64     `json:"on_hand_quantity"`
 }
 
 func (p *Product) BeforeCreate(tx *gorm.DB) (err error) {
@@ -27,3 +55,22 @@ func (p *Product) BeforeCreate(tx *gorm.DB) (err error) {
 func (p *Product) Save(db *gorm.DB) (err error) {
 	return db.WithContext(context.Background()).Save(p).Error
 }
+
+// FindByID retrieves a single product by its ID.
+func (p *Product) FindByID(db *gorm.DB, id uuid.UUID) (*Product, error) {
+	var product Product
+	err := db.Preload("Units").Preload("Components").Where("id = ?", id).First(&product).Error
+	return &product, err
+}
+
+// LoadStocks fills Stocks with the per-outlet on-hand quantity from the ledger.
+func (p *Product) LoadStocks(db *gorm.DB) error {
+	p.Stocks = []OutletStock{}
+	return db.Table("inventory_ledgers").
+		Select("inventory_ledgers.outlet_id, outlets.name AS outlet_name, COALESCE(SUM(inventory_ledgers.quantity_change), 0) AS on_hand_qty").
+		Joins("LEFT JOIN outlets ON outlets.id = inventory_ledgers.outlet_id").
+		Where("inventory_ledgers.item_id = ?", p.ID).
+		Group("inventory_ledgers.outlet_id, outlets.name").
+		Order("outlets.name").
+		Scan(&p.Stocks).Error
+}
@@ -0,0 +1,52 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SalesDailySummary holds the paid sales of one outlet on one local calendar day.
+type SalesDailySummary struct {
+	OutletID         uuid.UUID `gorm:"type:char(36);primary_key" json:"outlet_id"`
+	SalesDate        time.Time `gorm:"type:date;primary_key" json:"sales_date"` // Day in the outlet's time zone
+	Revenue          int64     `gorm:"not null;default:0" json:"revenue"`
+	PaidTransactions int64     `gorm:"not null;default:0" json:"paid_transactions"`
+	ProductsSold     int64     `gorm:"not null;default:0" json:"products_sold"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
+
+// SalesDailyCategorySummary splits a SalesDailySummary by product category.
+type SalesDailyCategorySummary struct {
+	OutletID     uuid.UUID       `gorm:"type:char(36);primary_key" json:"outlet_id"`
+	SalesDate    time.Time       `gorm:"type:date;primary_key" json:"sales_date"`
+	Category     ProductCategory `gorm:"primary_key" json:"category"`
+	Revenue      int64           `gorm:"not null;default:0" json:"revenue"`
+	ProductsSold int64           `gorm:"not null;default:0" json:"products_sold"`
+	UpdatedAt    time.Time       `json:"updated_at"`
+}
+
+// AddTo adds the summary's figures onto the stored row for the same outlet and
+// day, creating it when it does not exist yet.
+func (s *SalesDailySummary) AddTo(db *gorm.DB) error {
+	return db.Clauses(clause.OnConflict{
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"revenue":           gorm.Expr("revenue + VALUES(revenue)"),
+			"paid_transactions": gorm.Expr("paid_transactions + VALUES(paid_transactions)"),
+			"products_sold":     gorm.Expr("products_sold + VALUES(products_sold)"),
+		}),
+	}).Create(s).Error
+}
+
+// AddTo adds the summary's figures onto the stored row for the same outlet, day
+// and category, creating it when it does not exist yet.
+func (s *SalesDailyCategorySummary) AddTo(db *gorm.DB) error {
+	return db.Clauses(clause.OnConflict{
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"revenue":       gorm.Expr("revenue + VALUES(revenue)"),
+			"products_sold": gorm.Expr("products_sold + VALUES(products_sold)"),
+		}),
+	}).Create(s).Error
+}
@@ -0,0 +1,73 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Shift statuses.
+const (
+	ShiftOpen   = "open"
+	ShiftClosed = "closed"
+)
+
+// Cash movement types record cash put into or taken out of the drawer outside of sales.
+const (
+	CashPayIn  = "pay_in"
+	CashPayOut = "pay_out"
+)
+
+// Shift is one cashier's session at an outlet's cash drawer. Amounts are in
+// the same currency units as Transaction.Total.
+type Shift struct {
+	ID           uuid.UUID  `gorm:"type:char(36);primary_key" json:"id"`
+	OutletID     uuid.UUID  `gorm:"type:char(36);not null" json:"outlet_id"`
+	UserID       uuid.UUID  `gorm:"type:char(36);not null" json:"user_id"`
+	Status       string     `gorm:"size:10;not null;default:'open'" json:"status"`
+	OpeningFloat int64      `gorm:"not null;default:0" json:"opening_float"`
+	ExpectedCash *int64     `json:"expected_cash"` // Set when the shift is closed
+	CountedCash  *int64     `json:"counted_cash"`
+	Variance     *int64     `json:"variance"` // CountedCash minus ExpectedCash
+	OpeningNote  string     `gorm:"type:text" json:"opening_note"`
+	ClosingNote  string     `gorm:"type:text" json:"closing_note"`
+	OpenedAt     time.Time  `json:"opened_at"`
+	ClosedAt     *time.Time `json:"closed_at"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+
+	CashMovements []ShiftCashMovement `gorm:"foreignKey:ShiftID" json:"cash_movements,omitempty"`
+}
+
+// ShiftCashMovement is a pay-in or pay-out recorded against a shift.
+type ShiftCashMovement struct {
+	ID        uuid.UUID `gorm:"type:char(36);primary_key" json:"id"`
+	ShiftID   uuid.UUID `gorm:"type:char(36);not null" json:"shift_id"`
+	UserID    uuid.UUID `gorm:"type:char(36);not null" json:"user_id"`
+	Type      string    `gorm:"size:10;not null" json:"type"`
+	Amount    int64     `gorm:"not null" json:"amount"` // Always positive; Type gives the direction
+	Reason    string    `gorm:"size:255;not null;default:''" json:"reason"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// BeforeCreate is a GORM hook.
+func (s *Shift) BeforeCreate(tx *gorm.DB) (err error) {
+	s.ID = uuid.New()
+	return
+}
+
+// BeforeCreate is a GORM hook.
+func (m *ShiftCashMovement) BeforeCreate(tx *gorm.DB) (err error) {
+	m.ID = uuid.New()
+	return
+}
+
+// FindOpenShift returns the open shift of a cashier at an outlet, or
+// gorm.ErrRecordNotFound when there is none.
+func FindOpenShift(db *gorm.DB, outletID, userID uuid.UUID) (*Shift, error) {
+	var shift Shift
+	err := db.Where("outlet_id = ? AND user_id = ? AND status = ?", outletID, userID, ShiftOpen).First(&shift).Error
+	return &shift, err
+}
@@ -9,20 +9,26 @@ import (
 )
 
 type Transaction struct {
-	ID          uuid.UUID `gorm:"type:char(36);primary_key"`
-	UserID      uuid.UUID `gorm:"type:char(36);not null"`
-	InvoiceCode string    `gorm:"size:20;not null;unique"`
-	OutletID    uuid.UUID `gorm:"type:char(36);not null"`
-	Total       int64     `gorm:"not null"`
-	IsPaid      *bool     `gorm:"not null;default:false" json:"is_paid"`
-	Note        string    `gorm:"type:text"`
+	ID          uuid.UUID  `gorm:"type:char(36);primary_key"`
+	UserID      uuid.UUID  `gorm:"type:char(36);not null"` // Cashier who rang up the sale
+	CustomerID  *uuid.UUID `gorm:"type:char(36)"`          // Member the sale was made to, if any
+	InvoiceCode string     `gorm:"size:20;not null;unique"`
+	OutletID    uuid.UUID  `gorm:"type:char(36);not null"`
+	ShiftID     *uuid.UUID `gorm:"type:char(36)"` // Cashier shift the sale was rung up in
+	Total       int64      `gorm:"not null"`
+	IsPaid      *bool      `gorm:"not null;default:false" json:"is_paid"`
+	PaidAt      *time.Time
+	RefundedAt  *time.Time
+	Note        string `gorm:"type:text"`
 	CreatedAt   time.Time
 	UpdatedAt   time.Time
 
 	// Relationships
 	User               User                `gorm:"foreignKey:UserID"`
+	Customer           *Customer           `gorm:"foreignKey:CustomerID"`
 	TransactionDetails []TransactionDetail `gorm:"foreignKey:TransactionID"`
 	Outlet             Outlet              `gorm:"foreignKey:OutletID"`
+	Payments           []Payment           `gorm:"foreignKey:TransactionID"`
 }
 
 // BeforeCreate is a GORM hook.
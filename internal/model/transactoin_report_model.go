@@ -24,14 +24,15 @@ func (cs *CategorySummary) Scan(value interface{}) error {
 	return json.Unmarshal(b, &cs)
 }
 
+// TransactionReport holds lifetime sales totals in a single row with ID 1.
 type TransactionReport struct {
-	ID                    uint8 `gorm:"primary_key"`
-	TotalRevenue          uint64
-	TotalPaidTransactions int64
-	TotalProductsSold     uint64
-	TotalUniqueCustomers  uint64
-	CategorySummary       CategorySummary `gorm:"type:json"`
-	UpdatedAt             time.Time
+	ID                    uint8           `gorm:"primary_key" json:"-"`
+	TotalRevenue          uint64          `json:"total_revenue"`
+	TotalPaidTransactions int64           `json:"total_paid_transactions"`
+	TotalProductsSold     uint64          `json:"total_products_sold"`
+	TotalUniqueCustomers  uint64          `json:"total_unique_customers"`
+	CategorySummary       CategorySummary `gorm:"type:json" json:"category_summary"`
+	UpdatedAt             time.Time       `json:"updated_at"` // When the totals last changed
 }
 
 func (tr *TransactionReport) Save(db *gorm.DB) error {
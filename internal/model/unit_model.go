@@ -0,0 +1,104 @@
+package model
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Unit is an entry in the units-of-measure catalog.
+type Unit struct {
+	Code string `gorm:"size:20;primary_key" json:"code"`
+	Name string `gorm:"size:100;not null" json:"name"`
+}
+
+// Save creates or updates a unit record.
+func (u *Unit) Save(db *gorm.DB) error {
+	return db.WithContext(context.Background()).Save(u).Error
+}
+
+// FindAll retrieves the whole units catalog.
+func (u *Unit) FindAll(db *gorm.DB) ([]Unit, error) {
+	var units []Unit
+	err := db.Order("code").Find(&units).Error
+	return units, err
+}
+
+// ProductUnit converts one unit of a product into its base unit:
+// one UnitCode equals Factor base units.
+type ProductUnit struct {
+	ID        uuid.UUID `gorm:"type:char(36);primary_key" json:"id"`
+	ProductID uuid.UUID `gorm:"type:char(36);not null" json:"product_id"`
+	UnitCode  string    `gorm:"size:20;not null" json:"unit"`
+	Factor    int64     `gorm:"not null" json:"factor"`
+}
+
+// BeforeCreate is a GORM hook that runs before creating a new conversion.
+func (pu *ProductUnit) BeforeCreate(tx *gorm.DB) (err error) {
+	pu.ID = uuid.New()
+	return
+}
+
+// Factor returns how many base units one unit is worth for this product.
+// An empty unit means the base unit. Units must be preloaded.
+func (p *Product) Factor(unit string) (int64, error) {
+	if unit == "" || unit == p.BaseUnit {
+		return 1, nil
+	}
+	for _, pu := range p.Units {
+		if pu.UnitCode == unit {
+			return pu.Factor, nil
+		}
+	}
+	return 0, fmt.Errorf("unit %q is not defined for product %s", unit, p.Name)
+}
+
+// ToBase converts a quantity entered in unit into base units.
+func (p *Product) ToBase(unit string, qty int64) (int64, error) {
+	factor, err := p.Factor(unit)
+	if err != nil {
+		return 0, err
+	}
+	return qty * factor, nil
+}
+
+// FromBase renders a base quantity in unit.
+func (p *Product) FromBase(unit string, baseQty int64) (float64, error) {
+	factor, err := p.Factor(unit)
+	if err != nil {
+		return 0, err
+	}
+	return float64(baseQty) / float64(factor), nil
+}
+
+// PurchaseUnitOrBase returns the unit stock is bought in, falling back to the base unit.
+func (p *Product) PurchaseUnitOrBase() string {
+	if p.PurchaseUnit != nil {
+		return *p.PurchaseUnit
+	}
+	return p.BaseUnit
+}
+
+// SalesUnitOrBase returns the unit stock is sold in, falling back to the base unit.
+func (p *Product) SalesUnitOrBase() string {
+	if p.SalesUnit != nil {
+		return *p.SalesUnit
+	}
+	return p.BaseUnit
+}
+
+// FindProductsWithUnits loads products with their unit conversions, keyed by ID.
+func FindProductsWithUnits(db *gorm.DB, ids []uuid.UUID) (map[uuid.UUID]*Product, error) {
+	var products []Product
+	if err := db.Preload("Units").Where("id IN ?", ids).Find(&products).Error; err != nil {
+		return nil, err
+	}
+
+	byID := make(map[uuid.UUID]*Product, len(products))
+	for i := range products {
+		byID[products[i].ID] = &products[i]
+	}
+	return byID, nil
+}
@@ -0,0 +1,57 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Upload purposes; each names what the file is attached to on completion.
+const (
+	UploadPurposeProductImage = "product_image"
+	UploadPurposeAvatar       = "avatar"
+)
+
+// Upload modes. Direct uploads are PUT to a presigned storage URL; chunked
+// uploads are sent to the app in pieces when the storage driver has no
+// upload URL of its own.
+const (
+	UploadModeDirect  = "direct"
+	UploadModeChunked = "chunked"
+)
+
+// Upload statuses.
+const (
+	UploadPending   = "pending"
+	UploadCompleted = "completed" // Verified and attached to its target
+	UploadFailed    = "failed"    // The file did not match its declared size or checksum
+	UploadExpired   = "expired"   // Not completed in time; the file was deleted
+)
+
+// Upload is a slot a client uploads one file into before confirming it.
+type Upload struct {
+	ID            uuid.UUID  `gorm:"type:char(36);primary_key" json:"id"`
+	UserID        uuid.UUID  `gorm:"type:char(36);not null" json:"user_id"` // Only this user can send and complete the upload
+	Purpose       string     `gorm:"size:30;not null" json:"purpose"`
+	TargetID      uuid.UUID  `gorm:"type:char(36);not null" json:"target_id"` // Product or user the file is attached to
+	Mode          string     `gorm:"size:10;not null" json:"mode"`
+	Status        string     `gorm:"size:20;not null;default:'pending'" json:"status"`
+	ObjectKey     string     `gorm:"size:255;not null" json:"-"` // Storage key of a direct upload, staged file of a chunked one
+	FileName      string     `gorm:"size:255;not null;default:''" json:"file_name"`
+	ContentType   string     `gorm:"size:100;not null" json:"content_type"`
+	Size          int64      `gorm:"not null" json:"size"`
+	Checksum      string     `gorm:"size:64;not null" json:"checksum"`         // Hex SHA-256 declared by the client
+	ReceivedBytes int64      `gorm:"not null;default:0" json:"received_bytes"` // Chunked uploads only
+	Failure       string     `gorm:"type:text" json:"failure,omitempty"`       // Why verification failed
+	ExpiresAt     time.Time  `json:"expires_at"`
+	CompletedAt   *time.Time `json:"completed_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// BeforeCreate is a GORM hook.
+func (u *Upload) BeforeCreate(tx *gorm.DB) (err error) {
+	u.ID = uuid.New()
+	return
+}
@@ -18,6 +18,11 @@ type User struct {
 	ImageStatus string    `gorm:"size:20;not null;default:'default'" json:"image_status"` // New field
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
+
+	// AvatarKey is the storage prefix of the avatar renditions; AvatarURL
+	// points at the large one.
+	AvatarKey      string        `gorm:"size:255;not null;default:''" json:"-"`
+	AvatarVariants ImageVariants `gorm:"type:json" json:"avatar_variants,omitempty"`
 }
 
 // BeforeCreate is a GORM hook that runs before a new record is created.
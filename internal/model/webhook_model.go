@@ -0,0 +1,86 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Webhook delivery statuses.
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliverySucceeded = "succeeded"
+	WebhookDeliveryFailed    = "failed" // Gave up after the last retry
+)
+
+// WebhookEventTypes is stored as a JSON array.
+type WebhookEventTypes []string
+
+func (t WebhookEventTypes) Value() (driver.Value, error) {
+	return json.Marshal(t)
+}
+
+func (t *WebhookEventTypes) Scan(value interface{}) error {
+	b, ok := value.([]byte)
+	if !ok {
+		return errors.New("failed to scan WebhookEventTypes: value is not a byte slice")
+	}
+	return json.Unmarshal(b, t)
+}
+
+// Contains reports whether the subscription lists an event type.
+func (t WebhookEventTypes) Contains(eventType string) bool {
+	for _, subscribed := range t {
+		if subscribed == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookSubscription is an integrator endpoint that receives events.
+type WebhookSubscription struct {
+	ID         uuid.UUID         `gorm:"type:char(36);primary_key" json:"id"`
+	OutletID   *uuid.UUID        `gorm:"type:char(36)" json:"outlet_id"` // Only events about this outlet; nil receives every event
+	URL        string            `gorm:"size:2048;not null" json:"url"`
+	Secret     string            `gorm:"size:255;not null" json:"-"` // Signs every delivery
+	EventTypes WebhookEventTypes `gorm:"type:json;not null" json:"event_types"`
+	IsActive   bool              `gorm:"not null;default:true" json:"is_active"`
+	CreatedAt  time.Time         `json:"created_at"`
+	UpdatedAt  time.Time         `json:"updated_at"`
+}
+
+// BeforeCreate is a GORM hook.
+func (w *WebhookSubscription) BeforeCreate(tx *gorm.DB) (err error) {
+	w.ID = uuid.New()
+	return
+}
+
+// WebhookDelivery logs one event sent, or being sent, to a subscription.
+type WebhookDelivery struct {
+	ID             uuid.UUID  `gorm:"type:char(36);primary_key" json:"id"`
+	SubscriptionID uuid.UUID  `gorm:"type:char(36);not null" json:"subscription_id"`
+	EventID        *uint64    `json:"event_id"` // Nil for pings
+	EventType      string     `gorm:"size:100;not null" json:"event_type"`
+	Payload        string     `gorm:"type:json;not null" json:"payload"` // The exact request body
+	Status         string     `gorm:"size:20;not null;default:'pending'" json:"status"`
+	Attempts       int        `gorm:"not null;default:0" json:"attempts"`
+	ResponseStatus *int       `json:"response_status"`
+	ResponseBody   string     `gorm:"type:text" json:"response_body"` // Truncated
+	LastError      string     `gorm:"type:text" json:"last_error"`
+	DurationMs     *int       `json:"duration_ms"`
+	LastAttemptAt  *time.Time `json:"last_attempt_at"`
+	DeliveredAt    *time.Time `json:"delivered_at"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// BeforeCreate is a GORM hook.
+func (d *WebhookDelivery) BeforeCreate(tx *gorm.DB) (err error) {
+	d.ID = uuid.New()
+	return
+}
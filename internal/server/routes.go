@@ -1,55 +1,113 @@
 package server
 
 import (
-	"sync"
+	"log/slog"
+	"os"
 	"venturo-core/configs"
 	"venturo-core/internal/adapter/storage"
+	"venturo-core/internal/database"
 	"venturo-core/internal/handler/http"
 	"venturo-core/internal/middleware"
 	"venturo-core/internal/service"
+	"venturo-core/pkg/events"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/swagger"
-	"gorm.io/gorm"
 )
 
-func registerRoutes(app *fiber.App, db *gorm.DB, conf *configs.Config, wg *sync.WaitGroup) {
+// registerRoutes wires the services and routes. Writes go to the resolver's
+// primary; services with reports or listings read through the resolver.
+func registerRoutes(app *fiber.App, resolver *database.Resolver, conf *configs.Config, workers *Workers) {
+	db := resolver.Primary()
+
 	app.Static("/public", "./public")
 	app.Get("/swagger/*", swagger.HandlerDefault)
 
-	// Health check endpoint
-	app.Get("/health", func(c *fiber.Ctx) error {
-		return c.JSON(fiber.Map{
-			"status":  "ok",
-			"message": "Welcome to Venturo Core!",
-		})
-	})
+	// --- Setups ---
+	authMiddleware := middleware.NewAuthMiddleware(conf.Auth.JWTSecret)
 
-	api := app.Group("/api/v1")
+	// Health probes and pool statistics. The probes stay open for the
+	// orchestrator; the pool statistics describe the deployment, so they are not.
+	healthHandler := http.NewHealthHandler(service.NewHealthService(resolver))
+	app.Get("/livez", healthHandler.Livez)
+	app.Get("/readyz", healthHandler.Readyz)
+	app.Get("/metrics/db", authMiddleware, healthHandler.GetPoolStats) // Protected
 
-	// --- Setups ---
-	authMiddleware := middleware.NewAuthMiddleware(conf.JWTSecretKey)
+	// X-Read-Primary: true keeps a request's reads off the replicas.
+	api := app.Group("/api/v1", middleware.NewReadPrimaryMiddleware())
 
 	// --- Setup Adapters ---
-	localUploader := storage.NewLocalUploaderAdapter("./public/uploads")
+	storageAdapter, err := storage.New(storage.Config{
+		Driver:     conf.Storage.Driver,
+		LocalPath:  conf.Storage.LocalPath,
+		SigningKey: conf.Storage.SigningKey,
+		Endpoint:   conf.Storage.S3Endpoint,
+		Region:     conf.Storage.S3Region,
+		Bucket:     conf.Storage.S3Bucket,
+		AccessKey:  conf.Storage.S3AccessKey,
+		SecretKey:  conf.Storage.S3SecretKey,
+		UseSSL:     conf.Storage.S3UseSSL,
+		ACL:        conf.Storage.S3ACL,
+		PublicURL:  conf.Storage.S3PublicURL,
+	})
+	if err != nil {
+		slog.Error("could not set up file storage", "driver", conf.Storage.Driver, "error", err)
+		os.Exit(1)
+	}
+
+	// Signed URLs of the local driver are served by the app itself.
+	if localStorage, ok := storageAdapter.(*storage.LocalUploaderAdapter); ok {
+		fileHandler := http.NewFileHandler(localStorage)
+		app.Get(storage.SignedFilesPath+"*", fileHandler.ServeSigned)
+	}
 
 	// --- Setup services ---
 	authService := service.NewAuthService(db, conf)
-	userService := service.NewUserService(db, wg)
+	userService := service.NewUserService(db, storageAdapter, conf.Storage.StagingPath)
 	postService := service.NewPostService(db)
-	transactionService := service.NewTransactionService(db, wg)
-	productService := service.NewProductService(db, wg, localUploader)
+	transactionService := service.NewTransactionService(resolver)
+	productService := service.NewProductService(db, storageAdapter, conf.Storage.StagingPath)
 	inventoryService := service.NewInventoryService(db)
-	reportService := service.NewReportService(db)
+	reportService := service.NewReportService(resolver)
+	unitService := service.NewUnitService(db)
+	shiftService := service.NewShiftService(db)
+	outletService := service.NewOutletService(db)
+	importService := service.NewImportService(db)
+	customerService := service.NewCustomerService(resolver)
+	loyaltyService := service.NewLoyaltyService(db)
+	webhookService := service.NewWebhookService(db)
+	uploadService := service.NewUploadService(db, storageAdapter, conf.Storage.StagingPath, productService, userService)
+
+	// --- Setup background jobs ---
+	userService.RegisterJobs(workers.Jobs)
+	productService.RegisterJobs(workers.Jobs)
+	importService.RegisterJobs(workers.Jobs)
+	webhookService.RegisterJobs(workers.Jobs)
+	uploadService.RegisterJobs(workers.Jobs)
+
+	// --- Setup event subscribers ---
+	inventoryService.RegisterSubscribers(workers.Events)
+	workers.Events.AddSink(webhookService)
+	if conf.Events.LogSink {
+		workers.Events.AddSink(events.LogSink{})
+	}
 
 	// --- Setup handlers ---
 	authHandler := http.NewAuthHandler(authService)
 	userHandler := http.NewUserHandler(userService)
 	postHandler := http.NewPostHandler(postService)
-	transactionHandler := http.NewTransactionHandler(transactionService)
+	transactionHandler := http.NewTransactionHandler(transactionService, outletService)
 	productHandler := http.NewProductHandler(productService)
 	inventoryHandler := http.NewInventoryHandler(inventoryService)
-	reportHandler := http.NewReportHandler(reportService)
+	reportHandler := http.NewReportHandler(reportService, outletService)
+	unitHandler := http.NewUnitHandler(unitService)
+	shiftHandler := http.NewShiftHandler(shiftService)
+	outletHandler := http.NewOutletHandler(outletService)
+	importHandler := http.NewImportHandler(importService)
+	customerHandler := http.NewCustomerHandler(customerService)
+	loyaltyHandler := http.NewLoyaltyHandler(loyaltyService)
+	webhookHandler := http.NewWebhookHandler(webhookService)
+	uploadHandler := http.NewUploadHandler(uploadService)
 
 	// --- Auth routes ---
 	api.Post("/register", authHandler.Register)
@@ -71,18 +129,88 @@ func registerRoutes(app *fiber.App, db *gorm.DB, conf *configs.Config, wg *sync.
 
 	// --- Transaction routes ---
 	transactionRoutes := api.Group("/transactions")
+	transactionRoutes.Get("/", authMiddleware, transactionHandler.GetTransactions)    // Protected
 	transactionRoutes.Post("/", authMiddleware, transactionHandler.CreateTransaction) // Protected
 	transactionRoutes.Post("/:id/pay", authMiddleware, transactionHandler.MarkAsPaid) // Protected
+	transactionRoutes.Post("/:id/refund", authMiddleware, transactionHandler.Refund)  // Protected
+
+	// --- Customer routes ---
+	customerRoutes := api.Group("/customers")
+	customerRoutes.Get("/", authMiddleware, customerHandler.GetCustomers)                       // Protected
+	customerRoutes.Post("/", authMiddleware, customerHandler.CreateCustomer)                    // Protected
+	customerRoutes.Get("/:id", authMiddleware, customerHandler.GetCustomer)                     // Protected
+	customerRoutes.Put("/:id", authMiddleware, customerHandler.UpdateCustomer)                  // Protected
+	customerRoutes.Get("/:id/transactions", authMiddleware, customerHandler.GetPurchaseHistory) // Protected
+	customerRoutes.Get("/:id/points", authMiddleware, loyaltyHandler.GetBalance)                // Protected
+	customerRoutes.Get("/:id/points/history", authMiddleware, loyaltyHandler.GetHistory)        // Protected
+
+	// --- Loyalty routes ---
+	loyaltyRoutes := api.Group("/loyalty")
+	loyaltyRoutes.Get("/rules", authMiddleware, loyaltyHandler.GetRules)    // Protected
+	loyaltyRoutes.Put("/rules", authMiddleware, loyaltyHandler.UpdateRules) // Protected
+
+	// --- Webhook routes ---
+	webhookRoutes := api.Group("/webhooks")
+	webhookRoutes.Get("/", authMiddleware, webhookHandler.GetWebhooks)                                     // Protected
+	webhookRoutes.Post("/", authMiddleware, webhookHandler.CreateWebhook)                                  // Protected
+	webhookRoutes.Get("/:id", authMiddleware, webhookHandler.GetWebhook)                                   // Protected
+	webhookRoutes.Put("/:id", authMiddleware, webhookHandler.UpdateWebhook)                                // Protected
+	webhookRoutes.Delete("/:id", authMiddleware, webhookHandler.DeleteWebhook)                             // Protected
+	webhookRoutes.Post("/:id/ping", authMiddleware, webhookHandler.Ping)                                   // Protected
+	webhookRoutes.Get("/:id/deliveries", authMiddleware, webhookHandler.GetDeliveries)                     // Protected
+	webhookRoutes.Post("/:id/deliveries/:delivery_id/redeliver", authMiddleware, webhookHandler.Redeliver) // Protected
 
 	// --- Product routes ---
 	productRoutes := api.Group("/products")
-	productRoutes.Post("/", authMiddleware, productHandler.CreateProduct) // Protected
+	productRoutes.Post("/", authMiddleware, productHandler.CreateProduct)              // Protected
+	productRoutes.Get("/:id", authMiddleware, productHandler.GetProductByID)           // Protected
+	productRoutes.Put("/:id", authMiddleware, productHandler.UpdateProduct)            // Protected
+	productRoutes.Put("/:id/image", authMiddleware, productHandler.ReplaceImage)       // Protected
+	productRoutes.Put("/:id/components", authMiddleware, productHandler.SetComponents) // Protected
+	productRoutes.Put("/:id/units", authMiddleware, productHandler.SetUnits)           // Protected
+
+	// --- Upload routes ---
+	uploadRoutes := api.Group("/uploads")
+	uploadRoutes.Post("/", authMiddleware, uploadHandler.CreateUpload)               // Protected
+	uploadRoutes.Get("/:id", authMiddleware, uploadHandler.GetUpload)                // Protected
+	uploadRoutes.Patch("/:id", authMiddleware, uploadHandler.UploadChunk)            // Protected
+	uploadRoutes.Post("/:id/complete", authMiddleware, uploadHandler.CompleteUpload) // Protected
+
+	// --- Import routes ---
+	importRoutes := api.Group("/imports")
+	importRoutes.Post("/products", authMiddleware, importHandler.ImportProducts) // Protected
+	importRoutes.Get("/:id", authMiddleware, importHandler.GetImportJob)         // Protected
+
+	// --- Unit routes ---
+	unitRoutes := api.Group("/units")
+	unitRoutes.Get("/", authMiddleware, unitHandler.GetAllUnits) // Protected
+	unitRoutes.Post("/", authMiddleware, unitHandler.CreateUnit) // Protected
 
 	// --- Inventory routes ---
 	inventoryRoutes := api.Group("/inventory")
-	inventoryRoutes.Post("/stock-in", authMiddleware, inventoryHandler.StockIn) // Protected
+	inventoryRoutes.Post("/stock-in", authMiddleware, inventoryHandler.StockIn)                  // Protected
+	inventoryRoutes.Post("/write-off-expired", authMiddleware, inventoryHandler.WriteOffExpired) // Protected
+
+	// --- Outlet routes ---
+	outletRoutes := api.Group("/outlets")
+	outletRoutes.Patch("/:id", authMiddleware, outletHandler.UpdateSettings) // Protected
+
+	// --- Shift routes ---
+	shiftRoutes := api.Group("/shifts")
+	shiftRoutes.Post("/", authMiddleware, shiftHandler.OpenShift)                            // Protected
+	shiftRoutes.Get("/current", authMiddleware, shiftHandler.GetCurrentShift)                // Protected
+	shiftRoutes.Post("/:id/cash-movements", authMiddleware, shiftHandler.RecordCashMovement) // Protected
+	shiftRoutes.Get("/:id/report", authMiddleware, shiftHandler.GetShiftReport)              // Protected
+	shiftRoutes.Post("/:id/close", authMiddleware, shiftHandler.CloseShift)                  // Protected
 
 	// --- Report routes ---
 	reportRoutes := api.Group("/reports")
-	reportRoutes.Get("/inventory", authMiddleware, reportHandler.GetInventoryReport) // Protected
+	reportRoutes.Get("/inventory", authMiddleware, reportHandler.GetInventoryReport)         // Protected
+	reportRoutes.Get("/inventory/expiring", authMiddleware, reportHandler.GetExpiringReport) // Protected
+	reportRoutes.Get("/inventory/as-of", authMiddleware, reportHandler.GetStockAsOf)         // Protected
+	reportRoutes.Get("/sales", authMiddleware, reportHandler.GetSalesReport)                 // Protected
+	reportRoutes.Get("/summary", authMiddleware, reportHandler.GetSummary)                   // Protected
+	reportRoutes.Get("/products/top", authMiddleware, reportHandler.GetTopProducts)          // Protected
+	reportRoutes.Get("/products/slow-movers", authMiddleware, reportHandler.GetSlowMovers)   // Protected
+	reportRoutes.Get("/products/abc", authMiddleware, reportHandler.GetABCAnalysis)          // Protected
 }
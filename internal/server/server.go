@@ -1,44 +1,79 @@
 package server
 
 import (
+	"context"
 	"log/slog"
 	"os"
-	"sync"
-	"time"
 	"venturo-core/configs"
 	"venturo-core/internal/database"
+	"venturo-core/pkg/events"
+	"venturo-core/pkg/jobs"
 
 	"github.com/gofiber/fiber/v2"
 )
 
-// NewServer creates and configures a new Fiber application.
-func NewServer() (*fiber.App, *sync.WaitGroup) {
-	config, err := configs.LoadConfig()
+// Workers are the background processes that run next to the HTTP server.
+type Workers struct {
+	Jobs   *jobs.Queue
+	Events *events.Dispatcher
+}
+
+// NewServer creates and configures a new Fiber application and starts the
+// background workers.
+func NewServer(config *configs.Config) (*fiber.App, *Workers) {
+	db, err := database.ConnectDB(config)
 	if err != nil {
-		slog.Error("Failed to load configuration", "error", err)
+		slog.Error("Failed to connect to database", "error", err)
 		os.Exit(1)
 	}
-
-	database.ConnectDB(&config)
+	replicas, err := database.ConnectReplicas(config)
+	if err != nil {
+		slog.Error("Failed to set up read replicas", "error", err)
+		os.Exit(1)
+	}
+	resolver := database.NewResolver(db, replicas)
 
 	app := fiber.New()
 
-	var wg sync.WaitGroup
+	queue := jobs.New(db, jobs.Options{
+		Workers:           config.Jobs.Workers,
+		PollInterval:      config.Jobs.PollInterval,
+		VisibilityTimeout: config.Jobs.VisibilityTimeout,
+	})
+	workers := &Workers{
+		Jobs:   queue,
+		Events: events.NewDispatcher(db, queue),
+	}
+
+	registerRoutes(app, resolver, config, workers)
 
-	registerRoutes(app, database.DB, &config, &wg)
+	workers.Jobs.Start()
+	workers.Events.Start()
 
-	return app, &wg
+	return app, workers
 }
 
-func GracefulShutdown(app *fiber.App, wg *sync.WaitGroup) {
+// GracefulShutdown stops accepting requests and dispatching events, then
+// waits for running jobs. Jobs still running after the drain timeout are
+// retried once their visibility timeout expires.
+func GracefulShutdown(app *fiber.App, workers *Workers, config *configs.Config) {
 	slog.Info("Gracefully shutting down...")
-	slog.Info("Waiting for background processes to finish...")
-	wg.Wait()
-	slog.Info("All background processes finished.")
 
-	if err := app.ShutdownWithTimeout(5 * time.Second); err != nil {
+	if err := app.ShutdownWithTimeout(config.Server.ShutdownTimeout); err != nil {
 		slog.Error("Server shutdown failed", "error", err)
 		os.Exit(1)
 	}
 	slog.Info("Server gracefully stopped.")
+
+	slog.Info("Waiting for background jobs to finish...")
+	ctx, cancel := context.WithTimeout(context.Background(), config.Jobs.DrainTimeout)
+	defer cancel()
+	if err := workers.Events.Shutdown(ctx); err != nil {
+		slog.Error("Event dispatcher did not stop in time", "error", err)
+	}
+	if err := workers.Jobs.Shutdown(ctx); err != nil {
+		slog.Error("Background jobs did not finish in time", "error", err)
+		os.Exit(1)
+	}
+	slog.Info("All background jobs finished.")
 }
@@ -66,7 +66,7 @@ func (s *AuthService) Login(ctx context.Context, email, password string) (map[st
 	}
 
 	// Generate access token (JWT)
-	accessToken, err := utils.GenerateToken(user.ID, s.conf.JWTSecretKey)
+	accessToken, err := utils.GenerateToken(user.ID, s.conf.Auth.JWTSecret, s.conf.Auth.AccessTokenTTL)
 	if err != nil {
 		return nil, errors.New("could not generate access token")
 	}
@@ -128,7 +128,7 @@ func (s *AuthService) RefreshToken(ctx context.Context, refreshTokenString strin
 	}
 
 	// Generate new access token
-	accessToken, err := utils.GenerateToken(validRefreshToken.UserID, s.conf.JWTSecretKey)
+	accessToken, err := utils.GenerateToken(validRefreshToken.UserID, s.conf.Auth.JWTSecret, s.conf.Auth.AccessTokenTTL)
 	if err != nil {
 		return nil, errors.New("could not generate access token")
 	}
@@ -0,0 +1,270 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+	"venturo-core/internal/database"
+	"venturo-core/internal/model"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrCustomerExists is returned when a member code, phone or email is already registered.
+var ErrCustomerExists = errors.New("a customer with this member code, phone or email already exists")
+
+// memberCodeAttempts bounds the retries when a generated member code is taken.
+const memberCodeAttempts = 5
+
+// CustomerService manages the member directory. Listings read from the
+// replicas, if any.
+type CustomerService struct {
+	db       *gorm.DB
+	resolver *database.Resolver
+}
+
+// NewCustomerService creates a new customer service.
+func NewCustomerService(resolver *database.Resolver) *CustomerService {
+	return &CustomerService{db: resolver.Primary(), resolver: resolver}
+}
+
+// CustomerInput holds a customer's details. On update, nil fields are left as
+// they are and an empty phone or email clears it.
+type CustomerInput struct {
+	MemberCode *string // Generated when empty on create
+	Name       *string
+	Phone      *string
+	Email      *string
+}
+
+// CreateCustomer registers a new member.
+func (s *CustomerService) CreateCustomer(ctx context.Context, input CustomerInput) (*model.Customer, error) {
+	customer := model.Customer{
+		Phone: optionalString(input.Phone),
+		Email: optionalString(input.Email),
+	}
+	if input.Name != nil {
+		customer.Name = strings.TrimSpace(*input.Name)
+	}
+	if input.MemberCode != nil {
+		customer.MemberCode = strings.TrimSpace(*input.MemberCode)
+	}
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if customer.MemberCode == "" {
+			code, err := generateMemberCode(tx)
+			if err != nil {
+				return err
+			}
+			customer.MemberCode = code
+		}
+		if err := checkCustomerUnique(tx, &customer); err != nil {
+			return err
+		}
+		return tx.Create(&customer).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &customer, nil
+}
+
+// UpdateCustomer changes a member's details.
+func (s *CustomerService) UpdateCustomer(ctx context.Context, id uuid.UUID, input CustomerInput) (*model.Customer, error) {
+	var customer model.Customer
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&customer, "id = ?", id).Error; err != nil {
+			return errors.New("customer not found")
+		}
+
+		if input.MemberCode != nil && strings.TrimSpace(*input.MemberCode) != "" {
+			customer.MemberCode = strings.TrimSpace(*input.MemberCode)
+		}
+		if input.Name != nil {
+			customer.Name = strings.TrimSpace(*input.Name)
+		}
+		if input.Phone != nil {
+			customer.Phone = optionalString(input.Phone)
+		}
+		if input.Email != nil {
+			customer.Email = optionalString(input.Email)
+		}
+
+		if err := checkCustomerUnique(tx, &customer); err != nil {
+			return err
+		}
+		return tx.Model(&customer).Select("member_code", "name", "phone", "email").Updates(&customer).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &customer, nil
+}
+
+// ListCustomers returns one page of members, optionally matching a search
+// term against the start of their name, member code, phone or email.
+func (s *CustomerService) ListCustomers(ctx context.Context, search string, page, limit int) ([]model.Customer, int64, error) {
+	query := s.resolver.Reader(ctx).Model(&model.Customer{})
+	if search = strings.TrimSpace(search); search != "" {
+		prefix := escapeLike(search) + "%"
+		query = query.Where("name LIKE ? OR member_code LIKE ? OR phone LIKE ? OR email LIKE ?", prefix, prefix, prefix, prefix)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	customers := []model.Customer{}
+	err := query.Order("name, id").Offset((page - 1) * limit).Limit(limit).Find(&customers).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return customers, total, nil
+}
+
+// CustomerProfile is a member with their purchase totals.
+type CustomerProfile struct {
+	model.Customer
+	PaidTransactions int64      `json:"paid_transactions"`
+	TotalSpent       int64      `json:"total_spent"`
+	FirstPurchaseAt  *time.Time `json:"first_purchase_at"`
+	LastPurchaseAt   *time.Time `json:"last_purchase_at"`
+}
+
+// GetCustomer returns a member with their purchase totals.
+func (s *CustomerService) GetCustomer(ctx context.Context, id uuid.UUID) (*CustomerProfile, error) {
+	var profile CustomerProfile
+	if err := s.db.WithContext(ctx).First(&profile.Customer, "id = ?", id).Error; err != nil {
+		return nil, errors.New("customer not found")
+	}
+
+	var firstPurchaseAt, lastPurchaseAt sql.NullTime
+	err := s.db.WithContext(ctx).Model(&model.Transaction{}).
+		Select("COUNT(*), COALESCE(SUM(total), 0), MIN(COALESCE(paid_at, created_at)), MAX(COALESCE(paid_at, created_at))").
+		Where("customer_id = ? AND is_paid = ?", id, true).
+		Row().
+		Scan(&profile.PaidTransactions, &profile.TotalSpent, &firstPurchaseAt, &lastPurchaseAt)
+	if err != nil {
+		return nil, err
+	}
+	if firstPurchaseAt.Valid {
+		profile.FirstPurchaseAt = &firstPurchaseAt.Time
+		profile.LastPurchaseAt = &lastPurchaseAt.Time
+	}
+
+	return &profile, nil
+}
+
+// GetPurchaseHistory returns one page of a member's paid transactions, newest
+// first, with their items and payments.
+func (s *CustomerService) GetPurchaseHistory(ctx context.Context, id uuid.UUID, page, limit int) ([]model.Transaction, int64, error) {
+	reader := s.resolver.Reader(ctx)
+
+	var customer model.Customer
+	if err := reader.Select("id").First(&customer, "id = ?", id).Error; err != nil {
+		return nil, 0, errors.New("customer not found")
+	}
+
+	query := reader.Model(&model.Transaction{}).Where("customer_id = ? AND is_paid = ?", id, true)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	transactions := []model.Transaction{}
+	err := query.
+		Preload("Outlet").
+		Preload("TransactionDetails").
+		Preload("Payments").
+		Order("paid_at DESC, id DESC").
+		Offset((page - 1) * limit).
+		Limit(limit).
+		Find(&transactions).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return transactions, total, nil
+}
+
+// checkCustomerUnique rejects a member code, phone or email used by another customer.
+func checkCustomerUnique(tx *gorm.DB, customer *model.Customer) error {
+	conditions := []string{"member_code = ?"}
+	args := []interface{}{customer.MemberCode}
+	if customer.Phone != nil {
+		conditions = append(conditions, "phone = ?")
+		args = append(args, *customer.Phone)
+	}
+	if customer.Email != nil {
+		conditions = append(conditions, "email = ?")
+		args = append(args, *customer.Email)
+	}
+
+	var taken int64
+	err := tx.Model(&model.Customer{}).
+		Where("("+strings.Join(conditions, " OR ")+")", args...).
+		Where("id <> ?", customer.ID).
+		Count(&taken).Error
+	if err != nil {
+		return err
+	}
+	if taken > 0 {
+		return ErrCustomerExists
+	}
+	return nil
+}
+
+// generateMemberCode returns an unused member code such as M04817263.
+func generateMemberCode(tx *gorm.DB) (string, error) {
+	for i := 0; i < memberCodeAttempts; i++ {
+		code := fmt.Sprintf("M%08d", rand.Intn(100000000))
+
+		var taken int64
+		if err := tx.Model(&model.Customer{}).Where("member_code = ?", code).Count(&taken).Error; err != nil {
+			return "", err
+		}
+		if taken == 0 {
+			return code, nil
+		}
+	}
+	return "", errors.New("could not generate a unique member code")
+}
+
+// optionalString trims s and turns an empty value into nil.
+func optionalString(s *string) *string {
+	if s == nil {
+		return nil
+	}
+	trimmed := strings.TrimSpace(*s)
+	if trimmed == "" {
+		return nil
+	}
+	return &trimmed
+}
+
+// escapeLike escapes the LIKE wildcards in a search term.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// lockCustomer locks the customer row, serializing concurrent payments by the
+// same customer and changes to their points.
+func lockCustomer(tx *gorm.DB, customerID uuid.UUID) error {
+	var customer model.Customer
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&customer, "id = ?", customerID).Error
+	if err != nil {
+		return errors.New("customer not found")
+	}
+	return nil
+}
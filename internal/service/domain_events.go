@@ -0,0 +1,155 @@
+package service
+
+import (
+	"time"
+	"venturo-core/internal/model"
+	"venturo-core/pkg/events"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Domain event types published to the outbox.
+const (
+	EventTransactionCreated = "transaction.created"
+	EventTransactionPaid    = "transaction.paid"
+	EventStockChanged       = "stock.changed"
+	EventLowStock           = "inventory.low_stock"
+	EventProductCreated     = "product.created"
+	EventProductUpdated     = "product.updated"
+)
+
+// TransactionCreatedEvent is published when a sale is recorded.
+type TransactionCreatedEvent struct {
+	TransactionID uuid.UUID         `json:"transaction_id"`
+	OutletID      uuid.UUID         `json:"outlet_id"`
+	CustomerID    *uuid.UUID        `json:"customer_id"`
+	InvoiceCode   string            `json:"invoice_code"`
+	Total         int64             `json:"total"`
+	Items         []TransactionItem `json:"items"`
+	CreatedAt     time.Time         `json:"created_at"`
+}
+
+// TransactionItem is one sold line of a TransactionCreatedEvent.
+type TransactionItem struct {
+	ProductID   uuid.UUID `json:"product_id"`
+	ProductName string    `json:"product_name"`
+	Qty         int8      `json:"qty"`
+	Price       int32     `json:"price"`
+}
+
+// TransactionPaidEvent is published when a transaction is paid.
+type TransactionPaidEvent struct {
+	TransactionID uuid.UUID     `json:"transaction_id"`
+	OutletID      uuid.UUID     `json:"outlet_id"`
+	CustomerID    *uuid.UUID    `json:"customer_id"`
+	InvoiceCode   string        `json:"invoice_code"`
+	Total         int64         `json:"total"`
+	Payments      []PaidPayment `json:"payments"`
+	PaidAt        time.Time     `json:"paid_at"`
+}
+
+// PaidPayment is one tender of a paid transaction.
+type PaidPayment struct {
+	Method string `json:"method"`
+	Amount int64  `json:"amount"`
+}
+
+// StockChange is the net movement of one product at one outlet, in base
+// units, and the on-hand quantity it left.
+type StockChange struct {
+	ProductID      uuid.UUID `json:"product_id"`
+	OutletID       uuid.UUID `json:"outlet_id"`
+	QuantityChange int       `json:"quantity_change"`
+	OnHandQty      int64     `json:"on_hand_quantity"`
+}
+
+// StockChangedEvent is published when inventory ledger entries are posted.
+// Reason is the ledger entry type.
+type StockChangedEvent struct {
+	Reason        string        `json:"reason"`
+	TransactionID *uuid.UUID    `json:"transaction_id"`
+	Changes       []StockChange `json:"changes"`
+}
+
+// LowStockEvent is published when a product's on-hand quantity at an outlet
+// falls to its low-stock threshold.
+type LowStockEvent struct {
+	ProductID   uuid.UUID `json:"product_id"`
+	ProductName string    `json:"product_name"`
+	OutletID    uuid.UUID `json:"outlet_id"`
+	OnHandQty   int64     `json:"on_hand_quantity"`
+	Threshold   int       `json:"threshold"`
+}
+
+// ProductEvent is published when a product is created or updated.
+type ProductEvent struct {
+	ProductID         uuid.UUID `json:"product_id"`
+	SKU               *string   `json:"sku"`
+	Name              string    `json:"name"`
+	Price             int32     `json:"price"`
+	BaseUnit          string    `json:"base_unit"`
+	PurchaseUnit      *string   `json:"purchase_unit"`
+	SalesUnit         *string   `json:"sales_unit"`
+	LowStockThreshold *int      `json:"low_stock_threshold"`
+}
+
+// publishStockChanged publishes a StockChangedEvent, merging the changes per
+// product and outlet and reading the resulting balances inside tx. Nothing is
+// published when no stock moved.
+func publishStockChanged(tx *gorm.DB, reason string, transactionID *uuid.UUID, changes []StockChange) error {
+	type key struct{ productID, outletID uuid.UUID }
+	index := make(map[key]int)
+	merged := []StockChange{}
+	for _, change := range changes {
+		k := key{change.ProductID, change.OutletID}
+		if i, ok := index[k]; ok {
+			merged[i].QuantityChange += change.QuantityChange
+			continue
+		}
+		index[k] = len(merged)
+		merged = append(merged, change)
+	}
+	if len(merged) == 0 {
+		return nil
+	}
+
+	for i := range merged {
+		err := tx.Model(&model.InventoryLedger{}).
+			Select("COALESCE(SUM(quantity_change), 0)").
+			Where("item_id = ? AND outlet_id = ?", merged[i].ProductID, merged[i].OutletID).
+			Scan(&merged[i].OnHandQty).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	return events.Publish(tx, EventStockChanged, StockChangedEvent{
+		Reason:        reason,
+		TransactionID: transactionID,
+		Changes:       merged,
+	})
+}
+
+// publishProductEvent publishes a ProductEvent of the given type.
+func publishProductEvent(tx *gorm.DB, eventType string, product *model.Product) error {
+	return events.Publish(tx, eventType, ProductEvent{
+		ProductID:         product.ID,
+		SKU:               product.SKU,
+		Name:              product.Name,
+		Price:             product.Price,
+		BaseUnit:          product.BaseUnit,
+		PurchaseUnit:      product.PurchaseUnit,
+		SalesUnit:         product.SalesUnit,
+		LowStockThreshold: product.LowStockThreshold,
+	})
+}
+
+// publishProductUpdated reloads a product and publishes EventProductUpdated.
+func publishProductUpdated(tx *gorm.DB, productID uuid.UUID) error {
+	var product model.Product
+	if err := tx.First(&product, "id = ?", productID).Error; err != nil {
+		return err
+	}
+	return publishProductEvent(tx, EventProductUpdated, &product)
+}
@@ -0,0 +1,167 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+	"venturo-core/internal/database"
+
+	"gorm.io/gorm"
+)
+
+// readinessTimeout bounds the checks of the primary, and separately those of
+// the replicas, in one readiness probe.
+const readinessTimeout = 2 * time.Second
+
+// Readiness is the outcome of a readiness probe. Replicas are reported but do
+// not affect readiness: a replica outage fails the reports routed to it, not
+// the whole app.
+type Readiness struct {
+	Ready    bool            `json:"ready"`
+	Database string          `json:"database"`           // "ok" or why the ping failed
+	Replicas []string        `json:"replicas,omitempty"` // Likewise, per replica
+	Schema   SchemaReadiness `json:"schema"`
+}
+
+// SchemaReadiness compares the applied schema version with the one this build
+// expects. A newer applied version is fine, as during a rolling deploy.
+type SchemaReadiness struct {
+	Version  uint   `json:"version"`
+	Expected uint   `json:"expected"`
+	Dirty    bool   `json:"dirty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// PoolStats reports the state of the database connection pool.
+type PoolStats struct {
+	MaxOpenConnections int   `json:"max_open_connections"`
+	OpenConnections    int   `json:"open_connections"`
+	InUse              int   `json:"in_use"`
+	Idle               int   `json:"idle"`
+	WaitCount          int64 `json:"wait_count"`           // Connections waited for
+	WaitDurationMs     int64 `json:"wait_duration_ms"`     // Total time spent waiting
+	MaxIdleClosed      int64 `json:"max_idle_closed"`      // Closed because the pool had enough idle connections
+	MaxIdleTimeClosed  int64 `json:"max_idle_time_closed"` // Closed after idling too long
+	MaxLifetimeClosed  int64 `json:"max_lifetime_closed"`  // Closed after reaching their lifetime
+}
+
+// DatabasePools holds the pool statistics of the primary and each replica.
+type DatabasePools struct {
+	Primary  PoolStats   `json:"primary"`
+	Replicas []PoolStats `json:"replicas,omitempty"`
+}
+
+type HealthService struct {
+	db       *gorm.DB
+	resolver *database.Resolver
+}
+
+// NewHealthService creates a new health service.
+func NewHealthService(resolver *database.Resolver) *HealthService {
+	return &HealthService{db: resolver.Primary(), resolver: resolver}
+}
+
+// Readiness pings the database and checks that every migration this build
+// knows of has been applied cleanly, then pings the replicas.
+func (s *HealthService) Readiness(ctx context.Context) Readiness {
+	report := Readiness{Database: "ok"}
+	report.Ready = s.checkPrimary(ctx, &report)
+
+	// Replicas get their own timeout so one that is down cannot fail the probe.
+	ctx, cancel := context.WithTimeout(ctx, readinessTimeout)
+	defer cancel()
+	for _, replica := range s.resolver.Replicas() {
+		status := "ok"
+		if err := ping(ctx, replica); err != nil {
+			status = err.Error()
+		}
+		report.Replicas = append(report.Replicas, status)
+	}
+	return report
+}
+
+// checkPrimary fills in the primary's part of a readiness report and reports
+// whether it is ready.
+func (s *HealthService) checkPrimary(ctx context.Context, report *Readiness) bool {
+	ctx, cancel := context.WithTimeout(ctx, readinessTimeout)
+	defer cancel()
+
+	if err := ping(ctx, s.db); err != nil {
+		report.Database = err.Error()
+		return false
+	}
+
+	expected, err := database.LatestMigration()
+	if err != nil {
+		report.Schema.Error = fmt.Sprintf("could not read migrations: %v", err)
+		return false
+	}
+	report.Schema.Expected = expected
+
+	version, dirty, err := database.SchemaVersion(ctx, s.db)
+	if err != nil {
+		report.Schema.Error = fmt.Sprintf("could not read schema version: %v", err)
+		return false
+	}
+	report.Schema.Version = version
+	report.Schema.Dirty = dirty
+
+	switch {
+	case dirty:
+		report.Schema.Error = "a migration failed halfway"
+		return false
+	case version < expected:
+		report.Schema.Error = "migrations are pending"
+		return false
+	}
+	return true
+}
+
+// ping checks that a database answers.
+func ping(ctx context.Context, db *gorm.DB) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
+}
+
+// PoolStats returns the current connection pool statistics of the primary and
+// each replica.
+func (s *HealthService) PoolStats() (*DatabasePools, error) {
+	primary, err := poolStats(s.db)
+	if err != nil {
+		return nil, err
+	}
+
+	pools := &DatabasePools{Primary: primary}
+	for _, replica := range s.resolver.Replicas() {
+		stats, err := poolStats(replica)
+		if err != nil {
+			return nil, err
+		}
+		pools.Replicas = append(pools.Replicas, stats)
+	}
+	return pools, nil
+}
+
+// poolStats reads the pool statistics of one connection.
+func poolStats(db *gorm.DB) (PoolStats, error) {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return PoolStats{}, err
+	}
+
+	stats := sqlDB.Stats()
+	return PoolStats{
+		MaxOpenConnections: stats.MaxOpenConnections,
+		OpenConnections:    stats.OpenConnections,
+		InUse:              stats.InUse,
+		Idle:               stats.Idle,
+		WaitCount:          stats.WaitCount,
+		WaitDurationMs:     stats.WaitDuration.Milliseconds(),
+		MaxIdleClosed:      stats.MaxIdleClosed,
+		MaxIdleTimeClosed:  stats.MaxIdleTimeClosed,
+		MaxLifetimeClosed:  stats.MaxLifetimeClosed,
+	}, nil
+}
@@ -0,0 +1,102 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"venturo-core/internal/adapter/storage"
+	"venturo-core/internal/model"
+	"venturo-core/pkg/imaging"
+	"venturo-core/pkg/jobs"
+	"venturo-core/pkg/uploader"
+)
+
+// imageSource is where an image job reads the uploaded file.
+type imageSource struct {
+	ObjectName string `json:"object_name,omitempty"` // File staged by a multipart upload
+	StorageKey string `json:"storage_key,omitempty"` // Object left in storage by a direct upload
+}
+
+// openImageSource opens the file an image job processes.
+func openImageSource(ctx context.Context, up *uploader.FileUploader, adapter storage.StorageAdapter, source imageSource) (io.ReadCloser, error) {
+	if source.StorageKey != "" {
+		object, _, err := adapter.Open(ctx, source.StorageKey)
+		return object, err
+	}
+	return up.OpenStaged(source.ObjectName)
+}
+
+// uploadImage renders an image source into variants stored under keyPrefix.
+func uploadImage(ctx context.Context, up *uploader.FileUploader, adapter storage.StorageAdapter, source imageSource, keyPrefix string) ([]uploader.StoredRendition, error) {
+	src, err := openImageSource(ctx, up, adapter, source)
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close()
+	return up.UploadImage(ctx, src, keyPrefix)
+}
+
+// removeImageSource deletes the uploaded file once its job is done with it.
+func removeImageSource(ctx context.Context, up *uploader.FileUploader, adapter storage.StorageAdapter, source imageSource) {
+	if source.StorageKey != "" {
+		deleteImageKeys(ctx, adapter, []string{source.StorageKey})
+		return
+	}
+	up.RemoveStaged(source.ObjectName)
+}
+
+// storedImage converts uploaded renditions into the model's variant map and
+// returns the URL of the largest one, which the product or user links to.
+func storedImage(stored []uploader.StoredRendition) (model.ImageVariants, string) {
+	variants := make(model.ImageVariants, len(stored))
+	var url string
+	for _, rendition := range stored {
+		variants[rendition.Variant] = model.ImageVariant{
+			Key:    rendition.Key,
+			URL:    rendition.URL,
+			Width:  rendition.Width,
+			Height: rendition.Height,
+		}
+		url = rendition.URL // Renditions come smallest first
+	}
+	return variants, url
+}
+
+// imageKeys lists the storage keys of an image. legacyKey names the single
+// object of an image stored before renditions existed, if any.
+func imageKeys(variants model.ImageVariants, legacyKey string) []string {
+	if len(variants) > 0 {
+		return variants.Keys()
+	}
+	if legacyKey != "" {
+		return []string{legacyKey}
+	}
+	return nil
+}
+
+// rejectedImage reports whether an image job failed because of the file
+// itself, which no retry can fix.
+func rejectedImage(err error) bool {
+	return errors.Is(err, imaging.ErrInvalidImage) || errors.Is(err, storage.ErrObjectNotFound) || os.IsNotExist(err)
+}
+
+// imageJobError returns the error for a failed image job, marking it
+// permanent when the file was rejected.
+func imageJobError(err error) error {
+	if rejectedImage(err) {
+		return jobs.Permanent(err)
+	}
+	return err
+}
+
+// deleteImageKeys removes stored objects, logging failures; a leftover object
+// is not worth failing a job over.
+func deleteImageKeys(ctx context.Context, adapter storage.StorageAdapter, keys []string, logArgs ...interface{}) {
+	for _, key := range keys {
+		if err := adapter.Delete(ctx, key); err != nil {
+			slog.Error("Failed to delete stored image", append(logArgs, "key", key, "error", err)...)
+		}
+	}
+}
@@ -0,0 +1,500 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"venturo-core/internal/model"
+	"venturo-core/pkg/jobs"
+	"venturo-core/pkg/validator"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Import limits keep a single job from holding too much in memory.
+const (
+	MaxImportFileSize = 4 << 20 // 4 MB, Fiber's default body limit
+	MaxImportRows     = 20000
+
+	// importProgressEvery is how many rows pass between progress updates.
+	importProgressEvery = 100
+)
+
+// JobImportProducts processes a product import job.
+const JobImportProducts = "imports.products"
+
+// ImportService runs bulk imports as background jobs.
+type ImportService struct {
+	db *gorm.DB
+}
+
+// NewImportService creates a new import service.
+func NewImportService(db *gorm.DB) *ImportService {
+	return &ImportService{db: db}
+}
+
+// RegisterJobs registers the service's background job handlers.
+func (s *ImportService) RegisterJobs(queue *jobs.Queue) {
+	queue.Register(JobImportProducts, s.runProductImport)
+}
+
+// productImport is the payload of a JobImportProducts job.
+type productImport struct {
+	ImportJobID uuid.UUID `json:"import_job_id"`
+}
+
+// StartProductImportInput holds an uploaded product CSV and how to import it.
+type StartProductImportInput struct {
+	UserID   uuid.UUID
+	OutletID *uuid.UUID // Receives the opening balances; required when any row has opening_stock
+	DryRun   bool
+	FileName string
+	Data     []byte
+}
+
+// ProductImportRow is one parsed line of a product CSV.
+type ProductImportRow struct {
+	Line         int      `json:"-"`
+	SKU          string   `validate:"required,max=64"`
+	Name         string   `validate:"required,max=255"`
+	Price        int32    `validate:"min=0"`
+	BaseUnit     string   `validate:"max=20"` // Defaults to "pcs" for new products
+	OpeningStock int      `validate:"min=0"`
+	UnitCost     *float64 `validate:"omitempty,min=0"` // Cost per base unit of the opening stock
+}
+
+// productImportColumns maps the accepted CSV headers to row fields.
+var productImportColumns = map[string]func(row *ProductImportRow, value string) error{
+	"sku":  func(row *ProductImportRow, value string) error { row.SKU = value; return nil },
+	"name": func(row *ProductImportRow, value string) error { row.Name = value; return nil },
+	"price": func(row *ProductImportRow, value string) error {
+		price, err := strconv.ParseInt(value, 10, 32)
+		row.Price = int32(price)
+		return err
+	},
+	"base_unit": func(row *ProductImportRow, value string) error { row.BaseUnit = value; return nil },
+	"opening_stock": func(row *ProductImportRow, value string) error {
+		if value == "" {
+			return nil
+		}
+		stock, err := strconv.Atoi(value)
+		row.OpeningStock = stock
+		return err
+	},
+	"unit_cost": func(row *ProductImportRow, value string) error {
+		if value == "" {
+			return nil
+		}
+		cost, err := strconv.ParseFloat(value, 64)
+		row.UnitCost = &cost
+		return err
+	},
+}
+
+// requiredImportColumns must appear in the header row.
+var requiredImportColumns = []string{"sku", "name", "price"}
+
+// StartProductImport records an import job and processes the file in the
+// background. In dry-run mode every row is validated and nothing is written;
+// in commit mode a file without errors upserts products by SKU and posts
+// their opening balances in one DB transaction.
+func (s *ImportService) StartProductImport(ctx context.Context, input StartProductImportInput) (*model.ImportJob, error) {
+	if len(input.Data) > MaxImportFileSize {
+		return nil, fmt.Errorf("import file exceeds %d bytes", MaxImportFileSize)
+	}
+	if input.OutletID != nil {
+		var outlet model.Outlet
+		if err := s.db.WithContext(ctx).First(&outlet, "id = ?", *input.OutletID).Error; err != nil {
+			return nil, errors.New("outlet not found")
+		}
+	}
+
+	job := model.ImportJob{
+		Type:      model.ImportTypeProducts,
+		Mode:      model.ImportModeCommit,
+		Status:    model.ImportPending,
+		OutletID:  input.OutletID,
+		UserID:    input.UserID,
+		FileName:  input.FileName,
+		FileData:  input.Data,
+		RowErrors: model.ImportRowErrors{},
+	}
+	if input.DryRun {
+		job.Mode = model.ImportModeDryRun
+	}
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&job).Error; err != nil {
+			return err
+		}
+		return jobs.Enqueue(tx, JobImportProducts, productImport{ImportJobID: job.ID})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &job, nil
+}
+
+// GetJob returns an import job for progress polling.
+func (s *ImportService) GetJob(ctx context.Context, id uuid.UUID) (*model.ImportJob, error) {
+	var job model.ImportJob
+	if err := s.db.WithContext(ctx).Omit("file_data").First(&job, "id = ?", id).Error; err != nil {
+		return nil, errors.New("import job not found")
+	}
+	return &job, nil
+}
+
+// runProductImport handles JobImportProducts, the background half of
+// StartProductImport. Problems with the file finish the import as failed;
+// only errors reading the import job are retried.
+func (s *ImportService) runProductImport(ctx context.Context, queued *jobs.Job) error {
+	var payload productImport
+	if err := queued.Decode(&payload); err != nil {
+		return jobs.Permanent(err)
+	}
+
+	var job model.ImportJob
+	err := s.db.WithContext(ctx).First(&job, "id = ?", payload.ImportJobID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return jobs.Permanent(err)
+	}
+	if err != nil {
+		return err
+	}
+	if job.FinishedAt != nil {
+		return nil
+	}
+
+	s.processProductImport(ctx, job)
+	return nil
+}
+
+// processProductImport parses, validates and, in commit mode, applies an
+// import. A retried job starts over, since its commit was rolled back.
+func (s *ImportService) processProductImport(ctx context.Context, job model.ImportJob) {
+	data := job.FileData
+	job.ProcessedRows, job.CreatedRows, job.UpdatedRows = 0, 0, 0
+
+	fail := func(err error) {
+		slog.Error("Product import failed", "job_id", job.ID, "error", err)
+		s.finishJob(ctx, &job, model.ImportFailed, err.Error())
+	}
+
+	rows, rowErrors, err := parseProductCSV(data)
+	if err != nil {
+		fail(err)
+		return
+	}
+
+	job.Status = model.ImportValidating
+	job.TotalRows = len(rows)
+	s.saveProgress(ctx, &job)
+
+	rowErrors = append(rowErrors, s.validateProductRows(ctx, &job, rows)...)
+	job.RowErrors = rowErrors
+	job.ProcessedRows = len(rows)
+
+	if job.Mode == model.ImportModeDryRun {
+		s.finishJob(ctx, &job, model.ImportSucceeded, "")
+		return
+	}
+	if len(rowErrors) > 0 {
+		s.finishJob(ctx, &job, model.ImportFailed, "the file has errors; nothing was imported")
+		return
+	}
+
+	job.Status = model.ImportImporting
+	job.ProcessedRows = 0
+	s.saveProgress(ctx, &job)
+
+	if err := s.commitProductRows(ctx, &job, rows); err != nil {
+		job.CreatedRows, job.UpdatedRows = 0, 0
+		fail(err)
+		return
+	}
+	s.finishJob(ctx, &job, model.ImportSucceeded, "")
+}
+
+// parseProductCSV reads the header and every data row. Values that cannot be
+// parsed are reported as row errors; a malformed file is an error.
+func parseProductCSV(data []byte) ([]ProductImportRow, model.ImportRowErrors, error) {
+	reader := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, []byte("\ufeff"))))
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot read the header row: %w", err)
+	}
+
+	columns := make([]string, len(header))
+	seen := make(map[string]bool, len(header))
+	for i, name := range header {
+		columns[i] = strings.ToLower(strings.TrimSpace(name))
+		seen[columns[i]] = true
+	}
+	for _, name := range requiredImportColumns {
+		if !seen[name] {
+			return nil, nil, fmt.Errorf("missing required column %q", name)
+		}
+	}
+
+	rows := []ProductImportRow{}
+	rowErrors := model.ImportRowErrors{}
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(rows) == MaxImportRows {
+			return nil, nil, fmt.Errorf("import file has more than %d rows", MaxImportRows)
+		}
+
+		line, _ := reader.FieldPos(0)
+		row := ProductImportRow{Line: line}
+		for i, value := range record {
+			if i >= len(columns) {
+				break
+			}
+			set, ok := productImportColumns[columns[i]]
+			if !ok {
+				continue // Unknown columns are ignored
+			}
+			if err := set(&row, strings.TrimSpace(value)); err != nil {
+				rowErrors = append(rowErrors, model.ImportRowError{Line: line, Field: columns[i], Message: "invalid number " + strconv.Quote(value)})
+			}
+		}
+		rows = append(rows, row)
+	}
+
+	return rows, rowErrors, nil
+}
+
+// validateProductRows checks every row against the validator rules and the
+// current catalog, reporting all problems rather than stopping at the first.
+func (s *ImportService) validateProductRows(ctx context.Context, job *model.ImportJob, rows []ProductImportRow) model.ImportRowErrors {
+	rowErrors := model.ImportRowErrors{}
+	addError := func(line int, field, message string) {
+		rowErrors = append(rowErrors, model.ImportRowError{Line: line, Field: field, Message: message})
+	}
+
+	db := s.db.WithContext(ctx)
+	units := make(map[string]bool)
+	var catalog []model.Unit
+	if err := db.Find(&catalog).Error; err == nil {
+		for _, unit := range catalog {
+			units[unit.Code] = true
+		}
+	}
+
+	existing, err := findProductsBySKU(db, rows)
+	if err != nil {
+		addError(0, "", "could not look up existing products: "+err.Error())
+		return rowErrors
+	}
+
+	stocked := make(map[uuid.UUID]bool)
+	if job.OutletID != nil && len(existing) > 0 {
+		ids := make([]uuid.UUID, 0, len(existing))
+		for _, product := range existing {
+			ids = append(ids, product.ID)
+		}
+		var stockedIDs []uuid.UUID
+		err := db.Model(&model.InventoryLedger{}).
+			Where("outlet_id = ? AND item_id IN ?", *job.OutletID, ids).
+			Distinct().
+			Pluck("item_id", &stockedIDs).Error
+		if err != nil {
+			addError(0, "", "could not look up existing stock: "+err.Error())
+			return rowErrors
+		}
+		for _, id := range stockedIDs {
+			stocked[id] = true
+		}
+	}
+
+	firstLine := make(map[string]int, len(rows))
+	for i, row := range rows {
+		fieldErrors := validator.ValidateStruct(row)
+		for _, field := range sortedKeys(fieldErrors) {
+			addError(row.Line, snakeCase(field), fieldErrors[field])
+		}
+
+		if line, ok := firstLine[row.SKU]; ok && row.SKU != "" {
+			addError(row.Line, "sku", fmt.Sprintf("duplicate sku, first used on line %d", line))
+		} else {
+			firstLine[row.SKU] = row.Line
+		}
+
+		if row.BaseUnit != "" && !units[row.BaseUnit] {
+			addError(row.Line, "base_unit", "unknown unit "+strconv.Quote(row.BaseUnit))
+		}
+
+		if product, ok := existing[row.SKU]; ok {
+			if row.BaseUnit != "" && row.BaseUnit != product.BaseUnit {
+				addError(row.Line, "base_unit", "cannot change the base unit of existing product from "+product.BaseUnit)
+			}
+			if row.OpeningStock > 0 && stocked[product.ID] {
+				addError(row.Line, "opening_stock", "product already has stock at this outlet; use stock-in instead")
+			}
+		}
+
+		if row.OpeningStock > 0 && job.OutletID == nil {
+			addError(row.Line, "opening_stock", "outlet_id is required to import opening stock")
+		}
+
+		if (i+1)%importProgressEvery == 0 {
+			job.ProcessedRows = i + 1
+			s.saveProgress(ctx, job)
+		}
+	}
+
+	return rowErrors
+}
+
+// commitProductRows upserts every row and posts opening balances in one DB transaction.
+func (s *ImportService) commitProductRows(ctx context.Context, job *model.ImportJob, rows []ProductImportRow) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		existing, err := findProductsBySKU(tx.Clauses(clause.Locking{Strength: "UPDATE"}), rows)
+		if err != nil {
+			return err
+		}
+
+		changes := []StockChange{}
+		for i, row := range rows {
+			product, ok := existing[row.SKU]
+			if ok {
+				err = tx.Model(product).Updates(map[string]interface{}{"name": row.Name, "price": row.Price}).Error
+				if err == nil {
+					err = publishProductUpdated(tx, product.ID)
+				}
+				job.UpdatedRows++
+			} else {
+				sku := row.SKU
+				product = &model.Product{SKU: &sku, Name: row.Name, Price: row.Price, BaseUnit: row.BaseUnit}
+				if product.BaseUnit == "" {
+					product.BaseUnit = "pcs"
+				}
+				err = tx.Create(product).Error
+				if err == nil {
+					err = publishProductEvent(tx, EventProductCreated, product)
+				}
+				job.CreatedRows++
+			}
+			if err != nil {
+				return fmt.Errorf("line %d: %w", row.Line, err)
+			}
+
+			if row.OpeningStock > 0 {
+				opening := model.InventoryLedger{
+					ItemId:          product.ID,
+					OutletId:        *job.OutletID,
+					EntryType:       model.LedgerEntryOpening,
+					Unit:            product.BaseUnit,
+					EnteredQuantity: float64(row.OpeningStock),
+					QuantityChange:  row.OpeningStock,
+					UnitCost:        row.UnitCost,
+				}
+				if err := tx.Create(&opening).Error; err != nil {
+					return fmt.Errorf("line %d: %w", row.Line, err)
+				}
+				changes = append(changes, StockChange{ProductID: product.ID, OutletID: opening.OutletId, QuantityChange: opening.QuantityChange})
+			}
+
+			// Progress is written outside the DB transaction so pollers see it.
+			if (i+1)%importProgressEvery == 0 {
+				job.ProcessedRows = i + 1
+				s.saveProgress(ctx, job)
+			}
+		}
+		job.ProcessedRows = len(rows)
+		return publishStockChanged(tx, model.LedgerEntryOpening, nil, changes)
+	})
+}
+
+// findProductsBySKU returns the existing products matching the rows' SKUs.
+func findProductsBySKU(db *gorm.DB, rows []ProductImportRow) (map[string]*model.Product, error) {
+	skus := make([]string, 0, len(rows))
+	for _, row := range rows {
+		if row.SKU != "" {
+			skus = append(skus, row.SKU)
+		}
+	}
+
+	found := make(map[string]*model.Product, len(skus))
+	if len(skus) == 0 {
+		return found, nil
+	}
+
+	var products []model.Product
+	if err := db.Where("sku IN ?", skus).Find(&products).Error; err != nil {
+		return nil, err
+	}
+	for i := range products {
+		found[*products[i].SKU] = &products[i]
+	}
+	return found, nil
+}
+
+// saveProgress stores the job's status and counters. A failure is only logged:
+// progress is informational and must not abort the import.
+func (s *ImportService) saveProgress(ctx context.Context, job *model.ImportJob) {
+	err := s.db.WithContext(ctx).Model(job).
+		Select("status", "total_rows", "processed_rows", "created_rows", "updated_rows").
+		Updates(job).Error
+	if err != nil {
+		slog.Error("Failed to save import progress", "job_id", job.ID, "error", err)
+	}
+}
+
+// finishJob stores the final state of a job.
+func (s *ImportService) finishJob(ctx context.Context, job *model.ImportJob, status, failure string) {
+	finishedAt := time.Now()
+	job.Status = status
+	job.Failure = failure
+	job.FinishedAt = &finishedAt
+	job.FileData = nil // Not needed once the import has finished
+
+	err := s.db.WithContext(ctx).Model(job).
+		Select("status", "total_rows", "processed_rows", "created_rows", "updated_rows", "row_errors", "failure", "file_data", "finished_at").
+		Updates(job).Error
+	if err != nil {
+		slog.Error("Failed to finish import job", "job_id", job.ID, "error", err)
+	}
+}
+
+// snakeCase maps a lower-cased validator field name back to its CSV column.
+func snakeCase(field string) string {
+	switch field {
+	case "baseunit":
+		return "base_unit"
+	case "openingstock":
+		return "opening_stock"
+	case "unitcost":
+		return "unit_cost"
+	}
+	return field
+}
+
+// sortedKeys returns the keys of a validator error map in a stable order.
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
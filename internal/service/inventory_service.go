@@ -2,7 +2,11 @@ package service
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"time"
 	"venturo-core/internal/model"
+	"venturo-core/pkg/events"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
@@ -18,27 +22,241 @@ func NewInventoryService(db *gorm.DB) *InventoryService {
 	return &InventoryService{db: db}
 }
 
+// RegisterSubscribers subscribes the service to domain events.
+func (s *InventoryService) RegisterSubscribers(dispatcher *events.Dispatcher) {
+	dispatcher.Subscribe(EventStockChanged, "inventory.low_stock", s.checkLowStock)
+}
+
+// checkLowStock handles EventStockChanged and publishes EventLowStock for every
+// product whose on-hand quantity at an outlet fell from above its threshold to
+// at or below it.
+func (s *InventoryService) checkLowStock(ctx context.Context, event *events.Event) error {
+	var payload StockChangedEvent
+	if err := event.Decode(&payload); err != nil {
+		return err
+	}
+
+	for _, change := range payload.Changes {
+		if change.QuantityChange >= 0 {
+			continue
+		}
+
+		var product model.Product
+		err := s.db.WithContext(ctx).Select("id", "name", "low_stock_threshold").First(&product, "id = ?", change.ProductID).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if product.LowStockThreshold == nil {
+			continue
+		}
+
+		threshold := int64(*product.LowStockThreshold)
+		before := change.OnHandQty - int64(change.QuantityChange)
+		if change.OnHandQty > threshold || before <= threshold {
+			continue
+		}
+
+		err = events.Publish(s.db.WithContext(ctx), EventLowStock, LowStockEvent{
+			ProductID:   product.ID,
+			ProductName: product.Name,
+			OutletID:    change.OutletID,
+			OnHandQty:   change.OnHandQty,
+			Threshold:   *product.LowStockThreshold,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // StockInInput represents the data needed for stock-in operation.
 type StockInInput struct {
-	ItemID   uuid.UUID `json:"item_id" validate:"required"`
-	OutletID uuid.UUID `json:"outlet_id" validate:"required"`
-	Quantity int       `json:"quantity" validate:"required,min=1"`
+	ItemID     uuid.UUID  `json:"item_id" validate:"required"`
+	OutletID   uuid.UUID  `json:"outlet_id" validate:"required"`
+	Quantity   int        `json:"quantity" validate:"required,min=1"`
+	Unit       string     `json:"unit"`      // Defaults to the product's purchase unit
+	UnitCost   *float64   `json:"unit_cost"` // Cost of one Unit, used for valuation
+	LotNumber  string     `json:"lot_number"`
+	ExpiryDate *time.Time `json:"expiry_date"`
 }
 
 // StockIn creates a new record in inventory_ledgers with positive quantity_change.
 func (s *InventoryService) StockIn(ctx context.Context, input StockInInput) (*model.InventoryLedger, error) {
+	var product model.Product
+	if err := s.db.WithContext(ctx).Preload("Units").First(&product, "id = ?", input.ItemID).Error; err != nil {
+		return nil, errors.New("product not found")
+	}
+
+	unit := input.Unit
+	if unit == "" {
+		unit = product.PurchaseUnitOrBase()
+	}
+	baseQty, err := product.ToBase(unit, int64(input.Quantity))
+	if err != nil {
+		return nil, err
+	}
+
+	// The ledger keeps cost per base unit so movements in any unit can be valued.
+	var baseUnitCost *float64
+	if input.UnitCost != nil {
+		cost := *input.UnitCost * float64(input.Quantity) / float64(baseQty)
+		baseUnitCost = &cost
+	}
+
 	// Create inventory ledger entry
 	ledger := model.InventoryLedger{
-		ItemId:         input.ItemID,
-		OutletId:       input.OutletID,
-		TransactionId:  nil,            // No transaction for stock-in operations
-		QuantityChange: input.Quantity, // Positive for stock-in
+		ItemId:          input.ItemID,
+		OutletId:        input.OutletID,
+		TransactionId:   nil, // No transaction for stock-in operations
+		EntryType:       model.LedgerEntryStockIn,
+		LotNumber:       input.LotNumber,
+		ExpiryDate:      input.ExpiryDate,
+		Unit:            unit,
+		EnteredQuantity: float64(input.Quantity),
+		QuantityChange:  int(baseQty), // Positive for stock-in
+		UnitCost:        baseUnitCost,
 	}
 
 	// Save to database
-	if err := s.db.WithContext(ctx).Create(&ledger).Error; err != nil {
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&ledger).Error; err != nil {
+			return err
+		}
+		return publishStockChanged(tx, model.LedgerEntryStockIn, nil, []StockChange{
+			{ProductID: ledger.ItemId, OutletID: ledger.OutletId, QuantityChange: ledger.QuantityChange},
+		})
+	})
+	if err != nil {
 		return nil, err
 	}
 
 	return &ledger, nil
 }
+
+// WriteOffExpiredInput selects which expired lots to write off.
+type WriteOffExpiredInput struct {
+	OutletID *uuid.UUID
+}
+
+// WriteOffExpired posts an adjustment that zeroes every lot whose expiry date has passed.
+func (s *InventoryService) WriteOffExpired(ctx context.Context, input WriteOffExpiredInput) ([]model.InventoryLedger, error) {
+	type expiredLot struct {
+		ItemID     uuid.UUID
+		OutletID   uuid.UUID
+		BaseUnit   string
+		LotNumber  string
+		ExpiryDate *time.Time
+		OnHandQty  int
+	}
+
+	adjustments := []model.InventoryLedger{}
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		query := tx.Table("inventory_ledgers").
+			Select("inventory_ledgers.item_id, inventory_ledgers.outlet_id, products.base_unit, inventory_ledgers.lot_number, inventory_ledgers.expiry_date, SUM(inventory_ledgers.quantity_change) AS on_hand_qty").
+			Joins("JOIN products ON products.id = inventory_ledgers.item_id").
+			Where("inventory_ledgers.expiry_date < CURDATE()").
+			Group("inventory_ledgers.item_id, inventory_ledgers.outlet_id, products.base_unit, inventory_ledgers.lot_number, inventory_ledgers.expiry_date").
+			Having("SUM(inventory_ledgers.quantity_change) > 0")
+		if input.OutletID != nil {
+			query = query.Where("inventory_ledgers.outlet_id = ?", *input.OutletID)
+		}
+
+		var lots []expiredLot
+		if err := query.Scan(&lots).Error; err != nil {
+			return err
+		}
+
+		for _, lot := range lots {
+			adjustment := model.InventoryLedger{
+				ItemId:          lot.ItemID,
+				OutletId:        lot.OutletID,
+				EntryType:       model.LedgerEntryAdjustment,
+				LotNumber:       lot.LotNumber,
+				ExpiryDate:      lot.ExpiryDate,
+				Unit:            lot.BaseUnit,
+				EnteredQuantity: float64(-lot.OnHandQty),
+				QuantityChange:  -lot.OnHandQty,
+			}
+			if err := tx.Create(&adjustment).Error; err != nil {
+				return err
+			}
+			adjustments = append(adjustments, adjustment)
+		}
+
+		changes := make([]StockChange, 0, len(adjustments))
+		for _, adjustment := range adjustments {
+			changes = append(changes, StockChange{ProductID: adjustment.ItemId, OutletID: adjustment.OutletId, QuantityChange: adjustment.QuantityChange})
+		}
+		return publishStockChanged(tx, model.LedgerEntryAdjustment, nil, changes)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return adjustments, nil
+}
+
+// stockDeduction is one inventory movement caused by a sale. Qty is in base
+// units; Unit and Factor record how the quantity was entered.
+type stockDeduction struct {
+	ItemID    uuid.UUID
+	ItemName  string
+	Qty       int
+	Unit      string
+	Factor    int64
+	LotNumber string
+}
+
+// consumeStock posts the negative ledger entries for a sale deduction.
+// Stock is drawn first-expiry-first-out across unexpired lots unless the
+// deduction names a lot, which may be expired; whatever the lots cannot cover
+// is taken from untracked stock.
+func consumeStock(tx *gorm.DB, outletID uuid.UUID, transactionID *uuid.UUID, d stockDeduction) error {
+	lots, err := model.FindLotBalances(tx, d.ItemID, outletID, d.LotNumber != "")
+	if err != nil {
+		return err
+	}
+
+	entry := func(lotNumber string, expiryDate *time.Time, qty int) error {
+		ledger := model.InventoryLedger{
+			ItemId:          d.ItemID,
+			OutletId:        outletID,
+			TransactionId:   transactionID,
+			EntryType:       model.LedgerEntrySale,
+			LotNumber:       lotNumber,
+			ExpiryDate:      expiryDate,
+			Unit:            d.Unit,
+			EnteredQuantity: -float64(qty) / float64(d.Factor),
+			QuantityChange:  -qty,
+		}
+		return tx.Create(&ledger).Error
+	}
+
+	remaining := d.Qty
+	for _, lot := range lots {
+		if remaining == 0 {
+			break
+		}
+		if d.LotNumber != "" && lot.LotNumber != d.LotNumber {
+			continue
+		}
+
+		take := min(lot.OnHandQty, remaining)
+		if err := entry(lot.LotNumber, lot.ExpiryDate, take); err != nil {
+			return err
+		}
+		remaining -= take
+	}
+
+	if remaining == 0 {
+		return nil
+	}
+	if d.LotNumber != "" {
+		return fmt.Errorf("lot %s does not hold enough stock", d.LotNumber)
+	}
+	return entry("", nil, remaining)
+}
@@ -0,0 +1,375 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+	"venturo-core/internal/model"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrLoyaltyDisabled is returned when redeeming points while the loyalty program is off.
+	ErrLoyaltyDisabled = errors.New("loyalty points cannot be redeemed: the program is disabled or points have no value")
+	// ErrInsufficientPoints is returned when redeeming more points than the customer holds.
+	ErrInsufficientPoints = errors.New("customer does not have enough points")
+)
+
+// LoyaltyService manages the loyalty rules and customers' points.
+type LoyaltyService struct {
+	db *gorm.DB
+}
+
+// NewLoyaltyService creates a new loyalty service.
+func NewLoyaltyService(db *gorm.DB) *LoyaltyService {
+	return &LoyaltyService{db: db}
+}
+
+// GetRules returns the current earn and redemption rules.
+func (s *LoyaltyService) GetRules(ctx context.Context) (*model.LoyaltyRules, error) {
+	return loadLoyaltyRules(s.db.WithContext(ctx))
+}
+
+// UpdateLoyaltyRulesInput holds the rules to change; nil fields are left as they are.
+type UpdateLoyaltyRulesInput struct {
+	Enabled             *bool
+	PointsPerUnit       *float64
+	CategoryMultipliers model.CategoryMultipliers // Replaces all multipliers when not nil
+	PointValue          *int64
+	ExpiryDays          *int
+}
+
+// UpdateRules changes the loyalty rules. New rules apply to sales paid from
+// now on; points already earned keep their expiry.
+func (s *LoyaltyService) UpdateRules(ctx context.Context, input UpdateLoyaltyRulesInput) (*model.LoyaltyRules, error) {
+	for name, multiplier := range input.CategoryMultipliers {
+		if !isCategoryName(name) {
+			return nil, fmt.Errorf("unknown category %q", name)
+		}
+		if multiplier < 0 {
+			return nil, fmt.Errorf("multiplier of %s must not be negative", name)
+		}
+	}
+
+	var rules *model.LoyaltyRules
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		rules, err = loadLoyaltyRules(tx.Clauses(clause.Locking{Strength: "UPDATE"}))
+		if err != nil {
+			return err
+		}
+
+		if input.Enabled != nil {
+			rules.Enabled = *input.Enabled
+		}
+		if input.PointsPerUnit != nil {
+			rules.PointsPerUnit = *input.PointsPerUnit
+		}
+		if input.CategoryMultipliers != nil {
+			rules.CategoryMultipliers = input.CategoryMultipliers
+		}
+		if input.PointValue != nil {
+			rules.PointValue = *input.PointValue
+		}
+		if input.ExpiryDays != nil {
+			rules.ExpiryDays = *input.ExpiryDays
+		}
+		return tx.Save(rules).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return rules, nil
+}
+
+// PointsBalance is a customer's points and the next batch about to expire.
+type PointsBalance struct {
+	CustomerID     uuid.UUID  `json:"customer_id"`
+	Balance        int64      `json:"balance"`
+	Value          int64      `json:"value"` // Balance at the current point value
+	ExpiringPoints int64      `json:"expiring_points"`
+	NextExpiryAt   *time.Time `json:"next_expiry_at"`
+}
+
+// GetBalance returns a customer's points balance after expiring any points past their date.
+func (s *LoyaltyService) GetBalance(ctx context.Context, customerID uuid.UUID) (*PointsBalance, error) {
+	result := PointsBalance{CustomerID: customerID}
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := lockCustomer(tx, customerID); err != nil {
+			return err
+		}
+
+		lots, err := expirePoints(tx, customerID, time.Now())
+		if err != nil {
+			return err
+		}
+		for _, lot := range lots {
+			if lot.remaining > 0 && lot.entry.ExpiresAt != nil {
+				result.ExpiringPoints = lot.remaining
+				result.NextExpiryAt = lot.entry.ExpiresAt
+				break
+			}
+		}
+
+		rules, err := loadLoyaltyRules(tx)
+		if err != nil {
+			return err
+		}
+		result.Balance, err = model.PointsBalance(tx, customerID)
+		result.Value = result.Balance * rules.PointValue
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// GetHistory returns one page of a customer's points ledger, newest first.
+func (s *LoyaltyService) GetHistory(ctx context.Context, customerID uuid.UUID, page, limit int) ([]model.LoyaltyLedger, int64, error) {
+	var customer model.Customer
+	if err := s.db.WithContext(ctx).Select("id").First(&customer, "id = ?", customerID).Error; err != nil {
+		return nil, 0, errors.New("customer not found")
+	}
+
+	query := s.db.WithContext(ctx).Model(&model.LoyaltyLedger{}).Where("customer_id = ?", customerID)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	entries := []model.LoyaltyLedger{}
+	err := query.Order("created_at DESC, id DESC").Offset((page - 1) * limit).Limit(limit).Find(&entries).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return entries, total, nil
+}
+
+// loadLoyaltyRules returns the rules row, creating it disabled when missing.
+func loadLoyaltyRules(db *gorm.DB) (*model.LoyaltyRules, error) {
+	rules := model.LoyaltyRules{ID: 1}
+	if err := db.FirstOrCreate(&rules, model.LoyaltyRules{ID: 1}).Error; err != nil {
+		return nil, err
+	}
+	return &rules, nil
+}
+
+// redeemPoints spends the customer's points for a points tender of amount and
+// returns the points used. The customer must be locked by the caller.
+func redeemPoints(tx *gorm.DB, rules *model.LoyaltyRules, customerID, transactionID uuid.UUID, amount int64) (int64, error) {
+	if !rules.Enabled || rules.PointValue <= 0 {
+		return 0, ErrLoyaltyDisabled
+	}
+	if amount%rules.PointValue != 0 {
+		return 0, fmt.Errorf("points payment must be a multiple of the point value %d", rules.PointValue)
+	}
+	points := amount / rules.PointValue
+
+	if _, err := expirePoints(tx, customerID, time.Now()); err != nil {
+		return 0, err
+	}
+	balance, err := model.PointsBalance(tx, customerID)
+	if err != nil {
+		return 0, err
+	}
+	if balance < points {
+		return 0, ErrInsufficientPoints
+	}
+
+	redemption := model.LoyaltyLedger{
+		CustomerID:    customerID,
+		TransactionID: &transactionID,
+		EntryType:     model.LoyaltyEntryRedeem,
+		Points:        -points,
+	}
+	return points, tx.Create(&redemption).Error
+}
+
+// earnPoints credits the customer of a newly paid transaction. The part of the
+// total paid with points earns nothing.
+func earnPoints(tx *gorm.DB, rules *model.LoyaltyRules, transaction *model.Transaction, redeemedAmount int64) error {
+	if transaction.CustomerID == nil || !rules.Enabled || rules.PointsPerUnit <= 0 || transaction.Total <= 0 {
+		return nil
+	}
+
+	var categories []categorySale
+	err := tx.Model(&model.TransactionDetail{}).
+		Select("category, SUM(qty * price) AS revenue").
+		Where("transaction_id = ?", transaction.ID).
+		Group("category").
+		Scan(&categories).Error
+	if err != nil {
+		return err
+	}
+
+	var weighted float64
+	for _, c := range categories {
+		weighted += float64(c.Revenue) * rules.Multiplier(c.Category)
+	}
+	eligible := float64(transaction.Total-redeemedAmount) / float64(transaction.Total)
+	points := int64(math.Floor(weighted * eligible * rules.PointsPerUnit))
+	if points <= 0 {
+		return nil
+	}
+
+	earning := model.LoyaltyLedger{
+		CustomerID:    *transaction.CustomerID,
+		TransactionID: &transaction.ID,
+		EntryType:     model.LoyaltyEntryEarn,
+		Points:        points,
+	}
+	if rules.ExpiryDays > 0 {
+		expiresAt := transaction.PaidAt.AddDate(0, 0, rules.ExpiryDays)
+		earning.ExpiresAt = &expiresAt
+	}
+	return tx.Create(&earning).Error
+}
+
+// reversePoints undoes a refunded transaction's points: earned points that
+// have not expired are taken back and redeemed points are returned. The
+// balance may go negative when the earned points were already spent.
+func reversePoints(tx *gorm.DB, transaction *model.Transaction) error {
+	if transaction.CustomerID == nil {
+		return nil
+	}
+	if err := lockCustomer(tx, *transaction.CustomerID); err != nil {
+		return err
+	}
+
+	var entries []model.LoyaltyLedger
+	err := tx.Where("transaction_id = ? AND entry_type IN ?", transaction.ID, []string{model.LoyaltyEntryEarn, model.LoyaltyEntryRedeem}).
+		Find(&entries).Error
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		reversal := model.LoyaltyLedger{
+			CustomerID:    entry.CustomerID,
+			TransactionID: entry.TransactionID,
+			SourceEntryID: &entry.ID,
+			Points:        -entry.Points,
+		}
+
+		switch entry.EntryType {
+		case model.LoyaltyEntryEarn:
+			reversal.EntryType = model.LoyaltyEntryEarnReversal
+			// Points of this earning that already expired are gone and cannot be taken back twice.
+			var expired int64
+			err := tx.Model(&model.LoyaltyLedger{}).
+				Where("source_entry_id = ? AND entry_type = ?", entry.ID, model.LoyaltyEntryExpire).
+				Select("COALESCE(SUM(points), 0)").
+				Row().
+				Scan(&expired)
+			if err != nil {
+				return err
+			}
+			reversal.Points = -(entry.Points + expired)
+		case model.LoyaltyEntryRedeem:
+			reversal.EntryType = model.LoyaltyEntryRedeemReversal
+		}
+
+		if reversal.Points == 0 {
+			continue
+		}
+		if err := tx.Create(&reversal).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// pointLot is an earning and the part of it not yet spent, reversed or expired.
+type pointLot struct {
+	entry     model.LoyaltyLedger
+	remaining int64
+}
+
+// expirePoints records the expiry of every earning past its date and returns
+// the customer's lots, soonest to expire first. Redemptions use up the lots in
+// that order; reversals and expiries apply to their own earning. The customer
+// must be locked by the caller.
+func expirePoints(tx *gorm.DB, customerID uuid.UUID, now time.Time) ([]*pointLot, error) {
+	var entries []model.LoyaltyLedger
+	if err := tx.Where("customer_id = ?", customerID).Order("created_at, id").Find(&entries).Error; err != nil {
+		return nil, err
+	}
+
+	lots := []*pointLot{}
+	byID := make(map[uuid.UUID]*pointLot)
+	var spent int64
+	for _, entry := range entries {
+		switch entry.EntryType {
+		case model.LoyaltyEntryEarn:
+			lot := &pointLot{entry: entry, remaining: entry.Points}
+			lots = append(lots, lot)
+			byID[entry.ID] = lot
+		case model.LoyaltyEntryEarnReversal, model.LoyaltyEntryExpire:
+			if entry.SourceEntryID != nil {
+				if lot, ok := byID[*entry.SourceEntryID]; ok {
+					lot.remaining += entry.Points
+				}
+			}
+		case model.LoyaltyEntryRedeem, model.LoyaltyEntryRedeemReversal:
+			spent -= entry.Points
+		}
+	}
+
+	// Points without an expiry are spent last.
+	sort.SliceStable(lots, func(i, j int) bool {
+		a, b := lots[i].entry.ExpiresAt, lots[j].entry.ExpiresAt
+		if a == nil || b == nil {
+			return a != nil && b == nil
+		}
+		return a.Before(*b)
+	})
+
+	for _, lot := range lots {
+		if spent <= 0 {
+			break
+		}
+		take := min(max(lot.remaining, 0), spent)
+		lot.remaining -= take
+		spent -= take
+	}
+
+	for _, lot := range lots {
+		if lot.remaining <= 0 || lot.entry.ExpiresAt == nil || lot.entry.ExpiresAt.After(now) {
+			continue
+		}
+		expiry := model.LoyaltyLedger{
+			CustomerID:    customerID,
+			SourceEntryID: &lot.entry.ID,
+			EntryType:     model.LoyaltyEntryExpire,
+			Points:        -lot.remaining,
+		}
+		if err := tx.Create(&expiry).Error; err != nil {
+			return nil, err
+		}
+		lot.remaining = 0
+	}
+
+	return lots, nil
+}
+
+// isCategoryName reports whether name is the display name of a product category.
+func isCategoryName(name string) bool {
+	for _, category := range []model.ProductCategory{model.Goods, model.Service, model.Subscription} {
+		if category.String() == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,77 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+	"venturo-core/internal/model"
+	"venturo-core/pkg/export"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// OutletService manages outlet settings.
+type OutletService struct {
+	db *gorm.DB
+}
+
+// NewOutletService creates a new outlet service.
+func NewOutletService(db *gorm.DB) *OutletService {
+	return &OutletService{db: db}
+}
+
+// UpdateOutletSettingsInput holds the settings to change; nil fields are left as they are.
+type UpdateOutletSettingsInput struct {
+	Timezone         *string
+	Locale           *string
+	RequireOpenShift *bool
+}
+
+// UpdateSettings changes an outlet's time zone, locale and shift policy.
+func (s *OutletService) UpdateSettings(ctx context.Context, id uuid.UUID, input UpdateOutletSettingsInput) (*model.Outlet, error) {
+	var outlet model.Outlet
+	if err := s.db.WithContext(ctx).First(&outlet, "id = ?", id).Error; err != nil {
+		return nil, errors.New("outlet not found")
+	}
+
+	updates := map[string]interface{}{}
+	if input.Timezone != nil {
+		if _, err := time.LoadLocation(*input.Timezone); err != nil {
+			return nil, errors.New("unknown timezone " + *input.Timezone)
+		}
+		updates["timezone"] = *input.Timezone
+	}
+	if input.Locale != nil {
+		locale, ok := export.LookupLocale(*input.Locale)
+		if !ok {
+			return nil, errors.New("unsupported locale " + *input.Locale)
+		}
+		updates["locale"] = locale.Tag
+	}
+	if input.RequireOpenShift != nil {
+		updates["require_open_shift"] = *input.RequireOpenShift
+	}
+	if len(updates) == 0 {
+		return &outlet, nil
+	}
+
+	if err := s.db.WithContext(ctx).Model(&outlet).Updates(updates).Error; err != nil {
+		return nil, err
+	}
+	return &outlet, nil
+}
+
+// Locales returns the locale tag of every outlet, for formatting exported rows.
+func (s *OutletService) Locales(ctx context.Context) (map[uuid.UUID]string, error) {
+	var outlets []model.Outlet
+	if err := s.db.WithContext(ctx).Select("id", "locale").Find(&outlets).Error; err != nil {
+		return nil, err
+	}
+
+	locales := make(map[uuid.UUID]string, len(outlets))
+	for _, outlet := range outlets {
+		locales[outlet.ID] = outlet.Locale
+	}
+	return locales, nil
+}
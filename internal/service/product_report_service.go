@@ -0,0 +1,236 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"time"
+	"venturo-core/internal/model"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Product report page sizes.
+const (
+	DefaultProductReportLimit = 10
+	MaxProductReportLimit     = 500
+)
+
+// ABC classes split revenue into the top 80%, the next 15% and the last 5%.
+const (
+	abcClassAThreshold = 0.80
+	abcClassBThreshold = 0.95
+)
+
+// ProductReportFilter holds the filters shared by the product reports.
+type ProductReportFilter struct {
+	OutletID *uuid.UUID
+	From     *time.Time // Inclusive
+	To       *time.Time // Exclusive
+	Limit    int
+}
+
+// scopeSales restricts a query joined with transactions to paid sales matching the filter.
+func (f ProductReportFilter) scopeSales(query *gorm.DB) *gorm.DB {
+	query = query.Where("transactions.is_paid = ?", true)
+	if f.OutletID != nil {
+		query = query.Where("transactions.outlet_id = ?", *f.OutletID)
+	}
+	if f.From != nil {
+		query = query.Where("COALESCE(transactions.paid_at, transactions.created_at) >= ?", *f.From)
+	}
+	if f.To != nil {
+		query = query.Where("COALESCE(transactions.paid_at, transactions.created_at) < ?", *f.To)
+	}
+	return query
+}
+
+// Top product rankings.
+const (
+	RankByQuantity = "quantity"
+	RankByRevenue  = "revenue"
+)
+
+// ProductSales is the sales of one product over the filtered period.
+type ProductSales struct {
+	ProductID    uuid.UUID `json:"product_id"`
+	ProductName  string    `json:"product_name"`
+	QuantitySold int64     `json:"quantity_sold"`
+	Revenue      int64     `json:"revenue"`
+	Transactions int64     `json:"transactions"`
+}
+
+// productSales returns the per-product sales matching the filter, best sellers first.
+func (s *ReportService) productSales(ctx context.Context, filter ProductReportFilter, rankBy string, limit int) ([]ProductSales, error) {
+	order := "revenue DESC, quantity_sold DESC"
+	if rankBy == RankByQuantity {
+		order = "quantity_sold DESC, revenue DESC"
+	}
+
+	query := s.resolver.Reader(ctx).
+		Table("transaction_details").
+		Select("transaction_details.product_id, COALESCE(products.name, MAX(transaction_details.product_name)) AS product_name, SUM(transaction_details.qty) AS quantity_sold, SUM(transaction_details.qty * transaction_details.price) AS revenue, COUNT(DISTINCT transaction_details.transaction_id) AS transactions").
+		Joins("JOIN transactions ON transactions.id = transaction_details.transaction_id").
+		Joins("LEFT JOIN products ON products.id = transaction_details.product_id").
+		Group("transaction_details.product_id, products.name").
+		Order(order + ", transaction_details.product_id")
+	query = filter.scopeSales(query)
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+
+	sales := []ProductSales{}
+	if err := query.Scan(&sales).Error; err != nil {
+		return nil, err
+	}
+	return sales, nil
+}
+
+// GenerateTopProducts ranks products by quantity sold or revenue.
+func (s *ReportService) GenerateTopProducts(ctx context.Context, filter ProductReportFilter, rankBy string) ([]ProductSales, error) {
+	return s.productSales(ctx, filter, rankBy, filter.Limit)
+}
+
+// SlowMover is a product that has not sold within the horizon.
+type SlowMover struct {
+	ProductID         uuid.UUID  `json:"product_id"`
+	ProductName       string     `json:"product_name"`
+	LastSoldAt        *time.Time `json:"last_sold_at"` // Nil when never sold
+	DaysSinceLastSale *int       `json:"days_since_last_sale"`
+	OnHandQty         int64      `json:"on_hand_quantity"` // In the product's base unit
+	BaseUnit          string     `json:"base_unit"`
+}
+
+// GenerateSlowMovers lists products with no paid sales in the last days days,
+// optionally at one outlet. Products never sold come first, then the longest idle.
+func (s *ReportService) GenerateSlowMovers(ctx context.Context, filter ProductReportFilter, days int) ([]SlowMover, error) {
+	now := time.Now()
+	cutoff := now.AddDate(0, 0, -days)
+
+	lastSales := s.resolver.Reader(ctx).
+		Table("transaction_details").
+		Select("transaction_details.product_id, MAX(COALESCE(transactions.paid_at, transactions.created_at)) AS last_sold_at").
+		Joins("JOIN transactions ON transactions.id = transaction_details.transaction_id").
+		Where("transactions.is_paid = ?", true).
+		Group("transaction_details.product_id")
+	onHand := s.resolver.Reader(ctx).
+		Table("inventory_ledgers").
+		Select("item_id, SUM(quantity_change) AS on_hand_qty").
+		Group("item_id")
+	if filter.OutletID != nil {
+		lastSales = lastSales.Where("transactions.outlet_id = ?", *filter.OutletID)
+		onHand = onHand.Where("outlet_id = ?", *filter.OutletID)
+	}
+
+	query := s.resolver.Reader(ctx).
+		Table("products").
+		Select("products.id AS product_id, products.name AS product_name, last_sales.last_sold_at, COALESCE(on_hand.on_hand_qty, 0) AS on_hand_qty, products.base_unit").
+		Joins("LEFT JOIN (?) AS last_sales ON last_sales.product_id = products.id", lastSales).
+		Joins("LEFT JOIN (?) AS on_hand ON on_hand.item_id = products.id", onHand).
+		Where("last_sales.last_sold_at IS NULL OR last_sales.last_sold_at < ?", cutoff).
+		Order("last_sales.last_sold_at IS NOT NULL, last_sales.last_sold_at, products.name")
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+
+	movers := []SlowMover{}
+	if err := query.Scan(&movers).Error; err != nil {
+		return nil, err
+	}
+
+	for i := range movers {
+		if movers[i].LastSoldAt != nil {
+			idle := int(now.Sub(*movers[i].LastSoldAt).Hours() / 24)
+			movers[i].DaysSinceLastSale = &idle
+		}
+	}
+
+	return movers, nil
+}
+
+// ABCItem is one product's place in an outlet's revenue distribution.
+type ABCItem struct {
+	ProductSales
+	RevenueShare    float64 `json:"revenue_share"`
+	CumulativeShare float64 `json:"cumulative_share"`
+	Class           string  `json:"class"` // "A", "B" or "C"
+}
+
+// ABCOutlet is the ABC classification of the products sold at one outlet.
+type ABCOutlet struct {
+	OutletID     uuid.UUID `json:"outlet_id"`
+	OutletName   string    `json:"outlet_name"`
+	TotalRevenue int64     `json:"total_revenue"`
+	Items        []ABCItem `json:"items"`
+}
+
+// GenerateABCAnalysis classifies products per outlet by their share of revenue:
+// class A covers the first 80%, B the next 15% and C the remaining 5%. A
+// product that crosses a threshold stays in the higher class. Limit, when set,
+// trims each outlet's list after classification.
+func (s *ReportService) GenerateABCAnalysis(ctx context.Context, filter ProductReportFilter) ([]ABCOutlet, error) {
+	var outlets []model.Outlet
+	outletQuery := s.resolver.Reader(ctx).Order("name")
+	if filter.OutletID != nil {
+		outletQuery = outletQuery.Where("id = ?", *filter.OutletID)
+	}
+	if err := outletQuery.Find(&outlets).Error; err != nil {
+		return nil, err
+	}
+
+	report := make([]ABCOutlet, 0, len(outlets))
+	for _, outlet := range outlets {
+		outletFilter := filter
+		outletFilter.OutletID = &outlet.ID
+
+		sales, err := s.productSales(ctx, outletFilter, RankByRevenue, 0)
+		if err != nil {
+			return nil, err
+		}
+		if len(sales) == 0 {
+			continue
+		}
+
+		entry := ABCOutlet{OutletID: outlet.ID, OutletName: outlet.Name, Items: classifyABC(sales)}
+		for _, sale := range sales {
+			entry.TotalRevenue += sale.Revenue
+		}
+		if filter.Limit > 0 && len(entry.Items) > filter.Limit {
+			entry.Items = entry.Items[:filter.Limit]
+		}
+		report = append(report, entry)
+	}
+
+	return report, nil
+}
+
+// classifyABC assigns classes to sales ordered by revenue, highest first.
+func classifyABC(sales []ProductSales) []ABCItem {
+	sort.SliceStable(sales, func(i, j int) bool { return sales[i].Revenue > sales[j].Revenue })
+
+	var total int64
+	for _, sale := range sales {
+		total += sale.Revenue
+	}
+
+	items := make([]ABCItem, 0, len(sales))
+	var cumulative float64
+	for _, sale := range sales {
+		item := ABCItem{ProductSales: sale, Class: "C"}
+		if total > 0 {
+			item.RevenueShare = float64(sale.Revenue) / float64(total)
+		}
+
+		switch {
+		case cumulative < abcClassAThreshold:
+			item.Class = "A"
+		case cumulative < abcClassBThreshold:
+			item.Class = "B"
+		}
+
+		cumulative += item.RevenueShare
+		item.CumulativeShare = cumulative
+		items = append(items, item)
+	}
+	return items
+}
@@ -2,96 +2,511 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"mime/multipart"
+	"path"
 	"path/filepath"
-	"sync"
 	"venturo-core/internal/adapter/storage"
 	"venturo-core/internal/model"
+	"venturo-core/pkg/jobs"
+	"venturo-core/pkg/uploader"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
+// productStagingDir is the staging subdirectory of product images awaiting
+// processing.
+const productStagingDir = "products"
+
+// productImagePrefix is the storage folder of product image renditions.
+const productImagePrefix = "products"
+
+// JobUploadProductImage renders a staged product image and stores its variants.
+const JobUploadProductImage = "products.upload_image"
+
 // ProductService handles the business logic for products.
 type ProductService struct {
 	db             *gorm.DB
-	wg             *sync.WaitGroup
+	uploader       *uploader.FileUploader
 	storageAdapter storage.StorageAdapter
 }
 
-// NewProductService creates a new product service.
-func NewProductService(db *gorm.DB, wg *sync.WaitGroup, storageAdapter storage.StorageAdapter) *ProductService {
-	return &ProductService{db: db, wg: wg, storageAdapter: storageAdapter}
+// NewProductService creates a new product service. Images are staged under
+// stagingPath until their job has run.
+func NewProductService(db *gorm.DB, storageAdapter storage.StorageAdapter, stagingPath string) *ProductService {
+	fileUploader := uploader.NewFileUploader(storageAdapter, filepath.Join(stagingPath, productStagingDir))
+	return &ProductService{db: db, uploader: fileUploader, storageAdapter: storageAdapter}
+}
+
+// RegisterJobs registers the service's background job handlers.
+func (s *ProductService) RegisterJobs(queue *jobs.Queue) {
+	queue.Register(JobUploadProductImage, s.uploadProductImage)
+}
+
+// productImageUpload is the payload of a JobUploadProductImage job.
+type productImageUpload struct {
+	ProductID uuid.UUID `json:"product_id"`
+	imageSource
+	ImageKey     string   `json:"image_key"`     // Storage prefix of the renditions
+	PreviousKeys []string `json:"previous_keys"` // The replaced image, deleted after a successful upload
 }
 
 // CreateProductInput is the data needed to create a new product.
+// InitialStock, when positive, is posted as an opening balance at OutletID.
 type CreateProductInput struct {
-	Name  string
-	Price int32
-	Stock int16
-	Image *multipart.FileHeader
+	Name         string
+	Price        int32
+	BaseUnit     string // Defaults to "pcs"; InitialStock is counted in it
+	InitialStock int
+	OutletID     *uuid.UUID
+	Image        *multipart.FileHeader
 }
 
-// CreateProduct creates a product and asynchronously uploads its image.
+// CreateProduct creates a product and asynchronously processes its image.
+// An image that is not an acceptable JPEG, PNG or WebP is rejected up front.
 func (s *ProductService) CreateProduct(ctx context.Context, input CreateProductInput) (*model.Product, error) {
+	if input.InitialStock < 0 {
+		return nil, errors.New("initial stock cannot be negative")
+	}
+	if input.InitialStock > 0 && input.OutletID == nil {
+		return nil, errors.New("outlet_id is required when initial stock is provided")
+	}
+
 	product := model.Product{
-		Name:  input.Name,
-		Price: input.Price,
-		Stock: input.Stock,
+		Name:     input.Name,
+		Price:    input.Price,
+		BaseUnit: input.BaseUnit,
+	}
+	if product.BaseUnit == "" {
+		product.BaseUnit = "pcs"
 	}
 
-	// If an image is provided, prepare for upload.
+	// If an image is provided, stage it for the processing job.
+	var stagedName string
 	if input.Image != nil {
-		imageName := fmt.Sprintf("%s%s", uuid.NewString(), filepath.Ext(input.Image.Filename))
-		product.ImageURL = imageName
-		product.ImageStatus = "uploading"
+		imageID := uuid.NewString()
+		stagedName = imageID + filepath.Ext(input.Image.Filename)
+		if err := s.uploader.StageImage(input.Image, stagedName); err != nil {
+			return nil, err
+		}
+		product.ImageKey = path.Join(productImagePrefix, imageID)
+		product.ImageStatus = model.ImageStatusProcessing
 	}
 
-	// Save the initial product record. This is fast and synchronous.
-	if err := product.Save(s.db); err != nil {
+	// Save the product, its opening balance and the image upload job together.
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := product.Save(tx); err != nil {
+			return err
+		}
+		if input.Image != nil {
+			err := jobs.Enqueue(tx, JobUploadProductImage, productImageUpload{
+				ProductID:   product.ID,
+				imageSource: imageSource{ObjectName: stagedName},
+				ImageKey:    product.ImageKey,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		if err := publishProductEvent(tx, EventProductCreated, &product); err != nil {
+			return err
+		}
+		if input.InitialStock == 0 {
+			return nil
+		}
+
+		opening := model.InventoryLedger{
+			ItemId:          product.ID,
+			OutletId:        *input.OutletID,
+			EntryType:       model.LedgerEntryOpening,
+			Unit:            product.BaseUnit,
+			EnteredQuantity: float64(input.InitialStock),
+			QuantityChange:  input.InitialStock,
+		}
+		if err := tx.Create(&opening).Error; err != nil {
+			return err
+		}
+		return publishStockChanged(tx, model.LedgerEntryOpening, nil, []StockChange{
+			{ProductID: product.ID, OutletID: opening.OutletId, QuantityChange: opening.QuantityChange},
+		})
+	})
+	if err != nil {
+		if input.Image != nil {
+			s.uploader.RemoveStaged(stagedName)
+		}
 		return nil, err
 	}
 
-	// If there's an image, start the background upload process.
-	if input.Image != nil {
-		s.wg.Add(1)
-		go s.uploadProductImage(product.ID, input.Image, product.ImageURL)
+	if err := product.LoadStocks(s.db.WithContext(ctx)); err != nil {
+		return nil, err
 	}
 
 	return &product, nil
 }
 
-// uploadProductImage is the background worker.
-func (s *ProductService) uploadProductImage(productID uuid.UUID, file *multipart.FileHeader, objectName string) {
-	defer s.wg.Done()
-	bgCtx := context.Background()
+// GetProduct retrieves a product together with its per-outlet stock.
+func (s *ProductService) GetProduct(ctx context.Context, id uuid.UUID) (*model.Product, error) {
+	var product model.Product
+	found, err := product.FindByID(s.db.WithContext(ctx), id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := found.LoadStocks(s.db.WithContext(ctx)); err != nil {
+		return nil, err
+	}
+	return found, nil
+}
+
+// UpdateProductInput changes a product's details; nil fields are left as they
+// are. A negative LowStockThreshold turns the low-stock alert off.
+type UpdateProductInput struct {
+	Name              *string
+	Price             *int32
+	LowStockThreshold *int
+}
+
+// UpdateProduct changes a product's name, price or low-stock threshold.
+func (s *ProductService) UpdateProduct(ctx context.Context, productID uuid.UUID, input UpdateProductInput) (*model.Product, error) {
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var product model.Product
+		if err := tx.First(&product, "id = ?", productID).Error; err != nil {
+			return errors.New("product not found")
+		}
+
+		updates := map[string]interface{}{}
+		if input.Name != nil {
+			updates["name"] = *input.Name
+		}
+		if input.Price != nil {
+			updates["price"] = *input.Price
+		}
+		if input.LowStockThreshold != nil {
+			if *input.LowStockThreshold < 0 {
+				updates["low_stock_threshold"] = nil
+			} else {
+				updates["low_stock_threshold"] = *input.LowStockThreshold
+			}
+		}
+		if len(updates) == 0 {
+			return nil
+		}
+
+		if err := tx.Model(&product).Updates(updates).Error; err != nil {
+			return err
+		}
+		return publishProductUpdated(tx, productID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetProduct(ctx, productID)
+}
+
+// ComponentInput is one bill-of-materials line for SetComponents.
+type ComponentInput struct {
+	ComponentID uuid.UUID
+	Quantity    int
+	Unit        string
+}
+
+// SetComponents replaces the bill of materials of a product. An empty list turns
+// the product back into a stocked item.
+func (s *ProductService) SetComponents(ctx context.Context, productID uuid.UUID, inputs []ComponentInput) (*model.Product, error) {
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var product model.Product
+		if err := tx.First(&product, "id = ?", productID).Error; err != nil {
+			return errors.New("product not found")
+		}
 
-	// 1. Upload the file.
-	publicURL, err := s.storageAdapter.Upload(bgCtx, file, objectName)
+		componentIDs := make([]uuid.UUID, 0, len(inputs))
+		for _, input := range inputs {
+			if input.ComponentID == productID {
+				return errors.New("a product cannot be a component of itself")
+			}
+			if input.Quantity < 1 {
+				return errors.New("component quantity must be at least 1")
+			}
+			componentIDs = append(componentIDs, input.ComponentID)
+		}
+
+		if len(componentIDs) > 0 {
+			var found int64
+			if err := tx.Model(&model.Product{}).Where("id IN ?", componentIDs).Count(&found).Error; err != nil {
+				return err
+			}
+			if int(found) != len(componentIDs) {
+				return errors.New("component not found or listed twice")
+			}
+
+			// Only one level of composition is supported.
+			nested, err := model.FindComponentsByProductIDs(tx, componentIDs)
+			if err != nil {
+				return err
+			}
+			if len(nested) > 0 {
+				return errors.New("a composite product cannot be used as a component")
+			}
+
+			var usedIn int64
+			if err := tx.Model(&model.ProductComponent{}).Where("component_id = ?", productID).Count(&usedIn).Error; err != nil {
+				return err
+			}
+			if usedIn > 0 {
+				return errors.New("a product used as a component cannot have components")
+			}
+		}
+
+		if err := tx.Where("product_id = ?", productID).Delete(&model.ProductComponent{}).Error; err != nil {
+			return err
+		}
+
+		for _, input := range inputs {
+			component := model.ProductComponent{
+				ProductID:   productID,
+				ComponentID: input.ComponentID,
+				Quantity:    input.Quantity,
+				Unit:        input.Unit,
+			}
+			if err := tx.Create(&component).Error; err != nil {
+				return err
+			}
+		}
+		return publishProductUpdated(tx, productID)
+	})
 	if err != nil {
-		slog.Error("Failed to upload product image", "productID", productID, "error", err)
-		// Update status to 'failed'
-		s.updateImageStatus(productID, "failed", "")
-		return
+		return nil, err
 	}
 
-	// 2. Update status to 'done' on success.
-	slog.Info("Successfully uploaded product image", "productID", productID, "url", publicURL)
-	s.updateImageStatus(productID, "done", publicURL)
+	return s.GetProduct(ctx, productID)
 }
 
-// updateImageStatus is a helper to update the product record.
-func (s *ProductService) updateImageStatus(productID uuid.UUID, status string, url string) {
+// SetUnitsInput defines the unit conversions of a product. Every conversion says
+// how many base units one of Unit is worth.
+type SetUnitsInput struct {
+	PurchaseUnit string
+	SalesUnit    string
+	Conversions  []UnitConversionInput
+}
+
+// UnitConversionInput is one unit conversion for SetUnits.
+type UnitConversionInput struct {
+	Unit   string
+	Factor int64
+}
+
+// SetUnits replaces the unit conversions and the purchase and sales units of a product.
+// The base unit is fixed at creation because the ledger is stored in it.
+func (s *ProductService) SetUnits(ctx context.Context, productID uuid.UUID, input SetUnitsInput) (*model.Product, error) {
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var product model.Product
+		if err := tx.First(&product, "id = ?", productID).Error; err != nil {
+			return errors.New("product not found")
+		}
+
+		defined := map[string]bool{product.BaseUnit: true}
+		for _, conversion := range input.Conversions {
+			if conversion.Unit == product.BaseUnit {
+				return fmt.Errorf("unit %s is already the base unit", conversion.Unit)
+			}
+			if defined[conversion.Unit] {
+				return fmt.Errorf("unit %s is listed twice", conversion.Unit)
+			}
+			if conversion.Factor < 1 {
+				return fmt.Errorf("factor for unit %s must be at least 1", conversion.Unit)
+			}
+			defined[conversion.Unit] = true
+		}
+
+		var catalog []model.Unit
+		if err := tx.Where("code IN ?", mapKeys(defined)).Find(&catalog).Error; err != nil {
+			return err
+		}
+		if len(catalog) != len(defined) {
+			return errors.New("unit not found in the units catalog")
+		}
+
+		for _, unit := range []string{input.PurchaseUnit, input.SalesUnit} {
+			if unit != "" && !defined[unit] {
+				return fmt.Errorf("unit %s has no conversion for this product", unit)
+			}
+		}
+
+		// Bundles and recipes may measure this product in a unit being removed.
+		var componentUnits []string
+		err := tx.Model(&model.ProductComponent{}).
+			Where("component_id = ? AND unit <> ''", productID).
+			Distinct().Pluck("unit", &componentUnits).Error
+		if err != nil {
+			return err
+		}
+		for _, unit := range componentUnits {
+			if !defined[unit] {
+				return fmt.Errorf("unit %s is still used by a product that has this product as a component", unit)
+			}
+		}
+
+		if err := tx.Where("product_id = ?", productID).Delete(&model.ProductUnit{}).Error; err != nil {
+			return err
+		}
+		for _, conversion := range input.Conversions {
+			productUnit := model.ProductUnit{
+				ProductID: productID,
+				UnitCode:  conversion.Unit,
+				Factor:    conversion.Factor,
+			}
+			if err := tx.Create(&productUnit).Error; err != nil {
+				return err
+			}
+		}
+
+		err = tx.Model(&product).Updates(map[string]interface{}{
+			"purchase_unit": nullableString(input.PurchaseUnit),
+			"sales_unit":    nullableString(input.SalesUnit),
+		}).Error
+		if err != nil {
+			return err
+		}
+		return publishProductUpdated(tx, productID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetProduct(ctx, productID)
+}
+
+// mapKeys returns the keys of a set.
+func mapKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// nullableString maps an empty string to NULL.
+func nullableString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+// ReplaceImage stages a new image for a product and queues its processing.
+// The previous image is deleted from storage once the new one is stored.
+func (s *ProductService) ReplaceImage(ctx context.Context, productID uuid.UUID, image *multipart.FileHeader) (*model.Product, error) {
 	var product model.Product
-	// Using a map to update specific fields.
-	updates := map[string]interface{}{"image_status": status}
-	if url != "" {
-		updates["image_url"] = url
+	if err := s.db.WithContext(ctx).First(&product, "id = ?", productID).Error; err != nil {
+		return nil, errors.New("product not found")
+	}
+
+	stagedName := uuid.NewString() + filepath.Ext(image.Filename)
+	if err := s.uploader.StageImage(image, stagedName); err != nil {
+		return nil, err
+	}
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return s.queueImage(tx, &product, imageSource{ObjectName: stagedName})
+	})
+	if err != nil {
+		s.uploader.RemoveStaged(stagedName)
+		return nil, err
 	}
 
-	if err := s.db.Model(&product).Where("id = ?", productID).Updates(updates).Error; err != nil {
-		slog.Error("Failed to update product image status", "productID", productID, "error", err)
+	return &product, nil
+}
+
+// AttachUploadedImage queues a directly uploaded object as a product's new
+// image. It runs in the caller's transaction; the object is deleted once the
+// job has processed it.
+func (s *ProductService) AttachUploadedImage(tx *gorm.DB, productID uuid.UUID, storageKey string) error {
+	var product model.Product
+	if err := tx.First(&product, "id = ?", productID).Error; err != nil {
+		return errors.New("product not found")
+	}
+	return s.queueImage(tx, &product, imageSource{StorageKey: storageKey})
+}
+
+// queueImage points a product at a new image and queues its processing.
+func (s *ProductService) queueImage(tx *gorm.DB, product *model.Product, source imageSource) error {
+	// A ready image without variants predates renditions and is a single object.
+	var legacyKey string
+	if product.ImageStatus == model.ImageStatusReady {
+		legacyKey = product.ImageKey
+	}
+	previousKeys := imageKeys(product.ImageVariants, legacyKey)
+
+	imageKey := path.Join(productImagePrefix, uuid.NewString())
+	err := tx.Model(product).Updates(map[string]interface{}{
+		"image_key":    imageKey,
+		"image_status": model.ImageStatusProcessing,
+	}).Error
+	if err != nil {
+		return err
+	}
+	product.ImageKey = imageKey
+	product.ImageStatus = model.ImageStatusProcessing
+
+	return jobs.Enqueue(tx, JobUploadProductImage, productImageUpload{
+		ProductID:    product.ID,
+		imageSource:  source,
+		ImageKey:     imageKey,
+		PreviousKeys: previousKeys,
+	})
+}
+
+// uploadProductImage handles JobUploadProductImage. The product is marked
+// failed when the image is rejected or the last attempt fails; the previous
+// image stays in storage until a new one is ready.
+func (s *ProductService) uploadProductImage(ctx context.Context, job *jobs.Job) error {
+	var payload productImageUpload
+	if err := job.Decode(&payload); err != nil {
+		return jobs.Permanent(err)
+	}
+
+	// 1. Render and upload the variants.
+	stored, err := uploadImage(ctx, s.uploader, s.storageAdapter, payload.imageSource, payload.ImageKey)
+	if err != nil {
+		if rejectedImage(err) || job.LastAttempt() {
+			s.updateImage(payload.ProductID, payload.ImageKey, map[string]interface{}{"image_status": model.ImageStatusFailed})
+			removeImageSource(ctx, s.uploader, s.storageAdapter, payload.imageSource)
+		}
+		return imageJobError(err)
+	}
+
+	// 2. Point the product at the new variants.
+	variants, publicURL := storedImage(stored)
+	slog.Info("Successfully processed product image", "productID", payload.ProductID, "url", publicURL)
+	applied := s.updateImage(payload.ProductID, payload.ImageKey, map[string]interface{}{
+		"image_status":   model.ImageStatusReady,
+		"image_url":      publicURL,
+		"image_variants": variants,
+	})
+	if !applied {
+		// A newer image replaced this one while it was processing.
+		deleteImageKeys(ctx, s.storageAdapter, variants.Keys(), "productID", payload.ProductID)
+	} else {
+		// 3. Remove the image it replaced.
+		deleteImageKeys(ctx, s.storageAdapter, payload.PreviousKeys, "productID", payload.ProductID)
+	}
+
+	removeImageSource(ctx, s.uploader, s.storageAdapter, payload.imageSource)
+	return nil
+}
+
+// updateImage is a helper to update the product record. It only applies
+// while imageKey is still the product's image, and reports whether it did.
+func (s *ProductService) updateImage(productID uuid.UUID, imageKey string, updates map[string]interface{}) bool {
+	var product model.Product
+	result := s.db.Model(&product).Where("id = ? AND image_key = ?", productID, imageKey).Updates(updates)
+	if result.Error != nil {
+		slog.Error("Failed to update product image status", "productID", productID, "error", result.Error)
+		return false
 	}
+	return result.RowsAffected > 0
 }
@@ -2,19 +2,28 @@ package service
 
 import (
 	"context"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"time"
+	"venturo-core/internal/database"
+	"venturo-core/internal/model"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
-// ReportService handles report generation logic.
+// ReportService handles report generation logic. Reports read from the
+// replicas, if any.
 type ReportService struct {
-	db *gorm.DB
+	db       *gorm.DB
+	resolver *database.Resolver
 }
 
 // NewReportService creates a new report service.
-func NewReportService(db *gorm.DB) *ReportService {
-	return &ReportService{db: db}
+func NewReportService(resolver *database.Resolver) *ReportService {
+	return &ReportService{db: resolver.Primary(), resolver: resolver}
 }
 
 // InventoryReportItem represents the inventory status for a specific item at an outlet.
@@ -23,7 +32,11 @@ type InventoryReportItem struct {
 	ItemName     string               `json:"item_name"`
 	OutletID     uuid.UUID            `json:"outlet_id"`
 	OutletName   string               `json:"outlet_name"`
-	OnHandQty    int                  `json:"on_hand_quantity"`
+	OnHandQty    int                  `json:"on_hand_quantity"` // In the product's base unit
+	BaseUnit     string               `json:"base_unit"`
+	Quantity     float64              `json:"quantity"` // On-hand rendered in Unit
+	Unit         string               `json:"unit"`
+	Lots         []model.LotBalance   `json:"lots"`
 	Transactions []TransactionHistory `json:"transaction_history"`
 }
 
@@ -31,21 +44,46 @@ type InventoryReportItem struct {
 type TransactionHistory struct {
 	TransactionID   *uuid.UUID `json:"transaction_id"`
 	InvoiceCode     *string    `json:"invoice_code"`
+	EntryType       string     `json:"entry_type"`
 	QuantityChange  int        `json:"quantity_change"`
+	Unit            string     `json:"unit"` // Unit the movement was entered in
+	EnteredQuantity float64    `json:"entered_quantity"`
 	TransactionType string     `json:"transaction_type"` // "stock-in" or "stock-out"
-	CreatedAt       string     `json:"created_at"`
+	CreatedAt       time.Time  `json:"created_at"`
 }
 
+// Inventory report page sizes.
+const (
+	DefaultInventoryReportLimit = 50
+	MaxInventoryReportLimit     = 500
+	DefaultHistoryLimit         = 10
+	MaxHistoryLimit             = 100
+)
+
 // InventoryReportInput represents the filter criteria for the inventory report.
 type InventoryReportInput struct {
 	ItemID   *uuid.UUID `json:"item_id"`
 	OutletID *uuid.UUID `json:"outlet_id"`
+	Unit     string     `json:"unit"` // Render quantities in this unit where the product defines it
+
+	Cursor       string     `json:"cursor"`        // Opaque cursor from the previous page
+	Limit        int        `json:"limit"`         // Item/outlet pairs per page
+	HistoryLimit int        `json:"history_limit"` // Most recent movements per pair; 0 omits history
+	From         *time.Time `json:"from"`          // History window start, inclusive
+	To           *time.Time `json:"to"`            // History window end, exclusive
 }
 
-// GenerateInventoryReport generates a comprehensive inventory report.
-func (s *ReportService) GenerateInventoryReport(ctx context.Context, input InventoryReportInput) ([]InventoryReportItem, error) {
+// InventoryReportPage is one page of the inventory report.
+type InventoryReportPage struct {
+	Items      []InventoryReportItem `json:"items"`
+	NextCursor string                `json:"next_cursor,omitempty"`
+}
+
+// GenerateInventoryReport generates one page of the inventory report, ordered by
+// item and outlet. Pass NextCursor back as Cursor to fetch the following page.
+func (s *ReportService) GenerateInventoryReport(ctx context.Context, input InventoryReportInput) (*InventoryReportPage, error) {
 	// Build base query for inventory aggregation
-	query := s.db.WithContext(ctx).
+	query := s.resolver.Reader(ctx).
 		Table("inventory_ledgers").
 		Select(`
 			inventory_ledgers.item_id,
@@ -56,7 +94,9 @@ func (s *ReportService) GenerateInventoryReport(ctx context.Context, input Inven
 		`).
 		Joins("LEFT JOIN products ON products.id = inventory_ledgers.item_id").
 		Joins("LEFT JOIN outlets ON outlets.id = inventory_ledgers.outlet_id").
-		Group("inventory_ledgers.item_id, inventory_ledgers.outlet_id, products.name, outlets.name")
+		Group("inventory_ledgers.item_id, inventory_ledgers.outlet_id, products.name, outlets.name").
+		Order("inventory_ledgers.item_id, inventory_ledgers.outlet_id").
+		Limit(input.Limit + 1) // One extra row tells us whether another page exists
 
 	// Apply filters
 	if input.ItemID != nil {
@@ -65,6 +105,13 @@ func (s *ReportService) GenerateInventoryReport(ctx context.Context, input Inven
 	if input.OutletID != nil {
 		query = query.Where("inventory_ledgers.outlet_id = ?", *input.OutletID)
 	}
+	if input.Cursor != "" {
+		after, err := decodeInventoryCursor(input.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		query = query.Where("(inventory_ledgers.item_id, inventory_ledgers.outlet_id) > (?, ?)", after.ItemID, after.OutletID)
+	}
 
 	// Execute the aggregation query
 	type AggregationResult struct {
@@ -80,79 +127,492 @@ func (s *ReportService) GenerateInventoryReport(ctx context.Context, input Inven
 		return nil, err
 	}
 
-	// Build the report items with transaction history
-	var reportItems []InventoryReportItem
+	page := &InventoryReportPage{Items: []InventoryReportItem{}}
+	if len(aggregationResults) > input.Limit {
+		aggregationResults = aggregationResults[:input.Limit]
+		last := aggregationResults[len(aggregationResults)-1]
+		page.NextCursor = encodeInventoryCursor(itemOutletKey{last.ItemID, last.OutletID})
+	}
+	if len(aggregationResults) == 0 {
+		return page, nil
+	}
+
+	pairs := make([][]interface{}, 0, len(aggregationResults))
+	itemIDs := make([]uuid.UUID, 0, len(aggregationResults))
 	for _, aggResult := range aggregationResults {
-		// Get transaction history for this item/outlet combination
-		transactionHistory, err := s.getTransactionHistory(ctx, aggResult.ItemID, aggResult.OutletID)
-		if err != nil {
-			return nil, err
-		}
+		pairs = append(pairs, []interface{}{aggResult.ItemID, aggResult.OutletID})
+		itemIDs = append(itemIDs, aggResult.ItemID)
+	}
+
+	lots, err := s.getLotBreakdown(ctx, pairs)
+	if err != nil {
+		return nil, err
+	}
 
+	history, err := s.getTransactionHistory(ctx, pairs, input)
+	if err != nil {
+		return nil, err
+	}
+
+	renderer, err := s.newUnitRenderer(ctx, input.Unit, itemIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	// Build the report items with transaction history
+	for _, aggResult := range aggregationResults {
+		key := itemOutletKey{aggResult.ItemID, aggResult.OutletID}
+		quantity, unit := renderer.render(aggResult.ItemID, int64(aggResult.OnHandQty))
 		reportItem := InventoryReportItem{
 			ItemID:       aggResult.ItemID,
 			ItemName:     aggResult.ItemName,
 			OutletID:     aggResult.OutletID,
 			OutletName:   aggResult.OutletName,
 			OnHandQty:    aggResult.OnHandQty,
-			Transactions: transactionHistory,
+			BaseUnit:     renderer.baseUnit(aggResult.ItemID),
+			Quantity:     quantity,
+			Unit:         unit,
+			Lots:         lots[key],
+			Transactions: history[key],
 		}
 
-		reportItems = append(reportItems, reportItem)
+		page.Items = append(page.Items, reportItem)
 	}
 
-	return reportItems, nil
+	return page, nil
 }
 
-// getTransactionHistory retrieves the transaction history for a specific item/outlet combination.
-func (s *ReportService) getTransactionHistory(ctx context.Context, itemID, outletID uuid.UUID) ([]TransactionHistory, error) {
-	var history []TransactionHistory
+// StreamInventoryReport walks every page of the inventory report and hands each
+// item to emit, so full exports never hold the whole report in memory.
+func (s *ReportService) StreamInventoryReport(ctx context.Context, input InventoryReportInput, emit func(InventoryReportItem) error) error {
+	for {
+		page, err := s.GenerateInventoryReport(ctx, input)
+		if err != nil {
+			return err
+		}
 
-	// Query for all inventory ledger entries with transaction details
-	rows, err := s.db.WithContext(ctx).
-		Table("inventory_ledgers").
-		Select(`
-			inventory_ledgers.transaction_id,
-			transactions.invoice_code,
-			inventory_ledgers.quantity_change,
-			inventory_ledgers.created_at
-		`).
-		Joins("LEFT JOIN transactions ON transactions.id = inventory_ledgers.transaction_id").
-		Where("inventory_ledgers.item_id = ? AND inventory_ledgers.outlet_id = ?", itemID, outletID).
-		Order("inventory_ledgers.created_at DESC").
-		Rows()
+		for _, item := range page.Items {
+			if err := emit(item); err != nil {
+				return err
+			}
+		}
+
+		if page.NextCursor == "" {
+			return nil
+		}
+		input.Cursor = page.NextCursor
+	}
+}
 
+// encodeInventoryCursor turns the last item/outlet pair of a page into an opaque cursor.
+func encodeInventoryCursor(key itemOutletKey) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(key.ItemID.String() + ":" + key.OutletID.String()))
+}
+
+// ErrInvalidCursor is returned when a report cursor cannot be decoded.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// decodeInventoryCursor reverses encodeInventoryCursor.
+func decodeInventoryCursor(cursor string) (itemOutletKey, error) {
+	invalid := ErrInvalidCursor
+
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return itemOutletKey{}, invalid
+	}
+	itemStr, outletStr, ok := strings.Cut(string(raw), ":")
+	if !ok {
+		return itemOutletKey{}, invalid
+	}
+
+	itemID, err := uuid.Parse(itemStr)
+	if err != nil {
+		return itemOutletKey{}, invalid
+	}
+	outletID, err := uuid.Parse(outletStr)
+	if err != nil {
+		return itemOutletKey{}, invalid
+	}
+	return itemOutletKey{itemID, outletID}, nil
+}
+
+// unitRenderer converts base quantities of many products into a requested unit.
+type unitRenderer struct {
+	unit     string
+	products map[uuid.UUID]*model.Product
+}
+
+// newUnitRenderer loads the unit conversions of the given products.
+func (s *ReportService) newUnitRenderer(ctx context.Context, unit string, itemIDs []uuid.UUID) (*unitRenderer, error) {
+	products, err := model.FindProductsWithUnits(s.resolver.Reader(ctx), itemIDs)
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
+	return &unitRenderer{unit: unit, products: products}, nil
+}
 
-	for rows.Next() {
-		var transactionID *uuid.UUID
-		var invoiceCode *string
-		var quantityChange int
-		var createdAt string
+// render returns baseQty in the requested unit, falling back to the product's base
+// unit when no unit was requested or the product has no conversion for it.
+func (r *unitRenderer) render(itemID uuid.UUID, baseQty int64) (float64, string) {
+	product, ok := r.products[itemID]
+	if !ok {
+		return float64(baseQty), ""
+	}
 
-		if err := rows.Scan(&transactionID, &invoiceCode, &quantityChange, &createdAt); err != nil {
-			return nil, err
+	if r.unit != "" {
+		if qty, err := product.FromBase(r.unit, baseQty); err == nil {
+			return qty, r.unit
 		}
+	}
+	return float64(baseQty), product.BaseUnit
+}
+
+// baseUnit returns the base unit of a product.
+func (r *unitRenderer) baseUnit(itemID uuid.UUID) string {
+	if product, ok := r.products[itemID]; ok {
+		return product.BaseUnit
+	}
+	return ""
+}
+
+// itemOutletKey identifies an item/outlet pair of the inventory report.
+type itemOutletKey struct {
+	ItemID   uuid.UUID
+	OutletID uuid.UUID
+}
+
+// getLotBreakdown retrieves the on-hand quantity per lot for every item/outlet pair
+// on the current page, in one query.
+func (s *ReportService) getLotBreakdown(ctx context.Context, pairs [][]interface{}) (map[itemOutletKey][]model.LotBalance, error) {
+	var rows []struct {
+		ItemID   uuid.UUID
+		OutletID uuid.UUID
+		model.LotBalance
+	}
+	err := s.resolver.Reader(ctx).
+		Model(&model.InventoryLedger{}).
+		Select("item_id, outlet_id, lot_number, expiry_date, SUM(quantity_change) AS on_hand_qty").
+		Where("(item_id, outlet_id) IN ?", pairs).
+		Group("item_id, outlet_id, lot_number, expiry_date").
+		Having("SUM(quantity_change) <> 0").
+		Order("expiry_date IS NULL, expiry_date, lot_number").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	lots := make(map[itemOutletKey][]model.LotBalance)
+	for _, row := range rows {
+		key := itemOutletKey{row.ItemID, row.OutletID}
+		lots[key] = append(lots[key], row.LotBalance)
+	}
+	return lots, nil
+}
+
+// getTransactionHistory retrieves the most recent ledger movements of every
+// item/outlet pair on the current page in one windowed query, newest first.
+func (s *ReportService) getTransactionHistory(ctx context.Context, pairs [][]interface{}, input InventoryReportInput) (map[itemOutletKey][]TransactionHistory, error) {
+	history := make(map[itemOutletKey][]TransactionHistory)
+	if input.HistoryLimit == 0 {
+		return history, nil
+	}
+
+	window := ""
+	args := []interface{}{pairs}
+	if input.From != nil {
+		window += " AND inventory_ledgers.created_at >= ?"
+		args = append(args, *input.From)
+	}
+	if input.To != nil {
+		window += " AND inventory_ledgers.created_at < ?"
+		args = append(args, *input.To)
+	}
+	args = append(args, input.HistoryLimit)
+
+	var rows []struct {
+		ItemID          uuid.UUID
+		OutletID        uuid.UUID
+		TransactionID   *uuid.UUID
+		InvoiceCode     *string
+		EntryType       string
+		QuantityChange  int
+		Unit            string
+		EnteredQuantity float64
+		CreatedAt       time.Time
+	}
+	err := s.resolver.Reader(ctx).Raw(`
+		SELECT * FROM (
+			SELECT
+				inventory_ledgers.item_id,
+				inventory_ledgers.outlet_id,
+				inventory_ledgers.transaction_id,
+				transactions.invoice_code,
+				inventory_ledgers.entry_type,
+				inventory_ledgers.quantity_change,
+				inventory_ledgers.unit,
+				inventory_ledgers.entered_quantity,
+				inventory_ledgers.created_at,
+				ROW_NUMBER() OVER (
+					PARTITION BY inventory_ledgers.item_id, inventory_ledgers.outlet_id
+					ORDER BY inventory_ledgers.created_at DESC, inventory_ledgers.id DESC
+				) AS row_num
+			FROM inventory_ledgers
+			LEFT JOIN transactions ON transactions.id = inventory_ledgers.transaction_id
+			WHERE (inventory_ledgers.item_id, inventory_ledgers.outlet_id) IN ?`+window+`
+		) history
+		WHERE history.row_num <= ?
+		ORDER BY history.item_id, history.outlet_id, history.row_num`, args...).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
 
-		// Determine transaction type based on quantity change and transaction ID
+	for _, row := range rows {
+		// Determine transaction type based on quantity change
 		transactionType := "stock-in"
-		if quantityChange < 0 {
+		if row.QuantityChange < 0 {
 			transactionType = "stock-out"
 		}
 
-		historyItem := TransactionHistory{
-			TransactionID:   transactionID,
-			InvoiceCode:     invoiceCode,
-			QuantityChange:  quantityChange,
+		key := itemOutletKey{row.ItemID, row.OutletID}
+		history[key] = append(history[key], TransactionHistory{
+			TransactionID:   row.TransactionID,
+			InvoiceCode:     row.InvoiceCode,
+			EntryType:       row.EntryType,
+			QuantityChange:  row.QuantityChange,
+			Unit:            row.Unit,
+			EnteredQuantity: row.EnteredQuantity,
 			TransactionType: transactionType,
-			CreatedAt:       createdAt,
+			CreatedAt:       row.CreatedAt,
+		})
+	}
+	return history, nil
+}
+
+// ExpiringLotItem is a lot with stock left that expires within the report horizon.
+type ExpiringLotItem struct {
+	ItemID          uuid.UUID `json:"item_id"`
+	ItemName        string    `json:"item_name"`
+	OutletID        uuid.UUID `json:"outlet_id"`
+	OutletName      string    `json:"outlet_name"`
+	LotNumber       string    `json:"lot_number"`
+	ExpiryDate      time.Time `json:"expiry_date"`
+	OnHandQty       int       `json:"on_hand_quantity"` // In the product's base unit
+	Quantity        float64   `json:"quantity"`         // On-hand rendered in Unit
+	Unit            string    `json:"unit"`
+	DaysUntilExpiry int       `json:"days_until_expiry"` // Negative once the lot has expired
+}
+
+// ExpiringReportInput represents the filter criteria for the expiring-soon report.
+type ExpiringReportInput struct {
+	Days     int
+	ItemID   *uuid.UUID
+	OutletID *uuid.UUID
+	Unit     string
+}
+
+// GenerateExpiringReport lists lots with stock left that expire within input.Days,
+// including lots that have already expired.
+func (s *ReportService) GenerateExpiringReport(ctx context.Context, input ExpiringReportInput) ([]ExpiringLotItem, error) {
+	horizon := time.Now().AddDate(0, 0, input.Days).Format(time.DateOnly)
+
+	query := s.resolver.Reader(ctx).
+		Table("inventory_ledgers").
+		Select(`
+			inventory_ledgers.item_id,
+			products.name as item_name,
+			inventory_ledgers.outlet_id,
+			outlets.name as outlet_name,
+			inventory_ledgers.lot_number,
+			inventory_ledgers.expiry_date,
+			SUM(inventory_ledgers.quantity_change) as on_hand_qty
+		`).
+		Joins("LEFT JOIN products ON products.id = inventory_ledgers.item_id").
+		Joins("LEFT JOIN outlets ON outlets.id = inventory_ledgers.outlet_id").
+		Where("inventory_ledgers.expiry_date IS NOT NULL AND inventory_ledgers.expiry_date <= ?", horizon).
+		Group("inventory_ledgers.item_id, inventory_ledgers.outlet_id, products.name, outlets.name, inventory_ledgers.lot_number, inventory_ledgers.expiry_date").
+		Having("SUM(inventory_ledgers.quantity_change) > 0").
+		Order("inventory_ledgers.expiry_date, products.name")
+
+	if input.ItemID != nil {
+		query = query.Where("inventory_ledgers.item_id = ?", *input.ItemID)
+	}
+	if input.OutletID != nil {
+		query = query.Where("inventory_ledgers.outlet_id = ?", *input.OutletID)
+	}
+
+	items := []ExpiringLotItem{}
+	if err := query.Scan(&items).Error; err != nil {
+		return nil, err
+	}
+
+	itemIDs := make([]uuid.UUID, 0, len(items))
+	for _, item := range items {
+		itemIDs = append(itemIDs, item.ItemID)
+	}
+	renderer, err := s.newUnitRenderer(ctx, input.Unit, itemIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	today := calendarDate(time.Now())
+	for i := range items {
+		items[i].DaysUntilExpiry = int(calendarDate(items[i].ExpiryDate).Sub(today).Hours() / 24)
+		items[i].Quantity, items[i].Unit = renderer.render(items[i].ItemID, int64(items[i].OnHandQty))
+	}
+
+	return items, nil
+}
+
+// calendarDate strips the clock and zone from t so whole days can be subtracted.
+func calendarDate(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// StockAsOfInput represents the filter criteria for the point-in-time stock report.
+type StockAsOfInput struct {
+	AsOf             time.Time
+	ItemID           *uuid.UUID
+	OutletID         *uuid.UUID
+	Unit             string
+	IncludeValuation bool
+}
+
+// StockAsOfItem is the on-hand quantity of an item at an outlet at a point in time.
+type StockAsOfItem struct {
+	ItemID          uuid.UUID `json:"item_id"`
+	ItemName        string    `json:"item_name"`
+	OutletID        uuid.UUID `json:"outlet_id"`
+	OutletName      string    `json:"outlet_name"`
+	OnHandQty       int       `json:"on_hand_quantity"` // In the product's base unit
+	BaseUnit        string    `json:"base_unit"`
+	Quantity        float64   `json:"quantity"` // On-hand rendered in Unit
+	Unit            string    `json:"unit"`
+	AverageUnitCost *float64  `json:"average_unit_cost,omitempty"` // Weighted average per base unit
+	Value           *float64  `json:"value,omitempty"`
+}
+
+// StockAsOfReport is the point-in-time stock report.
+type StockAsOfReport struct {
+	AsOf       time.Time       `json:"as_of"`
+	Items      []StockAsOfItem `json:"items"`
+	TotalValue *float64        `json:"total_value,omitempty"`
+}
+
+// GenerateStockAsOf computes on-hand quantities per item/outlet from every ledger row
+// created at or before input.AsOf. Valuation uses the weighted average cost of the
+// costed purchases up to the same moment. All reads share one snapshot, so rows
+// inserted while the report runs cannot skew it.
+func (s *ReportService) GenerateStockAsOf(ctx context.Context, input StockAsOfInput) (*StockAsOfReport, error) {
+	type AggregationResult struct {
+		ItemID     uuid.UUID
+		ItemName   string
+		OutletID   uuid.UUID
+		OutletName string
+		OnHandQty  int
+		CostedQty  int64
+		CostTotal  float64
+	}
+
+	var aggregationResults []AggregationResult
+	var renderer *unitRenderer
+
+	err := s.resolver.Reader(ctx).Transaction(func(tx *gorm.DB) error {
+		query := tx.Table("inventory_ledgers").
+			Select(`
+				inventory_ledgers.item_id,
+				products.name as item_name,
+				inventory_ledgers.outlet_id,
+				outlets.name as outlet_name,
+				COALESCE(SUM(inventory_ledgers.quantity_change), 0) as on_hand_qty,
+				COALESCE(SUM(CASE WHEN inventory_ledgers.quantity_change > 0 AND inventory_ledgers.unit_cost IS NOT NULL
+					THEN inventory_ledgers.quantity_change END), 0) as costed_qty,
+				COALESCE(SUM(CASE WHEN inventory_ledgers.quantity_change > 0 AND inventory_ledgers.unit_cost IS NOT NULL
+					THEN inventory_ledgers.quantity_change * inventory_ledgers.unit_cost END), 0) as cost_total
+			`).
+			Joins("LEFT JOIN products ON products.id = inventory_ledgers.item_id").
+			Joins("LEFT JOIN outlets ON outlets.id = inventory_ledgers.outlet_id").
+			Where("inventory_ledgers.created_at <= ?", input.AsOf).
+			Group("inventory_ledgers.item_id, inventory_ledgers.outlet_id, products.name, outlets.name").
+			Having("SUM(inventory_ledgers.quantity_change) <> 0").
+			Order("products.name, outlets.name")
+
+		if input.ItemID != nil {
+			query = query.Where("inventory_ledgers.item_id = ?", *input.ItemID)
+		}
+		if input.OutletID != nil {
+			query = query.Where("inventory_ledgers.outlet_id = ?", *input.OutletID)
+		}
+
+		if err := query.Scan(&aggregationResults).Error; err != nil {
+			return err
 		}
 
-		history = append(history, historyItem)
+		itemIDs := make([]uuid.UUID, 0, len(aggregationResults))
+		for _, aggResult := range aggregationResults {
+			itemIDs = append(itemIDs, aggResult.ItemID)
+		}
+		products, err := model.FindProductsWithUnits(tx, itemIDs)
+		if err != nil {
+			return err
+		}
+		renderer = &unitRenderer{unit: input.Unit, products: products}
+		return nil
+	}, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return nil, err
 	}
 
-	return history, nil
+	report := &StockAsOfReport{AsOf: input.AsOf, Items: []StockAsOfItem{}}
+	var totalValue float64
+	for _, aggResult := range aggregationResults {
+		quantity, unit := renderer.render(aggResult.ItemID, int64(aggResult.OnHandQty))
+		item := StockAsOfItem{
+			ItemID:     aggResult.ItemID,
+			ItemName:   aggResult.ItemName,
+			OutletID:   aggResult.OutletID,
+			OutletName: aggResult.OutletName,
+			OnHandQty:  aggResult.OnHandQty,
+			BaseUnit:   renderer.baseUnit(aggResult.ItemID),
+			Quantity:   quantity,
+			Unit:       unit,
+		}
+
+		if input.IncludeValuation && aggResult.CostedQty > 0 {
+			averageCost := aggResult.CostTotal / float64(aggResult.CostedQty)
+			value := averageCost * float64(aggResult.OnHandQty)
+			item.AverageUnitCost = &averageCost
+			item.Value = &value
+			totalValue += value
+		}
+
+		report.Items = append(report.Items, item)
+	}
+
+	if input.IncludeValuation {
+		report.TotalValue = &totalValue
+	}
+	return report, nil
+}
+
+// GetSummary returns the lifetime sales totals, recreating the report row from
+// the transactions if it has gone missing.
+func (s *ReportService) GetSummary(ctx context.Context) (*model.TransactionReport, error) {
+	var report model.TransactionReport
+	err := s.resolver.Reader(ctx).First(&report, "id = ?", 1).Error
+	if err == nil {
+		return &report, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	// Seed and read back on the primary; a replica may not have the row yet.
+	if err := seedTransactionReport(s.db.WithContext(ctx), uuid.Nil); err != nil {
+		return nil, err
+	}
+	if err := s.db.WithContext(ctx).First(&report, "id = ?", 1).Error; err != nil {
+		return nil, err
+	}
+	return &report, nil
 }
@@ -0,0 +1,161 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+	"venturo-core/internal/model"
+
+	"github.com/google/uuid"
+)
+
+// Sales report granularities.
+const (
+	GranularityDay   = "day"
+	GranularityWeek  = "week"
+	GranularityMonth = "month"
+)
+
+// SalesReportInput represents the filter criteria for the sales time series.
+// From and To are calendar dates; each outlet reads them in its own time zone.
+type SalesReportInput struct {
+	Granularity string
+	OutletID    *uuid.UUID
+	From        time.Time // Inclusive
+	To          time.Time // Exclusive
+}
+
+// SalesBucket holds the sales of one outlet over one day, week or month.
+type SalesBucket struct {
+	OutletID         uuid.UUID                   `json:"outlet_id"`
+	OutletName       string                      `json:"outlet_name"`
+	Timezone         string                      `json:"timezone"`
+	PeriodStart      string                      `json:"period_start"` // YYYY-MM-DD in the outlet's time zone
+	Revenue          int64                       `json:"revenue"`
+	TransactionCount int64                       `json:"transaction_count"`
+	AverageBasket    float64                     `json:"average_basket"`
+	ItemsSold        int64                       `json:"items_sold"`
+	CategoryMix      map[string]CategoryMixEntry `json:"category_mix"`
+}
+
+// CategoryMixEntry is the share of one product category within a bucket.
+type CategoryMixEntry struct {
+	ItemsSold int64 `json:"items_sold"`
+	Revenue   int64 `json:"revenue"`
+}
+
+// GenerateSalesReport buckets the sales of each outlet by day, week (starting
+// Monday) or month. It reads the daily summaries kept by SalesSummaryService,
+// whose days already follow each outlet's local calendar, so a sale at 23:30 in
+// Jakarta lands on the Jakarta date regardless of the server's zone.
+func (s *ReportService) GenerateSalesReport(ctx context.Context, input SalesReportInput) ([]SalesBucket, error) {
+	if !input.From.Before(input.To) {
+		return nil, errors.New("from must be before to")
+	}
+
+	var outlets []model.Outlet
+	outletQuery := s.resolver.Reader(ctx).Model(&model.Outlet{})
+	if input.OutletID != nil {
+		outletQuery = outletQuery.Where("id = ?", *input.OutletID)
+	}
+	if err := outletQuery.Find(&outlets).Error; err != nil {
+		return nil, err
+	}
+
+	outletsByID := make(map[uuid.UUID]model.Outlet, len(outlets))
+	outletIDs := make([]uuid.UUID, 0, len(outlets))
+	for _, outlet := range outlets {
+		outletsByID[outlet.ID] = outlet
+		outletIDs = append(outletIDs, outlet.ID)
+	}
+	if len(outletIDs) == 0 {
+		return []SalesBucket{}, nil
+	}
+
+	from := input.From.Format(time.DateOnly)
+	to := input.To.Format(time.DateOnly)
+
+	var days []model.SalesDailySummary
+	err := s.resolver.Reader(ctx).
+		Where("outlet_id IN ? AND sales_date >= ? AND sales_date < ?", outletIDs, from, to).
+		Find(&days).Error
+	if err != nil {
+		return nil, err
+	}
+
+	type bucketKey struct {
+		OutletID    uuid.UUID
+		PeriodStart string
+	}
+	buckets := make(map[bucketKey]*SalesBucket)
+	for _, day := range days {
+		key := bucketKey{day.OutletID, periodStart(day.SalesDate, input.Granularity)}
+		bucket, ok := buckets[key]
+		if !ok {
+			outlet := outletsByID[day.OutletID]
+			bucket = &SalesBucket{
+				OutletID:    day.OutletID,
+				OutletName:  outlet.Name,
+				Timezone:    outlet.Location().String(),
+				PeriodStart: key.PeriodStart,
+				CategoryMix: make(map[string]CategoryMixEntry),
+			}
+			buckets[key] = bucket
+		}
+		bucket.Revenue += day.Revenue
+		bucket.TransactionCount += day.PaidTransactions
+		bucket.ItemsSold += day.ProductsSold
+	}
+
+	var categoryDays []model.SalesDailyCategorySummary
+	err = s.resolver.Reader(ctx).
+		Where("outlet_id IN ? AND sales_date >= ? AND sales_date < ?", outletIDs, from, to).
+		Find(&categoryDays).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, day := range categoryDays {
+		bucket, ok := buckets[bucketKey{day.OutletID, periodStart(day.SalesDate, input.Granularity)}]
+		if !ok {
+			continue // A sale recorded between the two reads
+		}
+		mix := bucket.CategoryMix[day.Category.String()]
+		mix.ItemsSold += day.ProductsSold
+		mix.Revenue += day.Revenue
+		bucket.CategoryMix[day.Category.String()] = mix
+	}
+
+	report := make([]SalesBucket, 0, len(buckets))
+	for _, bucket := range buckets {
+		if bucket.TransactionCount > 0 {
+			bucket.AverageBasket = float64(bucket.Revenue) / float64(bucket.TransactionCount)
+		}
+		report = append(report, *bucket)
+	}
+	sort.Slice(report, func(i, j int) bool {
+		if report[i].OutletName != report[j].OutletName {
+			return report[i].OutletName < report[j].OutletName
+		}
+		if report[i].OutletID != report[j].OutletID {
+			return report[i].OutletID.String() < report[j].OutletID.String()
+		}
+		return report[i].PeriodStart < report[j].PeriodStart
+	})
+
+	return report, nil
+}
+
+// periodStart returns the first day of the bucket containing the calendar day t.
+func periodStart(t time.Time, granularity string) string {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	switch granularity {
+	case GranularityWeek:
+		offset := (int(day.Weekday()) + 6) % 7 // Days since Monday
+		day = day.AddDate(0, 0, -offset)
+	case GranularityMonth:
+		day = time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+	}
+	return day.Format(time.DateOnly)
+}
@@ -0,0 +1,574 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"venturo-core/internal/database"
+	"venturo-core/internal/model"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SalesSummaryService maintains the sales aggregates derived from paid transactions.
+type SalesSummaryService struct {
+	db       *gorm.DB
+	resolver *database.Resolver
+}
+
+// NewSalesSummaryService creates a new sales summary service.
+func NewSalesSummaryService(resolver *database.Resolver) *SalesSummaryService {
+	return &SalesSummaryService{db: resolver.Primary(), resolver: resolver}
+}
+
+// categorySale is the quantity and revenue of one category within a transaction.
+type categorySale struct {
+	Category     model.ProductCategory
+	ProductsSold int64
+	Revenue      int64
+}
+
+// applySale adds one newly paid transaction to the aggregates. It must run in
+// the same DB transaction that marks it paid, so the aggregates can never count
+// a payment that was rolled back.
+func applySale(tx *gorm.DB, transaction *model.Transaction, loc *time.Location) error {
+	return addSale(tx, transaction, loc, 1)
+}
+
+// reverseSale takes a refunded transaction back out of the aggregates, on the
+// day it was sold. It must run in the refunding DB transaction, before the
+// transaction is marked refunded. The customer stays counted as unique: they
+// did buy once.
+func reverseSale(tx *gorm.DB, transaction *model.Transaction, loc *time.Location) error {
+	return addSale(tx, transaction, loc, -1)
+}
+
+// addSale adds a transaction to the aggregates with sign 1, or subtracts it
+// with sign -1.
+func addSale(tx *gorm.DB, transaction *model.Transaction, loc *time.Location, sign int64) error {
+	var categories []categorySale
+	err := tx.Model(&model.TransactionDetail{}).
+		Select("category, SUM(qty) AS products_sold, SUM(qty * price) AS revenue").
+		Where("transaction_id = ?", transaction.ID).
+		Group("category").
+		Scan(&categories).Error
+	if err != nil {
+		return err
+	}
+
+	var productsSold int64
+	for _, c := range categories {
+		productsSold += c.ProductsSold
+	}
+
+	// A customer counts as a new unique customer on their first paid transaction.
+	// Anonymous sales are not counted. Locking the customer serialises their
+	// concurrent first payments, which would otherwise both see no earlier one.
+	var newCustomers int64
+	if transaction.CustomerID != nil && sign > 0 {
+		if err := lockCustomer(tx, *transaction.CustomerID); err != nil {
+			return err
+		}
+		var earlierPayments int64
+		err = tx.Model(&model.Transaction{}).
+			Where("customer_id = ? AND is_paid = ? AND id <> ?", *transaction.CustomerID, true, transaction.ID).
+			Count(&earlierPayments).Error
+		if err != nil {
+			return err
+		}
+		if earlierPayments == 0 {
+			newCustomers = 1
+		}
+	}
+
+	// The global row is updated first: Rebuild locks it before touching the
+	// daily rows, and taking the locks in the same order avoids deadlocks.
+	categorySummary := clause.Expr{SQL: "COALESCE(category_summary, JSON_OBJECT())"}
+	if len(categories) > 0 {
+		paths := make([]string, 0, len(categories))
+		vars := make([]interface{}, 0, len(categories)*2)
+		for _, c := range categories {
+			path := fmt.Sprintf(`$."%s"`, c.Category.String())
+			paths = append(paths, "?, COALESCE(JSON_EXTRACT(category_summary, ?), 0) + ?")
+			vars = append(vars, path, path, sign*c.ProductsSold)
+		}
+		categorySummary = gorm.Expr("JSON_SET(COALESCE(category_summary, JSON_OBJECT()), "+strings.Join(paths, ", ")+")", vars...)
+	}
+
+	delta := map[string]interface{}{
+		"total_revenue":           gorm.Expr("total_revenue + ?", sign*transaction.Total),
+		"total_paid_transactions": gorm.Expr("total_paid_transactions + ?", sign),
+		"total_products_sold":     gorm.Expr("total_products_sold + ?", sign*productsSold),
+		"total_unique_customers":  gorm.Expr("total_unique_customers + ?", newCustomers),
+		"category_summary":        categorySummary,
+	}
+	update := tx.Model(&model.TransactionReport{}).Where("id = ?", 1).Updates(delta)
+	if update.Error != nil {
+		return update.Error
+	}
+	if update.RowsAffected == 0 {
+		// The row is missing. The seed leaves this payment out and the delta is
+		// applied on top, so the payment is counted once whichever request
+		// ends up seeding the row. A refund is not marked yet, so the seed
+		// still holds the sale the delta takes back out.
+		pending := transaction.ID
+		if sign < 0 {
+			pending = uuid.Nil
+		}
+		if err := seedTransactionReport(tx, pending); err != nil {
+			return err
+		}
+		if err := tx.Model(&model.TransactionReport{}).Where("id = ?", 1).Updates(delta).Error; err != nil {
+			return err
+		}
+	}
+
+	soldAt := transaction.CreatedAt
+	if transaction.PaidAt != nil {
+		soldAt = *transaction.PaidAt
+	}
+	salesDate := localDate(soldAt, loc)
+	daily := model.SalesDailySummary{
+		OutletID:         transaction.OutletID,
+		SalesDate:        salesDate,
+		Revenue:          sign * transaction.Total,
+		PaidTransactions: sign,
+		ProductsSold:     sign * productsSold,
+	}
+	if err := daily.AddTo(tx); err != nil {
+		return err
+	}
+
+	for _, c := range categories {
+		categoryDaily := model.SalesDailyCategorySummary{
+			OutletID:     transaction.OutletID,
+			SalesDate:    salesDate,
+			Category:     c.Category,
+			Revenue:      sign * c.Revenue,
+			ProductsSold: sign * c.ProductsSold,
+		}
+		if err := categoryDaily.AddTo(tx); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// seedTransactionReport creates the global report row from all paid
+// transactions that were not refunded when it does not exist, e.g. because the
+// seed in migration 000007 was never applied. pending is a payment whose delta the caller applies
+// next; it is left out of the seed. uuid.Nil leaves out nothing.
+func seedTransactionReport(tx *gorm.DB, pending uuid.UUID) error {
+	report := model.TransactionReport{ID: 1, CategorySummary: make(model.CategorySummary)}
+
+	err := tx.Model(&model.Transaction{}).
+		Select("COALESCE(SUM(IF(refunded_at IS NULL, total, 0)), 0), COALESCE(SUM(refunded_at IS NULL), 0), COUNT(DISTINCT customer_id)").
+		Where("is_paid = ? AND id <> ?", true, pending).
+		Row().
+		Scan(&report.TotalRevenue, &report.TotalPaidTransactions, &report.TotalUniqueCustomers)
+	if err != nil {
+		return err
+	}
+
+	var categories []categorySale
+	err = tx.Model(&model.TransactionDetail{}).
+		Select("transaction_details.category, SUM(transaction_details.qty) AS products_sold").
+		Joins("JOIN transactions ON transactions.id = transaction_details.transaction_id").
+		Where("transactions.is_paid = ? AND transactions.refunded_at IS NULL AND transactions.id <> ?", true, pending).
+		Group("transaction_details.category").
+		Scan(&categories).Error
+	if err != nil {
+		return err
+	}
+	for _, c := range categories {
+		report.TotalProductsSold += uint64(c.ProductsSold)
+		report.CategorySummary[c.Category.String()] += c.ProductsSold
+	}
+
+	// A concurrent seeder may insert first. Its figures lack the payments of
+	// transactions that had not committed yet, but each of those applies its
+	// own delta after seeding, so nothing is lost by keeping its row.
+	return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&report).Error
+}
+
+// localDate returns the calendar day of t in loc, as a DATE column value.
+func localDate(t time.Time, loc *time.Location) time.Time {
+	local := t.In(loc)
+	return time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.Local)
+}
+
+// SummaryMismatch is one figure whose stored aggregate differs from the value
+// recomputed from transactions.
+type SummaryMismatch struct {
+	Scope   string // "global", or "<outlet_id> <date>" with an optional category
+	Metric  string
+	Stored  int64
+	Rebuilt int64
+}
+
+// RebuildResult reports the differences found by Rebuild.
+type RebuildResult struct {
+	Mismatches []SummaryMismatch
+	Applied    bool
+}
+
+type dailyKey struct {
+	OutletID  uuid.UUID
+	SalesDate string
+}
+
+type dailyCategoryKey struct {
+	dailyKey
+	Category model.ProductCategory
+}
+
+// dailyFigures is the metric set shared by the daily and per-category rows.
+type dailyFigures struct {
+	Revenue          int64
+	PaidTransactions int64
+	ProductsSold     int64
+}
+
+// Rebuild recomputes every sales aggregate from paid transactions and diffs the
+// result against what is stored. With apply set, the stored aggregates are
+// replaced by the recomputed ones.
+//
+// When applying, the global report row on the primary is locked first, which
+// holds off concurrent payments so the recomputed figures and the replacement
+// describe the same set of sales. A dry run only reads, so it runs on a
+// replica if there is one; its snapshot is consistent without the lock.
+func (s *SalesSummaryService) Rebuild(ctx context.Context, apply bool) (*RebuildResult, error) {
+	result := &RebuildResult{Mismatches: []SummaryMismatch{}, Applied: apply}
+
+	db := s.resolver.Reader(ctx)
+	if apply {
+		db = s.db.WithContext(ctx)
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		query := tx
+		if apply {
+			query = tx.Clauses(clause.Locking{Strength: "UPDATE"})
+		}
+
+		var stored model.TransactionReport
+		err := query.First(&stored, "id = ?", 1).Error
+		if err != nil && err != gorm.ErrRecordNotFound {
+			return err
+		}
+
+		rebuiltReport, daily, categories, err := s.recompute(tx)
+		if err != nil {
+			return err
+		}
+
+		storedDaily, storedCategories, err := s.loadStored(tx)
+		if err != nil {
+			return err
+		}
+
+		result.Mismatches = append(result.Mismatches, diffReport(stored, rebuiltReport)...)
+		result.Mismatches = append(result.Mismatches, diffDaily(storedDaily, daily)...)
+		result.Mismatches = append(result.Mismatches, diffCategories(storedCategories, categories)...)
+
+		if !apply {
+			return nil
+		}
+		return s.replace(tx, rebuiltReport, daily, categories)
+	}, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: !apply})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// recompute derives all aggregates from the paid transactions visible to tx.
+// Refunded transactions only count towards the unique customers, as in
+// reverseSale.
+func (s *SalesSummaryService) recompute(tx *gorm.DB) (model.TransactionReport, map[dailyKey]dailyFigures, map[dailyCategoryKey]dailyFigures, error) {
+	report := model.TransactionReport{ID: 1, CategorySummary: make(model.CategorySummary)}
+	daily := make(map[dailyKey]dailyFigures)
+	categories := make(map[dailyCategoryKey]dailyFigures)
+
+	var outlets []model.Outlet
+	if err := tx.Find(&outlets).Error; err != nil {
+		return report, nil, nil, err
+	}
+	locations := make(map[uuid.UUID]*time.Location, len(outlets))
+	for _, outlet := range outlets {
+		locations[outlet.ID] = outlet.Location()
+	}
+	location := func(outletID uuid.UUID) *time.Location {
+		if loc, ok := locations[outletID]; ok {
+			return loc
+		}
+		return time.UTC
+	}
+
+	transactionDays := make(map[uuid.UUID]dailyKey)
+	customers := make(map[uuid.UUID]struct{})
+
+	rows, err := tx.Model(&model.Transaction{}).
+		Select("id, outlet_id, customer_id, total, COALESCE(paid_at, created_at), refunded_at IS NOT NULL").
+		Where("is_paid = ?", true).
+		Rows()
+	if err != nil {
+		return report, nil, nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var transactionID, outletID uuid.UUID
+		var customerID *uuid.UUID
+		var total int64
+		var paidAt time.Time
+		var refunded bool
+		if err := rows.Scan(&transactionID, &outletID, &customerID, &total, &paidAt, &refunded); err != nil {
+			return report, nil, nil, err
+		}
+
+		if customerID != nil {
+			customers[*customerID] = struct{}{}
+		}
+		if refunded {
+			continue
+		}
+
+		key := dailyKey{outletID, paidAt.In(location(outletID)).Format(time.DateOnly)}
+		figures := daily[key]
+		figures.Revenue += total
+		figures.PaidTransactions++
+		daily[key] = figures
+		transactionDays[transactionID] = key
+
+		report.TotalRevenue += uint64(total)
+		report.TotalPaidTransactions++
+	}
+	if err := rows.Err(); err != nil {
+		return report, nil, nil, err
+	}
+	report.TotalUniqueCustomers = uint64(len(customers))
+
+	detailRows, err := tx.Model(&model.TransactionDetail{}).
+		Select("transaction_details.transaction_id, transaction_details.category, SUM(transaction_details.qty), SUM(transaction_details.qty * transaction_details.price)").
+		Joins("JOIN transactions ON transactions.id = transaction_details.transaction_id").
+		Where("transactions.is_paid = ? AND transactions.refunded_at IS NULL", true).
+		Group("transaction_details.transaction_id, transaction_details.category").
+		Rows()
+	if err != nil {
+		return report, nil, nil, err
+	}
+	defer detailRows.Close()
+
+	for detailRows.Next() {
+		var transactionID uuid.UUID
+		var category model.ProductCategory
+		var qty, revenue int64
+		if err := detailRows.Scan(&transactionID, &category, &qty, &revenue); err != nil {
+			return report, nil, nil, err
+		}
+
+		key, ok := transactionDays[transactionID]
+		if !ok {
+			continue
+		}
+		figures := daily[key]
+		figures.ProductsSold += qty
+		daily[key] = figures
+
+		categoryKey := dailyCategoryKey{key, category}
+		categoryFigures := categories[categoryKey]
+		categoryFigures.ProductsSold += qty
+		categoryFigures.Revenue += revenue
+		categories[categoryKey] = categoryFigures
+
+		report.TotalProductsSold += uint64(qty)
+		report.CategorySummary[category.String()] += qty
+	}
+	if err := detailRows.Err(); err != nil {
+		return report, nil, nil, err
+	}
+
+	return report, daily, categories, nil
+}
+
+// loadStored reads the daily aggregates currently in the database.
+func (s *SalesSummaryService) loadStored(tx *gorm.DB) (map[dailyKey]dailyFigures, map[dailyCategoryKey]dailyFigures, error) {
+	var dailyRows []model.SalesDailySummary
+	if err := tx.Find(&dailyRows).Error; err != nil {
+		return nil, nil, err
+	}
+	daily := make(map[dailyKey]dailyFigures, len(dailyRows))
+	for _, row := range dailyRows {
+		daily[dailyKey{row.OutletID, row.SalesDate.Format(time.DateOnly)}] = dailyFigures{
+			Revenue:          row.Revenue,
+			PaidTransactions: row.PaidTransactions,
+			ProductsSold:     row.ProductsSold,
+		}
+	}
+
+	var categoryRows []model.SalesDailyCategorySummary
+	if err := tx.Find(&categoryRows).Error; err != nil {
+		return nil, nil, err
+	}
+	categories := make(map[dailyCategoryKey]dailyFigures, len(categoryRows))
+	for _, row := range categoryRows {
+		key := dailyCategoryKey{dailyKey{row.OutletID, row.SalesDate.Format(time.DateOnly)}, row.Category}
+		categories[key] = dailyFigures{Revenue: row.Revenue, ProductsSold: row.ProductsSold}
+	}
+
+	return daily, categories, nil
+}
+
+// replace swaps the stored aggregates for the recomputed ones.
+func (s *SalesSummaryService) replace(tx *gorm.DB, report model.TransactionReport, daily map[dailyKey]dailyFigures, categories map[dailyCategoryKey]dailyFigures) error {
+	if err := report.Save(tx); err != nil {
+		return err
+	}
+
+	if err := tx.Where("1 = 1").Delete(&model.SalesDailyCategorySummary{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("1 = 1").Delete(&model.SalesDailySummary{}).Error; err != nil {
+		return err
+	}
+
+	dailyRows := make([]model.SalesDailySummary, 0, len(daily))
+	for key, figures := range daily {
+		salesDate, err := time.ParseInLocation(time.DateOnly, key.SalesDate, time.Local)
+		if err != nil {
+			return err
+		}
+		dailyRows = append(dailyRows, model.SalesDailySummary{
+			OutletID:         key.OutletID,
+			SalesDate:        salesDate,
+			Revenue:          figures.Revenue,
+			PaidTransactions: figures.PaidTransactions,
+			ProductsSold:     figures.ProductsSold,
+		})
+	}
+	if len(dailyRows) > 0 {
+		if err := tx.CreateInBatches(dailyRows, 500).Error; err != nil {
+			return err
+		}
+	}
+
+	categoryRows := make([]model.SalesDailyCategorySummary, 0, len(categories))
+	for key, figures := range categories {
+		salesDate, err := time.ParseInLocation(time.DateOnly, key.SalesDate, time.Local)
+		if err != nil {
+			return err
+		}
+		categoryRows = append(categoryRows, model.SalesDailyCategorySummary{
+			OutletID:     key.OutletID,
+			SalesDate:    salesDate,
+			Category:     key.Category,
+			Revenue:      figures.Revenue,
+			ProductsSold: figures.ProductsSold,
+		})
+	}
+	if len(categoryRows) > 0 {
+		if err := tx.CreateInBatches(categoryRows, 500).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func diffReport(stored, rebuilt model.TransactionReport) []SummaryMismatch {
+	var mismatches []SummaryMismatch
+	add := func(metric string, storedValue, rebuiltValue int64) {
+		if storedValue != rebuiltValue {
+			mismatches = append(mismatches, SummaryMismatch{"global", metric, storedValue, rebuiltValue})
+		}
+	}
+
+	add("total_revenue", int64(stored.TotalRevenue), int64(rebuilt.TotalRevenue))
+	add("total_paid_transactions", stored.TotalPaidTransactions, rebuilt.TotalPaidTransactions)
+	add("total_products_sold", int64(stored.TotalProductsSold), int64(rebuilt.TotalProductsSold))
+	add("total_unique_customers", int64(stored.TotalUniqueCustomers), int64(rebuilt.TotalUniqueCustomers))
+	for _, category := range sortedCategories(stored.CategorySummary, rebuilt.CategorySummary) {
+		add("category_summary."+category, stored.CategorySummary[category], rebuilt.CategorySummary[category])
+	}
+
+	return mismatches
+}
+
+func diffDaily(stored, rebuilt map[dailyKey]dailyFigures) []SummaryMismatch {
+	keys := unionKeys(stored, rebuilt)
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].OutletID != keys[j].OutletID {
+			return keys[i].OutletID.String() < keys[j].OutletID.String()
+		}
+		return keys[i].SalesDate < keys[j].SalesDate
+	})
+
+	var mismatches []SummaryMismatch
+	for _, key := range keys {
+		scope := key.OutletID.String() + " " + key.SalesDate
+		mismatches = append(mismatches, diffFigures(scope, stored[key], rebuilt[key], true)...)
+	}
+	return mismatches
+}
+
+func diffCategories(stored, rebuilt map[dailyCategoryKey]dailyFigures) []SummaryMismatch {
+	keys := unionKeys(stored, rebuilt)
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].OutletID != keys[j].OutletID {
+			return keys[i].OutletID.String() < keys[j].OutletID.String()
+		}
+		if keys[i].SalesDate != keys[j].SalesDate {
+			return keys[i].SalesDate < keys[j].SalesDate
+		}
+		return keys[i].Category < keys[j].Category
+	})
+
+	var mismatches []SummaryMismatch
+	for _, key := range keys {
+		scope := key.OutletID.String() + " " + key.SalesDate + " " + key.Category.String()
+		mismatches = append(mismatches, diffFigures(scope, stored[key], rebuilt[key], false)...)
+	}
+	return mismatches
+}
+
+func diffFigures(scope string, stored, rebuilt dailyFigures, withTransactions bool) []SummaryMismatch {
+	var mismatches []SummaryMismatch
+	if stored.Revenue != rebuilt.Revenue {
+		mismatches = append(mismatches, SummaryMismatch{scope, "revenue", stored.Revenue, rebuilt.Revenue})
+	}
+	if withTransactions && stored.PaidTransactions != rebuilt.PaidTransactions {
+		mismatches = append(mismatches, SummaryMismatch{scope, "paid_transactions", stored.PaidTransactions, rebuilt.PaidTransactions})
+	}
+	if stored.ProductsSold != rebuilt.ProductsSold {
+		mismatches = append(mismatches, SummaryMismatch{scope, "products_sold", stored.ProductsSold, rebuilt.ProductsSold})
+	}
+	return mismatches
+}
+
+// unionKeys returns every key present in either map.
+func unionKeys[K comparable, V any](a, b map[K]V) []K {
+	keys := make([]K, 0, len(a))
+	for key := range a {
+		keys = append(keys, key)
+	}
+	for key := range b {
+		if _, ok := a[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+func sortedCategories(a, b model.CategorySummary) []string {
+	categories := unionKeys(a, b)
+	sort.Strings(categories)
+	return categories
+}
@@ -0,0 +1,289 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+	"venturo-core/internal/model"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrNoOpenShift is returned when an outlet requires an open shift and the cashier has none.
+	ErrNoOpenShift = errors.New("no open shift for this cashier at the outlet")
+	// ErrShiftAlreadyOpen is returned when opening a second shift at the same outlet.
+	ErrShiftAlreadyOpen = errors.New("cashier already has an open shift at this outlet")
+	// ErrShiftClosed is returned when changing a shift that has been closed.
+	ErrShiftClosed = errors.New("shift is closed")
+	// ErrNotShiftOwner is returned when a cashier changes another cashier's shift.
+	ErrNotShiftOwner = errors.New("shift belongs to another cashier")
+)
+
+// ShiftService handles cashier shifts and cash drawer reconciliation.
+type ShiftService struct {
+	db *gorm.DB
+}
+
+// NewShiftService creates a new shift service.
+func NewShiftService(db *gorm.DB) *ShiftService {
+	return &ShiftService{db: db}
+}
+
+// OpenShiftInput represents the data needed to open a shift.
+type OpenShiftInput struct {
+	OutletID     uuid.UUID
+	UserID       uuid.UUID
+	OpeningFloat int64
+	Note         string
+}
+
+// OpenShift starts a shift for the cashier with the given cash in the drawer.
+func (s *ShiftService) OpenShift(ctx context.Context, input OpenShiftInput) (*model.Shift, error) {
+	shift := model.Shift{
+		OutletID:     input.OutletID,
+		UserID:       input.UserID,
+		Status:       model.ShiftOpen,
+		OpeningFloat: input.OpeningFloat,
+		OpeningNote:  input.Note,
+		OpenedAt:     time.Now(),
+	}
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var outlet model.Outlet
+		if err := tx.First(&outlet, "id = ?", input.OutletID).Error; err != nil {
+			return errors.New("outlet not found")
+		}
+
+		_, err := model.FindOpenShift(tx, input.OutletID, input.UserID)
+		if err == nil {
+			return ErrShiftAlreadyOpen
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		// The unique open_key index still rejects a concurrent second open.
+		return tx.Create(&shift).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &shift, nil
+}
+
+// GetCurrentShift returns the cashier's open shift at an outlet.
+func (s *ShiftService) GetCurrentShift(ctx context.Context, outletID, userID uuid.UUID) (*model.Shift, error) {
+	shift, err := model.FindOpenShift(s.db.WithContext(ctx), outletID, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("open shift not found")
+		}
+		return nil, err
+	}
+	return shift, nil
+}
+
+// CashMovementInput represents a pay-in or pay-out.
+type CashMovementInput struct {
+	ShiftID uuid.UUID
+	UserID  uuid.UUID
+	Type    string
+	Amount  int64
+	Reason  string
+}
+
+// RecordCashMovement records cash put into or taken out of an open shift's
+// drawer. Only the cashier who opened the shift can record one.
+func (s *ShiftService) RecordCashMovement(ctx context.Context, input CashMovementInput) (*model.ShiftCashMovement, error) {
+	if input.Type != model.CashPayIn && input.Type != model.CashPayOut {
+		return nil, errors.New("type must be pay_in or pay_out")
+	}
+
+	movement := model.ShiftCashMovement{
+		ShiftID: input.ShiftID,
+		UserID:  input.UserID,
+		Type:    input.Type,
+		Amount:  input.Amount,
+		Reason:  input.Reason,
+	}
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var shift model.Shift
+		if err := tx.Clauses(clause.Locking{Strength: "SHARE"}).First(&shift, "id = ?", input.ShiftID).Error; err != nil {
+			return errors.New("shift not found")
+		}
+		if shift.UserID != input.UserID {
+			return ErrNotShiftOwner
+		}
+		if shift.Status != model.ShiftOpen {
+			return ErrShiftClosed
+		}
+		return tx.Create(&movement).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &movement, nil
+}
+
+// PaymentMethodTotal sums the payments taken with one method during a shift.
+type PaymentMethodTotal struct {
+	Method   string `json:"method"`
+	Payments int64  `json:"payments"`
+	Amount   int64  `json:"amount"`
+}
+
+// ShiftReport is the X report of an open shift or the Z report of a closed one.
+type ShiftReport struct {
+	Type             string               `json:"type"` // "X" while open, "Z" once closed
+	Shift            model.Shift          `json:"shift"`
+	TransactionCount int64                `json:"transaction_count"` // Transactions rung up in the shift
+	Revenue          int64                `json:"revenue"`           // All payments taken in the shift
+	PaymentMethods   []PaymentMethodTotal `json:"payment_methods"`
+	CashSales        int64                `json:"cash_sales"`
+	PayIns           int64                `json:"pay_ins"`
+	PayOuts          int64                `json:"pay_outs"`
+	ExpectedCash     int64                `json:"expected_cash"` // Opening float + cash sales + pay-ins - pay-outs
+	CountedCash      *int64               `json:"counted_cash"`
+	Variance         *int64               `json:"variance"` // Counted minus expected
+}
+
+// GetShiftReport builds the current report of a shift: an X report while it is
+// open, the Z report once it has been closed.
+func (s *ShiftService) GetShiftReport(ctx context.Context, shiftID uuid.UUID) (*ShiftReport, error) {
+	var shift model.Shift
+	if err := s.db.WithContext(ctx).Preload("CashMovements").First(&shift, "id = ?", shiftID).Error; err != nil {
+		return nil, errors.New("shift not found")
+	}
+	return buildShiftReport(s.db.WithContext(ctx), shift)
+}
+
+// CloseShiftInput represents the data needed to close a shift.
+type CloseShiftInput struct {
+	ShiftID     uuid.UUID
+	UserID      uuid.UUID // The cashier closing it, who must own the shift
+	CountedCash int64
+	Note        string
+}
+
+// CloseShift records the counted cash, stores the expected amount and variance,
+// and returns the Z report. Only the cashier who opened the shift can close it.
+func (s *ShiftService) CloseShift(ctx context.Context, input CloseShiftInput) (*ShiftReport, error) {
+	var report *ShiftReport
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var shift model.Shift
+		// Locking the shift holds off payments and cash movements until it is closed.
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("CashMovements").First(&shift, "id = ?", input.ShiftID).Error
+		if err != nil {
+			return errors.New("shift not found")
+		}
+		if shift.UserID != input.UserID {
+			return ErrNotShiftOwner
+		}
+		if shift.Status != model.ShiftOpen {
+			return ErrShiftClosed
+		}
+
+		report, err = buildShiftReport(tx, shift)
+		if err != nil {
+			return err
+		}
+
+		closedAt := time.Now()
+		variance := input.CountedCash - report.ExpectedCash
+		shift.Status = model.ShiftClosed
+		shift.ExpectedCash = &report.ExpectedCash
+		shift.CountedCash = &input.CountedCash
+		shift.Variance = &variance
+		shift.ClosingNote = input.Note
+		shift.ClosedAt = &closedAt
+		err = tx.Model(&shift).
+			Select("status", "expected_cash", "counted_cash", "variance", "closing_note", "closed_at").
+			Updates(&shift).Error
+		if err != nil {
+			return err
+		}
+
+		report.Type = "Z"
+		report.Shift = shift
+		report.CountedCash = shift.CountedCash
+		report.Variance = shift.Variance
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+// buildShiftReport totals a shift's payments and cash movements.
+func buildShiftReport(db *gorm.DB, shift model.Shift) (*ShiftReport, error) {
+	report := &ShiftReport{
+		Type:           "X",
+		Shift:          shift,
+		PaymentMethods: []PaymentMethodTotal{},
+		CountedCash:    shift.CountedCash,
+		Variance:       shift.Variance,
+	}
+	if shift.Status == model.ShiftClosed {
+		report.Type = "Z"
+	}
+
+	if err := db.Model(&model.Transaction{}).Where("shift_id = ?", shift.ID).Count(&report.TransactionCount).Error; err != nil {
+		return nil, err
+	}
+
+	err := db.Model(&model.Payment{}).
+		Select("method, COUNT(*) AS payments, SUM(amount) AS amount").
+		Where("shift_id = ?", shift.ID).
+		Group("method").
+		Order("method").
+		Scan(&report.PaymentMethods).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, total := range report.PaymentMethods {
+		report.Revenue += total.Amount
+		if total.Method == model.PaymentCash {
+			report.CashSales = total.Amount
+		}
+	}
+	for _, movement := range shift.CashMovements {
+		switch movement.Type {
+		case model.CashPayIn:
+			report.PayIns += movement.Amount
+		case model.CashPayOut:
+			report.PayOuts += movement.Amount
+		}
+	}
+	report.ExpectedCash = shift.OpeningFloat + report.CashSales + report.PayIns - report.PayOuts
+
+	return report, nil
+}
+
+// resolveShift returns the cashier's open shift at the outlet for attaching a
+// sale or payment. It is nil when there is none and the outlet allows that.
+// The shift row is share-locked so it cannot close under the caller's transaction.
+func resolveShift(tx *gorm.DB, outlet model.Outlet, userID uuid.UUID) (*uuid.UUID, error) {
+	var shift model.Shift
+	err := tx.Clauses(clause.Locking{Strength: "SHARE"}).
+		Where("outlet_id = ? AND user_id = ? AND status = ?", outlet.ID, userID, model.ShiftOpen).
+		First(&shift).Error
+	if err == nil {
+		return &shift.ID, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	if outlet.RequireOpenShift {
+		return nil, ErrNoOpenShift
+	}
+	return nil, nil
+}
@@ -6,9 +6,10 @@ import (
 	"fmt"
 	"math/rand"
 	"strings"
-	"sync"
 	"time"
+	"venturo-core/internal/database"
 	"venturo-core/internal/model"
+	"venturo-core/pkg/events"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
@@ -16,42 +17,45 @@ import (
 )
 
 type TransactionService struct {
-	db *gorm.DB
-	wg *sync.WaitGroup
+	db       *gorm.DB
+	resolver *database.Resolver
 }
 
-func NewTransactionService(db *gorm.DB, wg *sync.WaitGroup) *TransactionService {
-	return &TransactionService{db: db, wg: wg}
+// NewTransactionService creates a new transaction service. Listings read from
+// the replicas, if any.
+func NewTransactionService(resolver *database.Resolver) *TransactionService {
+	return &TransactionService{db: resolver.Primary(), resolver: resolver}
 }
 
+// ErrAlreadyPaid is returned when paying a transaction that is already paid.
+var ErrAlreadyPaid = errors.New("transaction is already paid")
+
+// ErrCompositeLot is returned when a sale line names a lot of a composite
+// product, whose stock is held by its components.
+var ErrCompositeLot = errors.New("a composite product has no lots of its own")
+
 type CreateTransactionInput struct {
-	UserID   uuid.UUID
-	OutletID uuid.UUID
-	Items    []struct {
-		ProductID   uuid.UUID
-		ProductName string
-		Category    model.ProductCategory
-		Qty         int8
-		Price       int32
-	}
-	Note string
+	UserID     uuid.UUID
+	OutletID   uuid.UUID
+	CustomerID *uuid.UUID // Optional member making the purchase
+	Items      []CreateTransactionItem
+	Note       string
 }
 
-func (s *TransactionService) CreateTransaction(ctx context.Context, input CreateTransactionInput) (*model.Transaction, error) {
-	// 1. Validate stock availability for each item
-	for _, item := range input.Items {
-		currentStock, err := s.getCurrentStock(ctx, item.ProductID, input.OutletID)
-		if err != nil {
-			return nil, fmt.Errorf("failed to check stock for product %s: %w", item.ProductName, err)
-		}
-
-		if currentStock < int(item.Qty) {
-			return nil, fmt.Errorf("insufficient stock for product '%s': available %d, requested %d",
-				item.ProductName, currentStock, item.Qty)
-		}
-	}
+// CreateTransactionItem is a single line of a new transaction.
+// LotNumber is optional; without it stock is consumed first-expiry-first-out.
+type CreateTransactionItem struct {
+	ProductID   uuid.UUID
+	ProductName string
+	Category    model.ProductCategory
+	Qty         int8
+	Price       int32
+	Unit        string // Defaults to the product's sales unit
+	LotNumber   string
+}
 
-	// 2. Calculate total and prepare details
+func (s *TransactionService) CreateTransaction(ctx context.Context, input CreateTransactionInput) (*model.Transaction, error) {
+	// 1. Calculate total and prepare details
 	var total int64
 	var details []model.TransactionDetail
 	var itemNames []string
@@ -68,7 +72,7 @@ func (s *TransactionService) CreateTransaction(ctx context.Context, input Create
 		itemNames = append(itemNames, item.ProductName)
 	}
 
-	// 3. Generate invoice code and note
+	// 2. Generate invoice code and note
 	invoiceCode := generateInvoiceCode()
 	note := fmt.Sprintf("INV %s includes: %s. Additional notes: %s",
 		invoiceCode,
@@ -76,9 +80,10 @@ func (s *TransactionService) CreateTransaction(ctx context.Context, input Create
 		input.Note,
 	)
 
-	// 4. Create transaction object
+	// 3. Create transaction object
 	transaction := model.Transaction{
 		UserID:             input.UserID,
+		CustomerID:         input.CustomerID,
 		OutletID:           input.OutletID,
 		InvoiceCode:        invoiceCode,
 		Total:              total,
@@ -86,43 +91,231 @@ func (s *TransactionService) CreateTransaction(ctx context.Context, input Create
 		TransactionDetails: details, // GORM will auto-create these
 	}
 
-	// 5. Save transaction in a database transaction
+	// 4. Check stock, save the transaction and deduct inventory in one database transaction
 	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var outlet model.Outlet
+		if err := tx.First(&outlet, "id = ?", input.OutletID).Error; err != nil {
+			return errors.New("outlet not found")
+		}
+
+		if input.CustomerID != nil {
+			var customer model.Customer
+			if err := tx.Select("id").First(&customer, "id = ?", *input.CustomerID).Error; err != nil {
+				return errors.New("customer not found")
+			}
+		}
+
+		// Attach the sale to the cashier's open shift
+		shiftID, err := resolveShift(tx, outlet, input.UserID)
+		if err != nil {
+			return err
+		}
+		transaction.ShiftID = shiftID
+
+		// Composite products deduct their components instead of themselves
+		deductions, err := explodeItems(tx, input.Items)
+		if err != nil {
+			return err
+		}
+
+		if err := s.validateStock(tx, input.OutletID, deductions); err != nil {
+			return err
+		}
+
 		// Create the transaction
 		if err := tx.Create(&transaction).Error; err != nil {
 			return err
 		}
-		return nil
+
+		changes := make([]StockChange, 0, len(deductions))
+		for _, d := range deductions {
+			if err := consumeStock(tx, input.OutletID, &transaction.ID, d); err != nil {
+				return fmt.Errorf("failed to create inventory ledger for product %s: %w", d.ItemName, err)
+			}
+			changes = append(changes, StockChange{ProductID: d.ItemID, OutletID: input.OutletID, QuantityChange: -d.Qty})
+		}
+		if err := publishStockChanged(tx, model.LedgerEntrySale, &transaction.ID, changes); err != nil {
+			return err
+		}
+
+		items := make([]TransactionItem, 0, len(details))
+		for _, detail := range details {
+			items = append(items, TransactionItem{
+				ProductID:   detail.ProductID,
+				ProductName: detail.ProductName,
+				Qty:         detail.Qty,
+				Price:       detail.Price,
+			})
+		}
+		return events.Publish(tx, EventTransactionCreated, TransactionCreatedEvent{
+			TransactionID: transaction.ID,
+			OutletID:      transaction.OutletID,
+			CustomerID:    transaction.CustomerID,
+			InvoiceCode:   transaction.InvoiceCode,
+			Total:         transaction.Total,
+			Items:         items,
+			CreatedAt:     transaction.CreatedAt,
+		})
 	})
 
 	if err != nil {
 		return nil, err
 	}
 
-	// 6. Create inventory ledger entries asynchronously after successful transaction
-	s.wg.Add(1)
-	go func() {
-		defer s.wg.Done()
-		s.createInventoryLedgerEntries(transaction.ID, input.OutletID, input.Items)
-	}()
-
 	return &transaction, nil
 }
 
+// explodeItems turns sold lines into stock deductions in base units, replacing
+// every composite product with its bill-of-materials components. A line's unit
+// converts through the product's own factor, composite or not.
+func explodeItems(db *gorm.DB, items []CreateTransactionItem) ([]stockDeduction, error) {
+	productIDs := make([]uuid.UUID, 0, len(items))
+	for _, item := range items {
+		productIDs = append(productIDs, item.ProductID)
+	}
+
+	components, err := model.FindComponentsByProductIDs(db, productIDs)
+	if err != nil {
+		return nil, err
+	}
+	for _, bom := range components {
+		for _, component := range bom {
+			productIDs = append(productIDs, component.ComponentID)
+		}
+	}
+
+	products, err := model.FindProductsWithUnits(db, productIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	var deductions []stockDeduction
+	for _, item := range items {
+		product, ok := products[item.ProductID]
+		if !ok {
+			return nil, fmt.Errorf("product '%s' not found", item.ProductName)
+		}
+
+		unit := item.Unit
+		if unit == "" {
+			unit = product.SalesUnitOrBase()
+		}
+		factor, err := product.Factor(unit)
+		if err != nil {
+			return nil, err
+		}
+
+		bom, ok := components[item.ProductID]
+		if !ok {
+			deductions = append(deductions, stockDeduction{
+				ItemID:    item.ProductID,
+				ItemName:  item.ProductName,
+				Qty:       int(factor) * int(item.Qty),
+				Unit:      unit,
+				Factor:    factor,
+				LotNumber: item.LotNumber,
+			})
+			continue
+		}
+
+		if item.LotNumber != "" {
+			return nil, fmt.Errorf("%w: %s", ErrCompositeLot, item.ProductName)
+		}
+		// The bill of materials is per base unit of the composite.
+		for _, component := range bom {
+			componentProduct := products[component.ComponentID]
+			componentUnit := component.Unit
+			if componentUnit == "" {
+				componentUnit = componentProduct.BaseUnit
+			}
+			componentFactor, err := componentProduct.Factor(componentUnit)
+			if err != nil {
+				return nil, err
+			}
+
+			deductions = append(deductions, stockDeduction{
+				ItemID:   component.ComponentID,
+				ItemName: componentProduct.Name,
+				Qty:      int(componentFactor) * component.Quantity * int(factor) * int(item.Qty),
+				Unit:     componentUnit,
+				Factor:   componentFactor,
+			})
+		}
+	}
+	return deductions, nil
+}
+
+// validateStock checks that the outlet holds enough stock for every deduction.
+// The deducted products are locked first so concurrent sales cannot oversell them.
+func (s *TransactionService) validateStock(tx *gorm.DB, outletID uuid.UUID, deductions []stockDeduction) error {
+	required := make(map[uuid.UUID]int)
+	names := make(map[uuid.UUID]string)
+	var itemIDs []uuid.UUID
+	for _, d := range deductions {
+		if _, seen := required[d.ItemID]; !seen {
+			itemIDs = append(itemIDs, d.ItemID)
+		}
+		required[d.ItemID] += d.Qty
+		names[d.ItemID] = d.ItemName
+	}
+
+	var locked []model.Product
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id IN ?", itemIDs).Order("id").Find(&locked).Error; err != nil {
+		return err
+	}
+
+	// A deduction naming an expired lot draws on that lot alone, so it does
+	// not count against the sellable stock.
+	fromExpired := make(map[uuid.UUID]int)
+	for _, d := range deductions {
+		if d.LotNumber == "" {
+			continue
+		}
+
+		lotStock, expired, err := s.getLotStock(tx, d.ItemID, outletID, d.LotNumber)
+		if err != nil {
+			return fmt.Errorf("failed to check lot stock for product %s: %w", d.ItemName, err)
+		}
+		if lotStock < d.Qty {
+			return fmt.Errorf("insufficient stock in lot '%s' for product '%s': available %d, requested %d",
+				d.LotNumber, d.ItemName, lotStock, d.Qty)
+		}
+		if expired {
+			fromExpired[d.ItemID] += d.Qty
+		}
+	}
+
+	for _, itemID := range itemIDs {
+		sellableStock, err := s.getSellableStock(tx, itemID, outletID)
+		if err != nil {
+			return fmt.Errorf("failed to check stock for product %s: %w", names[itemID], err)
+		}
+
+		if requested := required[itemID] - fromExpired[itemID]; sellableStock < requested {
+			return fmt.Errorf("insufficient stock for product '%s': available %d, requested %d",
+				names[itemID], sellableStock, requested)
+		}
+	}
+	return nil
+}
+
 // Generate invoice code from random string
 func generateInvoiceCode() string {
 	rand.Seed(time.Now().UnixNano())
 	return fmt.Sprintf("INV-%d-%04d", time.Now().Year(), rand.Intn(10000))
 }
 
-// getCurrentStock calculates the current on-hand stock for a specific item and outlet
-// by summing all quantity_change entries in the inventory_ledgers table.
-func (s *TransactionService) getCurrentStock(ctx context.Context, itemID, outletID uuid.UUID) (int, error) {
+// getSellableStock calculates the on-hand stock for a specific item and outlet
+// that may be sold, by summing the quantity_change entries in the
+// inventory_ledgers table outside expired lots.
+func (s *TransactionService) getSellableStock(db *gorm.DB, itemID, outletID uuid.UUID) (int, error) {
 	var totalStock int64
 
-	err := s.db.WithContext(ctx).
+	err := db.
 		Model(&model.InventoryLedger{}).
 		Where("item_id = ? AND outlet_id = ?", itemID, outletID).
+		Where(model.NotExpired).
 		Select("COALESCE(SUM(quantity_change), 0)").
 		Row().
 		Scan(&totalStock)
@@ -134,114 +327,316 @@ func (s *TransactionService) getCurrentStock(ctx context.Context, itemID, outlet
 	return int(totalStock), nil
 }
 
-// createInventoryLedgerEntries creates negative inventory ledger entries for sold items
-// This runs asynchronously after a transaction is successfully created
-func (s *TransactionService) createInventoryLedgerEntries(transactionID, outletID uuid.UUID, items []struct {
-	ProductID   uuid.UUID
-	ProductName string
-	Category    model.ProductCategory
-	Qty         int8
-	Price       int32
-}) {
-	bgCtx := context.Background()
-	fmt.Printf("Starting inventory ledger creation for transaction %s\n", transactionID)
-
-	// Create inventory ledger entries in a database transaction
-	err := s.db.WithContext(bgCtx).Transaction(func(tx *gorm.DB) error {
-		for _, item := range items {
-			ledger := model.InventoryLedger{
-				ItemId:         item.ProductID,
-				OutletId:       outletID,
-				TransactionId:  &transactionID,
-				QuantityChange: -int(item.Qty), // Negative for stock-out
+// getLotStock calculates the on-hand stock of a single lot of an item at an
+// outlet and reports whether the lot has expired.
+func (s *TransactionService) getLotStock(db *gorm.DB, itemID, outletID uuid.UUID, lotNumber string) (int, bool, error) {
+	var lotStock int64
+	var expired bool
+
+	err := db.
+		Model(&model.InventoryLedger{}).
+		Where("item_id = ? AND outlet_id = ? AND lot_number = ?", itemID, outletID, lotNumber).
+		Select("COALESCE(SUM(quantity_change), 0), COALESCE(MAX(expiry_date < CURDATE()), FALSE)").
+		Row().
+		Scan(&lotStock, &expired)
+
+	if err != nil {
+		return 0, false, err
+	}
+
+	return int(lotStock), expired, nil
+}
+
+// PaymentInput is one tender used to pay a transaction.
+type PaymentInput struct {
+	Method string
+	Amount int64
+}
+
+// MarkAsPaidInput represents the data needed to pay a transaction. Without
+// payments the full total is taken in cash.
+type MarkAsPaidInput struct {
+	TransactionID uuid.UUID
+	UserID        uuid.UUID // Cashier taking the payment
+	Payments      []PaymentInput
+}
+
+// MarkAsPaid records the payments, marks the transaction paid and adds it to
+// the sales aggregates in the same DB transaction. Payments are attached to the
+// paying cashier's open shift. Paying an already paid transaction is rejected
+// so it is never counted twice. A points payment spends the customer's loyalty
+// points, and the rest of the total earns new ones.
+func (s *TransactionService) MarkAsPaid(ctx context.Context, input MarkAsPaidInput) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var transaction model.Transaction
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Preload("Outlet").
+			First(&transaction, "id = ?", input.TransactionID).Error
+		if err != nil {
+			return errors.New("transaction not found")
+		}
+		if transaction.IsPaid != nil && *transaction.IsPaid {
+			return ErrAlreadyPaid
+		}
+
+		payments := input.Payments
+		if len(payments) == 0 {
+			payments = []PaymentInput{{Method: model.PaymentCash, Amount: transaction.Total}}
+		}
+		var paid int64
+		for _, p := range payments {
+			paid += p.Amount
+		}
+		if paid != transaction.Total {
+			return fmt.Errorf("payments add up to %d but the transaction total is %d", paid, transaction.Total)
+		}
+
+		shiftID, err := resolveShift(tx, transaction.Outlet, input.UserID)
+		if err != nil {
+			return err
+		}
+
+		rules, err := loadLoyaltyRules(tx)
+		if err != nil {
+			return err
+		}
+
+		var redeemed int64
+		for _, p := range payments {
+			if p.Method != model.PaymentPoints {
+				continue
+			}
+			if transaction.CustomerID == nil {
+				return errors.New("points can only be redeemed on a transaction with a customer")
+			}
+			if redeemed == 0 {
+				if err := lockCustomer(tx, *transaction.CustomerID); err != nil {
+					return err
+				}
 			}
-			if err := tx.Create(&ledger).Error; err != nil {
-				return fmt.Errorf("failed to create inventory ledger for product %s: %w", item.ProductName, err)
+			if _, err := redeemPoints(tx, rules, *transaction.CustomerID, transaction.ID, p.Amount); err != nil {
+				return err
 			}
+			redeemed += p.Amount
 		}
-		return nil
+
+		for _, p := range payments {
+			payment := model.Payment{
+				TransactionID: transaction.ID,
+				ShiftID:       shiftID,
+				Method:        p.Method,
+				Amount:        p.Amount,
+			}
+			if err := tx.Create(&payment).Error; err != nil {
+				return err
+			}
+		}
+
+		isPaid := true
+		paidAt := time.Now()
+		transaction.IsPaid = &isPaid
+		transaction.PaidAt = &paidAt
+		if err := tx.Model(&transaction).Select("is_paid", "paid_at").Updates(&transaction).Error; err != nil {
+			return err
+		}
+
+		if err := applySale(tx, &transaction, transaction.Outlet.Location()); err != nil {
+			return err
+		}
+		if err := earnPoints(tx, rules, &transaction, redeemed); err != nil {
+			return err
+		}
+
+		paidPayments := make([]PaidPayment, 0, len(payments))
+		for _, p := range payments {
+			paidPayments = append(paidPayments, PaidPayment{Method: p.Method, Amount: p.Amount})
+		}
+		return events.Publish(tx, EventTransactionPaid, TransactionPaidEvent{
+			TransactionID: transaction.ID,
+			OutletID:      transaction.OutletID,
+			CustomerID:    transaction.CustomerID,
+			InvoiceCode:   transaction.InvoiceCode,
+			Total:         transaction.Total,
+			Payments:      paidPayments,
+			PaidAt:        paidAt,
+		})
 	})
+}
 
-	if err != nil {
-		// Log the error but don't fail the transaction since it's already completed
-		fmt.Printf("Failed to create inventory ledger entries for transaction %s: %v\n", transactionID, err)
-	} else {
-		fmt.Printf("Successfully created inventory ledger entries for transaction %s\n", transactionID)
-	}
+var (
+	// ErrNotPaid is returned when refunding a transaction that has not been paid.
+	ErrNotPaid = errors.New("transaction is not paid")
+	// ErrAlreadyRefunded is returned when refunding a transaction twice.
+	ErrAlreadyRefunded = errors.New("transaction is already refunded")
+)
+
+// RefundInput represents the data needed to refund a transaction.
+type RefundInput struct {
+	TransactionID uuid.UUID
+	UserID        uuid.UUID // Cashier giving the money back
 }
 
-func (s *TransactionService) MarkAsPaid(ctx context.Context, transactionId uuid.UUID) error {
+// Refund refunds a paid transaction in full: every payment is returned with
+// the same method on the refunding cashier's open shift, the sold stock goes
+// back to its lots, the customer's loyalty points are reversed and the sale is
+// taken back out of the sales aggregates on the day it was made.
+func (s *TransactionService) Refund(ctx context.Context, input RefundInput) (*model.Transaction, error) {
 	var transaction model.Transaction
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Preload("Outlet").
+			Preload("Payments").
+			First(&transaction, "id = ?", input.TransactionID).Error
+		if err != nil {
+			return errors.New("transaction not found")
+		}
+		if transaction.IsPaid == nil || !*transaction.IsPaid {
+			return ErrNotPaid
+		}
+		if transaction.RefundedAt != nil {
+			return ErrAlreadyRefunded
+		}
 
-	if err := s.db.WithContext(ctx).First(&transaction, "id = ?", transactionId).Error; err != nil {
-		return errors.New("transaction not found")
-	}
+		shiftID, err := resolveShift(tx, transaction.Outlet, input.UserID)
+		if err != nil {
+			return err
+		}
 
-	isPaid := true
-	transaction.IsPaid = &isPaid
-	if err := transaction.Save(s.db); err != nil {
-		return err
+		for _, p := range transaction.Payments {
+			refund := model.Payment{
+				TransactionID: transaction.ID,
+				ShiftID:       shiftID,
+				Method:        p.Method,
+				Amount:        -p.Amount,
+			}
+			if err := tx.Create(&refund).Error; err != nil {
+				return err
+			}
+		}
+
+		var sold []model.InventoryLedger
+		if err := tx.Where("transaction_id = ? AND entry_type = ?", transaction.ID, model.LedgerEntrySale).Find(&sold).Error; err != nil {
+			return err
+		}
+		changes := make([]StockChange, 0, len(sold))
+		for _, entry := range sold {
+			returned := model.InventoryLedger{
+				ItemId:          entry.ItemId,
+				OutletId:        entry.OutletId,
+				TransactionId:   &transaction.ID,
+				EntryType:       model.LedgerEntryRefund,
+				LotNumber:       entry.LotNumber,
+				ExpiryDate:      entry.ExpiryDate,
+				Unit:            entry.Unit,
+				EnteredQuantity: -entry.EnteredQuantity,
+				QuantityChange:  -entry.QuantityChange,
+				UnitCost:        entry.UnitCost,
+			}
+			if err := tx.Create(&returned).Error; err != nil {
+				return err
+			}
+			changes = append(changes, StockChange{ProductID: entry.ItemId, OutletID: entry.OutletId, QuantityChange: returned.QuantityChange})
+		}
+		if err := publishStockChanged(tx, model.LedgerEntryRefund, &transaction.ID, changes); err != nil {
+			return err
+		}
+
+		if err := reversePoints(tx, &transaction); err != nil {
+			return err
+		}
+		if err := reverseSale(tx, &transaction, transaction.Outlet.Location()); err != nil {
+			return err
+		}
+
+		refundedAt := time.Now()
+		transaction.RefundedAt = &refundedAt
+		return tx.Model(&transaction).Select("refunded_at").Updates(&transaction).Error
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	const isPaidQuery = "is_paid = ?"
+	return &transaction, nil
+}
 
-	s.wg.Add(1)
-	go func() {
-		defer s.wg.Done()
-		bgCtx := context.Background()
+// ListTransactionsInput represents the filters of the transaction list.
+type ListTransactionsInput struct {
+	OutletID *uuid.UUID
+	IsPaid   *bool
+	From     *time.Time // Inclusive, on created_at
+	To       *time.Time // Exclusive
+	Page     int
+	Limit    int
+}
 
-		err := s.db.WithContext(bgCtx).Transaction(func(tx *gorm.DB) error {
-			var report model.TransactionReport
-			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&report, "id = ?", 1).Error; err != nil {
-				return nil
-			}
+func (input ListTransactionsInput) scope(db *gorm.DB) *gorm.DB {
+	if input.OutletID != nil {
+		db = db.Where("outlet_id = ?", *input.OutletID)
+	}
+	if input.IsPaid != nil {
+		db = db.Where("is_paid = ?", *input.IsPaid)
+	}
+	if input.From != nil {
+		db = db.Where("created_at >= ?", *input.From)
+	}
+	if input.To != nil {
+		db = db.Where("created_at < ?", *input.To)
+	}
+	return db
+}
 
-			var totalRevenue, totalUniqueCustomers uint64
-			var totalPaidTransactions int64
-			tx.Model(&model.Transaction{}).Where(isPaidQuery, true).Select("COALESCE(SUM(total), 0)").Row().Scan(&totalRevenue)
-			tx.Model(&model.Transaction{}).Where(isPaidQuery, true).Count(&totalPaidTransactions)
-			tx.Model(&model.Transaction{}).Where(isPaidQuery, true).Select("COUNT(DISTINCT user_id)").Row().Scan(&totalUniqueCustomers)
+// ListTransactions returns one page of transactions, newest first, with the total count.
+func (s *TransactionService) ListTransactions(ctx context.Context, input ListTransactionsInput) ([]model.Transaction, int64, error) {
+	reader := s.resolver.Reader(ctx)
 
-			var totalProductsSold uint64
-			tx.Model(&model.TransactionDetail{}).Joins("JOIN transactions ON transactions.id = transaction_details.transaction_id").
-				Where("transactions.is_paid = ?", true).Select("COALESCE(SUM(qty), 0)").Row().Scan(&totalProductsSold)
+	var total int64
+	if err := input.scope(reader.Model(&model.Transaction{})).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
 
-			type CategoryResult struct {
-				Category model.ProductCategory
-				Count    int64
-			}
+	transactions := []model.Transaction{}
+	err := input.scope(reader).
+		Preload("Outlet").
+		Preload("Payments").
+		Order("created_at DESC, id DESC").
+		Offset((input.Page - 1) * input.Limit).
+		Limit(input.Limit).
+		Find(&transactions).Error
+	if err != nil {
+		return nil, 0, err
+	}
 
-			var categoryResults []CategoryResult
-			tx.Model(&model.TransactionDetail{}).Joins("JOIN transactions ON transactions.id = transaction_details.transaction_id").
-				Where("transactions.is_paid = ?", true).Select("category, SUM(qty) as count").Group("category").Find(&categoryResults)
-
-			report.TotalRevenue = totalRevenue
-			report.TotalPaidTransactions = totalPaidTransactions
-			report.TotalProductsSold = totalProductsSold
-			report.TotalUniqueCustomers = totalUniqueCustomers
-			report.CategorySummary = make(model.CategorySummary)
-			for _, res := range categoryResults {
-				var categoryName string
-				switch res.Category {
-				case model.Goods:
-					categoryName = "Goods"
-				case model.Service:
-					categoryName = "Service"
-				case model.Subscription:
-					categoryName = "Subscription"
-				default:
-					categoryName = "Other"
-				}
-				report.CategorySummary[categoryName] = res.Count
-			}
+	return transactions, total, nil
+}
 
-			return report.Save(tx)
-		})
+// streamBatchSize is how many transactions StreamTransactions loads at a time.
+const streamBatchSize = 500
+
+// StreamTransactions calls emit for every transaction matching the filter,
+// oldest first, loading them in keyset-paginated batches.
+func (s *TransactionService) StreamTransactions(ctx context.Context, input ListTransactionsInput, emit func(model.Transaction) error) error {
+	reader := s.resolver.Reader(ctx)
+	var lastCreatedAt time.Time
+	var lastID uuid.UUID
+	for first := true; ; first = false {
+		query := input.scope(reader).Preload("Outlet")
+		if !first {
+			query = query.Where("(created_at, id) > (?, ?)", lastCreatedAt, lastID)
+		}
 
-		if err != nil {
-			fmt.Printf("Failed to update transaction report: %v\n", err)
+		var batch []model.Transaction
+		if err := query.Order("created_at, id").Limit(streamBatchSize).Find(&batch).Error; err != nil {
+			return err
 		}
-	}()
 
-	return nil
+		for _, transaction := range batch {
+			if err := emit(transaction); err != nil {
+				return err
+			}
+		}
+		if len(batch) < streamBatchSize {
+			return nil
+		}
+		lastCreatedAt, lastID = batch[len(batch)-1].CreatedAt, batch[len(batch)-1].ID
+	}
 }
@@ -0,0 +1,39 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"venturo-core/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// UnitService manages the units-of-measure catalog.
+type UnitService struct {
+	db *gorm.DB
+}
+
+// NewUnitService creates a new unit service.
+func NewUnitService(db *gorm.DB) *UnitService {
+	return &UnitService{db: db}
+}
+
+// GetAllUnits retrieves the whole units catalog.
+func (s *UnitService) GetAllUnits(ctx context.Context) ([]model.Unit, error) {
+	var unit model.Unit
+	return unit.FindAll(s.db.WithContext(ctx))
+}
+
+// CreateUnit adds a unit to the catalog.
+func (s *UnitService) CreateUnit(ctx context.Context, code, name string) (*model.Unit, error) {
+	var existing model.Unit
+	if err := s.db.WithContext(ctx).Where("code = ?", code).First(&existing).Error; err == nil {
+		return nil, errors.New("unit with this code already exists")
+	}
+
+	unit := model.Unit{Code: code, Name: name}
+	if err := unit.Save(s.db.WithContext(ctx)); err != nil {
+		return nil, err
+	}
+	return &unit, nil
+}
@@ -0,0 +1,399 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+	"venturo-core/internal/adapter/storage"
+	"venturo-core/internal/model"
+	"venturo-core/pkg/imaging"
+	"venturo-core/pkg/jobs"
+	"venturo-core/pkg/uploader"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// uploadTTL is how long a client has to send and complete an upload.
+	uploadTTL = time.Hour
+	// uploadExpiryGrace lets a completion that started just before expiry finish
+	// before the file is deleted.
+	uploadExpiryGrace = 5 * time.Minute
+
+	// uploadStagingDir is the staging subdirectory of chunked uploads until
+	// they are completed.
+	uploadStagingDir = "incoming"
+	// uploadPrefix is the storage folder of uploaded files awaiting processing.
+	uploadPrefix = "uploads"
+)
+
+// JobExpireUpload deletes the file of an upload that was never completed.
+const JobExpireUpload = "uploads.expire"
+
+var (
+	// ErrUploadNotPending is returned for an upload that was completed, failed or expired.
+	ErrUploadNotPending = errors.New("upload is no longer pending")
+	// ErrUploadNotChunked is returned when sending a chunk to a direct upload.
+	ErrUploadNotChunked = errors.New("upload is not chunked; PUT the file to its URL")
+	// ErrUploadOffset is returned for a chunk that does not continue where the last one ended.
+	ErrUploadOffset = errors.New("upload offset does not match the bytes received")
+	// ErrUploadTooLarge is returned for a chunk that goes past the declared size.
+	ErrUploadTooLarge = errors.New("chunk goes past the declared upload size")
+	// ErrUploadIncomplete is returned when completing an upload before the whole file arrived.
+	ErrUploadIncomplete = errors.New("upload has not received the whole file")
+	// ErrUploadMismatch is returned when the file differs from its declared size or checksum.
+	ErrUploadMismatch = errors.New("uploaded file does not match its declared size or checksum")
+)
+
+// UploadService hands out upload slots so large files bypass the request
+// body, then verifies and attaches them once the client confirms.
+type UploadService struct {
+	db             *gorm.DB
+	storageAdapter storage.StorageAdapter
+	uploader       *uploader.FileUploader
+	products       *ProductService
+	users          *UserService
+}
+
+// NewUploadService creates a new upload service. Completed uploads are
+// attached through the product and user services.
+func NewUploadService(db *gorm.DB, storageAdapter storage.StorageAdapter, stagingPath string, products *ProductService, users *UserService) *UploadService {
+	fileUploader := uploader.NewFileUploader(storageAdapter, filepath.Join(stagingPath, uploadStagingDir))
+	return &UploadService{db: db, storageAdapter: storageAdapter, uploader: fileUploader, products: products, users: users}
+}
+
+// RegisterJobs registers the service's background job handlers.
+func (s *UploadService) RegisterJobs(queue *jobs.Queue) {
+	queue.Register(JobExpireUpload, s.expireUpload)
+}
+
+// uploadExpiry is the payload of a JobExpireUpload job.
+type uploadExpiry struct {
+	UploadID uuid.UUID `json:"upload_id"`
+}
+
+// CreateUploadInput describes the file a client is about to upload.
+type CreateUploadInput struct {
+	Purpose     string
+	ProductID   *uuid.UUID // Required for product images; avatars belong to the caller
+	FileName    string
+	ContentType string
+	Size        int64
+	Checksum    string // Hex SHA-256 of the file
+}
+
+// UploadSlot tells the client where to send the file.
+type UploadSlot struct {
+	Upload *model.Upload `json:"upload"`
+	Method string        `json:"method"` // PUT for direct uploads, PATCH for chunked ones
+	URL    string        `json:"url"`    // Empty for chunked uploads, which go to the upload's own endpoint
+}
+
+// CreateUpload reserves an upload. When the storage driver can sign upload
+// URLs the client PUTs the file straight to storage; otherwise it sends the
+// file to the app in chunks.
+func (s *UploadService) CreateUpload(ctx context.Context, userID uuid.UUID, input CreateUploadInput) (*UploadSlot, error) {
+	if input.Size > imaging.DefaultLimits.MaxBytes {
+		return nil, imaging.ErrTooLarge
+	}
+
+	upload := model.Upload{
+		UserID:      userID,
+		Purpose:     input.Purpose,
+		FileName:    filepath.Base(input.FileName),
+		ContentType: input.ContentType,
+		Size:        input.Size,
+		Checksum:    strings.ToLower(input.Checksum),
+		Status:      model.UploadPending,
+		ExpiresAt:   time.Now().Add(uploadTTL),
+	}
+
+	switch input.Purpose {
+	case model.UploadPurposeAvatar:
+		upload.TargetID = userID
+	case model.UploadPurposeProductImage:
+		if input.ProductID == nil {
+			return nil, errors.New("product_id is required for product images")
+		}
+		var product model.Product
+		if err := s.db.WithContext(ctx).Select("id").First(&product, "id = ?", *input.ProductID).Error; err != nil {
+			return nil, errors.New("product not found")
+		}
+		upload.TargetID = product.ID
+	default:
+		return nil, fmt.Errorf("unknown upload purpose %q", input.Purpose)
+	}
+
+	slot := UploadSlot{Upload: &upload}
+	objectKey := path.Join(uploadPrefix, uuid.NewString())
+	signedURL, err := s.storageAdapter.SignedUploadURL(ctx, objectKey, uploadTTL)
+	switch {
+	case err == nil:
+		upload.Mode = model.UploadModeDirect
+		upload.ObjectKey = objectKey
+		slot.Method = "PUT"
+		slot.URL = signedURL
+	case errors.Is(err, storage.ErrDirectUploadUnsupported):
+		upload.Mode = model.UploadModeChunked
+		upload.ObjectKey = uuid.NewString()
+		slot.Method = "PATCH"
+	default:
+		return nil, err
+	}
+
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&upload).Error; err != nil {
+			return err
+		}
+		return jobs.Enqueue(tx, JobExpireUpload, uploadExpiry{UploadID: upload.ID}, jobs.Delay(uploadTTL+uploadExpiryGrace))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &slot, nil
+}
+
+// GetUpload returns one of the user's uploads.
+func (s *UploadService) GetUpload(ctx context.Context, userID, id uuid.UUID) (*model.Upload, error) {
+	var upload model.Upload
+	if err := s.db.WithContext(ctx).First(&upload, "id = ? AND user_id = ?", id, userID).Error; err != nil {
+		return nil, errors.New("upload not found")
+	}
+	return &upload, nil
+}
+
+// AppendChunk stores the next piece of a chunked upload. offset must equal the
+// bytes received so far; resending a chunk after an interruption overwrites it.
+func (s *UploadService) AppendChunk(ctx context.Context, userID, id uuid.UUID, offset int64, data []byte) (*model.Upload, error) {
+	var upload model.Upload
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			First(&upload, "id = ? AND user_id = ?", id, userID).Error
+		if err != nil {
+			return errors.New("upload not found")
+		}
+		if upload.Mode != model.UploadModeChunked {
+			return ErrUploadNotChunked
+		}
+		if upload.Status != model.UploadPending || time.Now().After(upload.ExpiresAt) {
+			return ErrUploadNotPending
+		}
+		if offset != upload.ReceivedBytes {
+			return ErrUploadOffset
+		}
+		if offset+int64(len(data)) > upload.Size {
+			return ErrUploadTooLarge
+		}
+
+		if err := s.uploader.AppendStaged(upload.ObjectKey, offset, data); err != nil {
+			return err
+		}
+		upload.ReceivedBytes = offset + int64(len(data))
+		return tx.Model(&upload).Update("received_bytes", upload.ReceivedBytes).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &upload, nil
+}
+
+// CompleteUpload verifies the uploaded file against its declared size and
+// checksum and attaches it to its product or user, whose image job processes
+// it. A file that fails verification is deleted and the upload marked failed.
+func (s *UploadService) CompleteUpload(ctx context.Context, userID, id uuid.UUID) (*model.Upload, error) {
+	upload, err := s.GetUpload(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if upload.Status != model.UploadPending || time.Now().After(upload.ExpiresAt) {
+		return nil, ErrUploadNotPending
+	}
+
+	storageKey, err := s.verify(ctx, upload)
+	if err != nil {
+		if errors.Is(err, ErrUploadMismatch) || errors.Is(err, imaging.ErrInvalidImage) {
+			s.fail(ctx, upload, err)
+		}
+		return nil, err
+	}
+
+	now := time.Now()
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&model.Upload{}).
+			Where("id = ? AND status = ?", upload.ID, model.UploadPending).
+			Updates(map[string]interface{}{"status": model.UploadCompleted, "completed_at": now})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrUploadNotPending
+		}
+
+		if upload.Purpose == model.UploadPurposeAvatar {
+			return s.users.AttachUploadedAvatar(tx, upload.TargetID, storageKey)
+		}
+		return s.products.AttachUploadedImage(tx, upload.TargetID, storageKey)
+	})
+	if err != nil {
+		if storageKey != upload.ObjectKey {
+			// The copy of a chunked upload is only ours while unattached.
+			deleteImageKeys(ctx, s.storageAdapter, []string{storageKey}, "uploadID", upload.ID)
+		}
+		return nil, err
+	}
+
+	if upload.Mode == model.UploadModeChunked {
+		s.uploader.RemoveStaged(upload.ObjectKey)
+	}
+	upload.Status = model.UploadCompleted
+	upload.CompletedAt = &now
+	return upload, nil
+}
+
+// verify checks an uploaded file and returns the storage key the image job
+// reads it from. A chunked upload is copied to storage once it checks out.
+func (s *UploadService) verify(ctx context.Context, upload *model.Upload) (string, error) {
+	if upload.Mode == model.UploadModeChunked {
+		if upload.ReceivedBytes != upload.Size {
+			return "", ErrUploadIncomplete
+		}
+		staged, err := s.uploader.OpenStaged(upload.ObjectKey)
+		if err != nil {
+			return "", err
+		}
+		defer staged.Close()
+		if err := verifyUploadedFile(staged, upload); err != nil {
+			return "", err
+		}
+
+		if _, err := staged.Seek(0, io.SeekStart); err != nil {
+			return "", err
+		}
+		storageKey := path.Join(uploadPrefix, uuid.NewString())
+		if _, err := s.storageAdapter.Upload(ctx, storageKey, staged, upload.Size, upload.ContentType); err != nil {
+			return "", err
+		}
+		return storageKey, nil
+	}
+
+	// The signed PUT does not cap the size, so check it before reading the object.
+	info, err := s.storageAdapter.Stat(ctx, upload.ObjectKey)
+	if errors.Is(err, storage.ErrObjectNotFound) {
+		return "", ErrUploadIncomplete
+	}
+	if err != nil {
+		return "", err
+	}
+	if info.Size != upload.Size {
+		return "", fmt.Errorf("%w: received %d bytes, expected %d", ErrUploadMismatch, info.Size, upload.Size)
+	}
+
+	object, _, err := s.storageAdapter.Open(ctx, upload.ObjectKey)
+	if errors.Is(err, storage.ErrObjectNotFound) {
+		return "", ErrUploadIncomplete
+	}
+	if err != nil {
+		return "", err
+	}
+	defer object.Close()
+	if err := verifyUploadedFile(object, upload); err != nil {
+		return "", err
+	}
+	return upload.ObjectKey, nil
+}
+
+// verifyUploadedFile reads a file, checking that it is an acceptable image of
+// the declared size and checksum. It stops one byte past the declared size.
+func verifyUploadedFile(r io.Reader, upload *model.Upload) error {
+	hash := sha256.New()
+	counter := &byteCounter{}
+	tee := io.TeeReader(io.LimitReader(r, upload.Size+1), io.MultiWriter(hash, counter))
+
+	if _, err := imaging.Check(tee, upload.Size, imaging.DefaultLimits); err != nil {
+		return err
+	}
+	// Check only reads the header; hash the rest.
+	if _, err := io.Copy(io.Discard, tee); err != nil {
+		return err
+	}
+
+	if counter.n != upload.Size {
+		return fmt.Errorf("%w: received %d bytes, expected %d", ErrUploadMismatch, counter.n, upload.Size)
+	}
+	if hex.EncodeToString(hash.Sum(nil)) != upload.Checksum {
+		return fmt.Errorf("%w: checksum differs", ErrUploadMismatch)
+	}
+	return nil
+}
+
+// byteCounter counts the bytes written to it.
+type byteCounter struct {
+	n int64
+}
+
+func (c *byteCounter) Write(p []byte) (int, error) {
+	c.n += int64(len(p))
+	return len(p), nil
+}
+
+// fail marks an upload that failed verification and deletes its file.
+func (s *UploadService) fail(ctx context.Context, upload *model.Upload, reason error) {
+	result := s.db.WithContext(ctx).Model(&model.Upload{}).
+		Where("id = ? AND status = ?", upload.ID, model.UploadPending).
+		Updates(map[string]interface{}{"status": model.UploadFailed, "failure": reason.Error()})
+	if result.Error != nil {
+		slog.Error("Failed to mark upload failed", "uploadID", upload.ID, "error", result.Error)
+		return
+	}
+	if result.RowsAffected > 0 {
+		s.removeFile(ctx, upload)
+	}
+}
+
+// removeFile deletes whatever the client uploaded.
+func (s *UploadService) removeFile(ctx context.Context, upload *model.Upload) {
+	if upload.Mode == model.UploadModeChunked {
+		s.uploader.RemoveStaged(upload.ObjectKey)
+		return
+	}
+	deleteImageKeys(ctx, s.storageAdapter, []string{upload.ObjectKey}, "uploadID", upload.ID)
+}
+
+// expireUpload handles JobExpireUpload.
+func (s *UploadService) expireUpload(ctx context.Context, job *jobs.Job) error {
+	var payload uploadExpiry
+	if err := job.Decode(&payload); err != nil {
+		return jobs.Permanent(err)
+	}
+
+	var upload model.Upload
+	err := s.db.WithContext(ctx).First(&upload, "id = ?", payload.UploadID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil // Removed along with its user
+	}
+	if err != nil {
+		return err
+	}
+
+	result := s.db.WithContext(ctx).Model(&model.Upload{}).
+		Where("id = ? AND status = ?", upload.ID, model.UploadPending).
+		Update("status", model.UploadExpired)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected > 0 {
+		s.removeFile(ctx, &upload)
+	}
+	return nil
+}
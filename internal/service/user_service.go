@@ -2,13 +2,13 @@ package service
 
 import (
 	"context"
-	"fmt"
+	"errors"
 	"mime/multipart"
-	"os"
+	"path"
 	"path/filepath"
-	"sync"
 	"venturo-core/internal/adapter/storage"
 	"venturo-core/internal/model"
+	"venturo-core/pkg/jobs"
 	"venturo-core/pkg/uploader"
 
 	"log/slog"
@@ -17,25 +17,37 @@ import (
 	"gorm.io/gorm"
 )
 
-// Define the temporary local storage path
-const tempUploadPath = "./public/uploads/avatars"
+// avatarStagingDir is the staging subdirectory of avatars awaiting processing.
+const avatarStagingDir = "avatars"
+
+// avatarPrefix is the storage folder of avatar renditions.
+const avatarPrefix = "avatars"
+
+// JobUploadAvatar renders a staged avatar and stores its variants.
+const JobUploadAvatar = "users.upload_avatar"
 
 type UserService struct {
-	db       *gorm.DB
-	uploader *uploader.FileUploader
-	wg       *sync.WaitGroup
+	db             *gorm.DB
+	uploader       *uploader.FileUploader
+	storageAdapter storage.StorageAdapter
 }
 
-func NewUserService(db *gorm.DB, wg *sync.WaitGroup) *UserService {
-	gcsAdapter := storage.NewGCSAdapter("your-gcs-bucket-name")
-	fileUploader := uploader.NewFileUploader(gcsAdapter, tempUploadPath)
+func NewUserService(db *gorm.DB, storageAdapter storage.StorageAdapter, stagingPath string) *UserService {
+	fileUploader := uploader.NewFileUploader(storageAdapter, filepath.Join(stagingPath, avatarStagingDir))
+	return &UserService{db: db, uploader: fileUploader, storageAdapter: storageAdapter}
+}
 
-	// Ensure the temporary upload directory exists
-	if err := os.MkdirAll(tempUploadPath, os.ModePerm); err != nil {
-		slog.Error("could not create temp upload directory", "error", err)
-		os.Exit(1)
-	}
-	return &UserService{db: db, uploader: fileUploader, wg: wg}
+// RegisterJobs registers the service's background job handlers.
+func (s *UserService) RegisterJobs(queue *jobs.Queue) {
+	queue.Register(JobUploadAvatar, s.uploadAvatar)
+}
+
+// avatarUpload is the payload of a JobUploadAvatar job.
+type avatarUpload struct {
+	UserID uuid.UUID `json:"user_id"`
+	imageSource
+	AvatarKey    string   `json:"avatar_key"`    // Storage prefix of the renditions
+	PreviousKeys []string `json:"previous_keys"` // The replaced avatar, deleted after a successful upload
 }
 
 // GetUserProfile retrieves a user's profile by their ID.
@@ -44,7 +56,8 @@ func (s *UserService) GetUserProfile(userID uuid.UUID) (*model.User, error) {
 	return user.FindByID(s.db, userID)
 }
 
-// UpdateUserProfile updates a user's profile data.
+// UpdateUserProfile updates a user's profile data. A new avatar is checked,
+// staged locally and processed into renditions by a background job.
 func (s *UserService) UpdateUserProfile(ctx context.Context, userID uuid.UUID, newName string, file *multipart.FileHeader) (*model.User, error) {
 	// First, find the user to ensure they exist.
 	user, err := s.GetUserProfile(userID)
@@ -52,45 +65,124 @@ func (s *UserService) UpdateUserProfile(ctx context.Context, userID uuid.UUID, n
 		return nil, err // User not found
 	}
 
+	var stagedName string
 	if file != nil {
-		// Generate a new unique filename
-		ext := filepath.Ext(file.Filename)
-		newFileName := fmt.Sprintf("%s%s", uuid.New().String(), ext)
-		user.AvatarURL = newFileName // Store only the filename
-		user.ImageStatus = "uploading"
+		stagedName = uuid.New().String() + filepath.Ext(file.Filename)
+		if err := s.uploader.StageImage(file, stagedName); err != nil {
+			return nil, err
+		}
 	}
 
 	// Update the user's name.
 	user.Name = newName
 
-	// Save the updated user record. GORM's Save() handles updates automatically.
-	if err := user.Save(s.db); err != nil {
+	// Save the user and queue the processing together.
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := user.Save(tx); err != nil {
+			return err
+		}
+		if file == nil {
+			return nil
+		}
+		return s.queueAvatar(tx, user, imageSource{ObjectName: stagedName})
+	})
+	if err != nil {
+		if file != nil {
+			s.uploader.RemoveStaged(stagedName)
+		}
 		return nil, err
 	}
 
-	if file != nil {
-		// Define the database logic in callbacks
-		onLocalUpload := func() {
-			user.ImageStatus = "local"
-			if err := user.Save(s.db); err != nil {
-				slog.Error("Error updating status to 'local' for user", "userID", userID, "error", err)
-			}
-		}
+	return user, nil
+}
+
+// AttachUploadedAvatar queues a directly uploaded object as a user's new
+// avatar. It runs in the caller's transaction; the object is deleted once the
+// job has processed it.
+func (s *UserService) AttachUploadedAvatar(tx *gorm.DB, userID uuid.UUID, storageKey string) error {
+	var user model.User
+	if err := tx.First(&user, "id = ?", userID).Error; err != nil {
+		return errors.New("user not found")
+	}
+	return s.queueAvatar(tx, &user, imageSource{StorageKey: storageKey})
+}
+
+// queueAvatar points a user at a new avatar and queues its processing. The
+// replaced avatar is deleted once the new one is stored.
+func (s *UserService) queueAvatar(tx *gorm.DB, user *model.User, source imageSource) error {
+	// Avatars stored before renditions kept their object name in avatar_url.
+	var legacyKey string
+	if user.AvatarKey == "" {
+		legacyKey = user.AvatarURL
+	}
+	previousKeys := imageKeys(user.AvatarVariants, legacyKey)
+
+	avatarKey := path.Join(avatarPrefix, uuid.New().String())
+	err := tx.Model(user).Updates(map[string]interface{}{
+		"avatar_key":   avatarKey,
+		"image_status": model.ImageStatusProcessing,
+	}).Error
+	if err != nil {
+		return err
+	}
+	user.AvatarKey = avatarKey
+	user.ImageStatus = model.ImageStatusProcessing
+
+	return jobs.Enqueue(tx, JobUploadAvatar, avatarUpload{
+		UserID:       user.ID,
+		imageSource:  source,
+		AvatarKey:    avatarKey,
+		PreviousKeys: previousKeys,
+	})
+}
+
+// uploadAvatar handles JobUploadAvatar. The user keeps their previous avatar
+// until the new one is ready; a rejected avatar is marked failed.
+func (s *UserService) uploadAvatar(ctx context.Context, job *jobs.Job) error {
+	var payload avatarUpload
+	if err := job.Decode(&payload); err != nil {
+		return jobs.Permanent(err)
+	}
 
-		onCloudUpload := func() {
-			user.ImageStatus = "cloud"
-			if err := user.Save(s.db); err != nil {
-				slog.Error("Error updating status to 'cloud' for user", "userID", userID, "error", err)
-			}
+	stored, err := uploadImage(ctx, s.uploader, s.storageAdapter, payload.imageSource, payload.AvatarKey)
+	if err != nil {
+		if rejectedImage(err) || job.LastAttempt() {
+			s.updateAvatar(ctx, payload, map[string]interface{}{"image_status": model.ImageStatusFailed})
+			removeImageSource(ctx, s.uploader, s.storageAdapter, payload.imageSource)
 		}
+		return imageJobError(err)
+	}
 
-		// Start the background process
-		s.wg.Add(1)
-		go func() {
-			defer s.wg.Done()
-			s.uploader.UploadAsync(file, user.AvatarURL, onLocalUpload, onCloudUpload)
-		}()
+	variants, avatarURL := storedImage(stored)
+	applied, err := s.updateAvatar(ctx, payload, map[string]interface{}{
+		"image_status":    model.ImageStatusReady,
+		"avatar_url":      avatarURL,
+		"avatar_variants": variants,
+	})
+	if err != nil {
+		return err
 	}
 
-	return user, nil
+	if !applied {
+		// The user changed their avatar again while this one was queued.
+		deleteImageKeys(ctx, s.storageAdapter, variants.Keys(), "userID", payload.UserID)
+	} else {
+		deleteImageKeys(ctx, s.storageAdapter, payload.PreviousKeys, "userID", payload.UserID)
+	}
+
+	removeImageSource(ctx, s.uploader, s.storageAdapter, payload.imageSource)
+	return nil
+}
+
+// updateAvatar updates the user only while the job's avatar is still theirs,
+// and reports whether it did.
+func (s *UserService) updateAvatar(ctx context.Context, payload avatarUpload, updates map[string]interface{}) (bool, error) {
+	result := s.db.WithContext(ctx).Model(&model.User{}).
+		Where("id = ? AND avatar_key = ?", payload.UserID, payload.AvatarKey).
+		Updates(updates)
+	if result.Error != nil {
+		slog.Error("Failed to update avatar status", "userID", payload.UserID, "error", result.Error)
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
 }
@@ -0,0 +1,604 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+	"venturo-core/internal/model"
+	"venturo-core/pkg/events"
+	"venturo-core/pkg/jobs"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// JobDeliverWebhook sends one webhook delivery.
+const JobDeliverWebhook = "webhooks.deliver"
+
+// Headers of every webhook request. The signature is the hex HMAC-SHA256 of
+// the timestamp, a dot and the raw body, keyed with the subscription secret
+// and prefixed with "sha256=".
+const (
+	WebhookEventHeader     = "X-Webhook-Event"
+	WebhookDeliveryHeader  = "X-Webhook-Delivery"
+	WebhookTimestampHeader = "X-Webhook-Timestamp"
+	WebhookSignatureHeader = "X-Webhook-Signature"
+)
+
+// Webhook delivery settings.
+const (
+	webhookTimeout       = 10 * time.Second
+	webhookMaxAttempts   = 8
+	webhookResponseLimit = 2048 // Bytes of the response body kept in the delivery log
+	webhookPingEvent     = "ping"
+)
+
+// WebhookEventTypes are the events a subscription can receive.
+var WebhookEventTypes = []string{
+	EventTransactionCreated,
+	EventTransactionPaid,
+	EventStockChanged,
+	EventLowStock,
+	EventProductCreated,
+	EventProductUpdated,
+}
+
+var (
+	// ErrUnknownEventType is returned when subscribing to an event that does not exist.
+	ErrUnknownEventType = errors.New("unknown event type")
+	// ErrDeliveryPending is returned when redelivering a delivery that is still being retried.
+	ErrDeliveryPending = errors.New("delivery is still pending")
+	// ErrWebhookURL is returned for an endpoint that is not a public HTTP(S) host.
+	ErrWebhookURL = errors.New("webhook URL must point to a public host")
+)
+
+// WebhookService manages webhook subscriptions and delivers events to them.
+// It is an events.Sink.
+type WebhookService struct {
+	db     *gorm.DB
+	client *http.Client
+}
+
+// NewWebhookService creates a new webhook service. Its client refuses to
+// connect to loopback, private and link-local addresses, so a subscription
+// cannot reach internal services, even through a redirect or a DNS record that
+// changed after it was checked.
+func NewWebhookService(db *gorm.DB) *WebhookService {
+	dialer := &net.Dialer{Timeout: webhookTimeout, Control: checkWebhookDial}
+	transport := &http.Transport{
+		DialContext:         dialer.DialContext,
+		TLSHandshakeTimeout: webhookTimeout,
+		MaxIdleConns:        100,
+		IdleConnTimeout:     90 * time.Second,
+	}
+	return &WebhookService{db: db, client: &http.Client{Timeout: webhookTimeout, Transport: transport}}
+}
+
+// RegisterJobs registers the service's background job handlers.
+func (s *WebhookService) RegisterJobs(queue *jobs.Queue) {
+	queue.Register(JobDeliverWebhook, s.deliverWebhook)
+}
+
+// webhookBody is the JSON body of every webhook request.
+type webhookBody struct {
+	ID        string          `json:"id"` // Event ID; the same across redeliveries
+	Type      string          `json:"type"`
+	CreatedAt time.Time       `json:"created_at"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// webhookDelivery is the payload of a JobDeliverWebhook job.
+type webhookDelivery struct {
+	DeliveryID uuid.UUID `json:"delivery_id"`
+}
+
+// WebhookInput holds a subscription's settings. On update, nil fields are
+// left as they are.
+type WebhookInput struct {
+	URL        *string
+	Secret     *string // Generated when empty on create
+	EventTypes []string
+	OutletID   *uuid.UUID
+	IsActive   *bool
+}
+
+// CreateWebhook registers a subscription.
+func (s *WebhookService) CreateWebhook(ctx context.Context, input WebhookInput) (*model.WebhookSubscription, error) {
+	subscription := model.WebhookSubscription{IsActive: true}
+	if input.URL != nil {
+		if err := checkWebhookURL(ctx, *input.URL); err != nil {
+			return nil, err
+		}
+		subscription.URL = *input.URL
+	}
+	if input.Secret != nil && *input.Secret != "" {
+		subscription.Secret = *input.Secret
+	} else {
+		secret, err := generateWebhookSecret()
+		if err != nil {
+			return nil, err
+		}
+		subscription.Secret = secret
+	}
+	if input.IsActive != nil {
+		subscription.IsActive = *input.IsActive
+	}
+
+	eventTypes, err := checkEventTypes(input.EventTypes)
+	if err != nil {
+		return nil, err
+	}
+	subscription.EventTypes = eventTypes
+
+	if input.OutletID != nil {
+		if err := s.checkOutlet(ctx, *input.OutletID); err != nil {
+			return nil, err
+		}
+		subscription.OutletID = input.OutletID
+	}
+
+	if err := s.db.WithContext(ctx).Create(&subscription).Error; err != nil {
+		return nil, err
+	}
+	return &subscription, nil
+}
+
+// UpdateWebhook changes a subscription.
+func (s *WebhookService) UpdateWebhook(ctx context.Context, id uuid.UUID, input WebhookInput) (*model.WebhookSubscription, error) {
+	subscription, err := s.GetWebhook(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if input.URL != nil {
+		if err := checkWebhookURL(ctx, *input.URL); err != nil {
+			return nil, err
+		}
+		subscription.URL = *input.URL
+	}
+	if input.Secret != nil && *input.Secret != "" {
+		subscription.Secret = *input.Secret
+	}
+	if input.IsActive != nil {
+		subscription.IsActive = *input.IsActive
+	}
+	if input.EventTypes != nil {
+		eventTypes, err := checkEventTypes(input.EventTypes)
+		if err != nil {
+			return nil, err
+		}
+		subscription.EventTypes = eventTypes
+	}
+	if input.OutletID != nil {
+		if err := s.checkOutlet(ctx, *input.OutletID); err != nil {
+			return nil, err
+		}
+		subscription.OutletID = input.OutletID
+	}
+
+	err = s.db.WithContext(ctx).Model(subscription).
+		Select("url", "secret", "event_types", "outlet_id", "is_active").
+		Updates(subscription).Error
+	if err != nil {
+		return nil, err
+	}
+	return subscription, nil
+}
+
+// ListWebhooks returns every subscription.
+func (s *WebhookService) ListWebhooks(ctx context.Context) ([]model.WebhookSubscription, error) {
+	subscriptions := []model.WebhookSubscription{}
+	err := s.db.WithContext(ctx).Order("created_at, id").Find(&subscriptions).Error
+	return subscriptions, err
+}
+
+// GetWebhook returns a subscription.
+func (s *WebhookService) GetWebhook(ctx context.Context, id uuid.UUID) (*model.WebhookSubscription, error) {
+	var subscription model.WebhookSubscription
+	if err := s.db.WithContext(ctx).First(&subscription, "id = ?", id).Error; err != nil {
+		return nil, errors.New("webhook not found")
+	}
+	return &subscription, nil
+}
+
+// DeleteWebhook removes a subscription and its delivery log.
+func (s *WebhookService) DeleteWebhook(ctx context.Context, id uuid.UUID) error {
+	result := s.db.WithContext(ctx).Delete(&model.WebhookSubscription{}, "id = ?", id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("webhook not found")
+	}
+	return nil
+}
+
+// ListDeliveries returns one page of a subscription's delivery log, newest first.
+func (s *WebhookService) ListDeliveries(ctx context.Context, id uuid.UUID, page, limit int) ([]model.WebhookDelivery, int64, error) {
+	if _, err := s.GetWebhook(ctx, id); err != nil {
+		return nil, 0, err
+	}
+
+	query := s.db.WithContext(ctx).Model(&model.WebhookDelivery{}).Where("subscription_id = ?", id)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	deliveries := []model.WebhookDelivery{}
+	err := query.Order("created_at DESC, id").Offset((page - 1) * limit).Limit(limit).Find(&deliveries).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return deliveries, total, nil
+}
+
+// Redeliver queues a finished delivery to be sent again with the same body.
+func (s *WebhookService) Redeliver(ctx context.Context, subscriptionID, deliveryID uuid.UUID) (*model.WebhookDelivery, error) {
+	var delivery model.WebhookDelivery
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			First(&delivery, "id = ? AND subscription_id = ?", deliveryID, subscriptionID).Error
+		if err != nil {
+			return errors.New("delivery not found")
+		}
+		if delivery.Status == model.WebhookDeliveryPending {
+			return ErrDeliveryPending
+		}
+
+		delivery.Status = model.WebhookDeliveryPending
+		if err := tx.Model(&delivery).Update("status", delivery.Status).Error; err != nil {
+			return err
+		}
+		return jobs.Enqueue(tx, JobDeliverWebhook, webhookDelivery{DeliveryID: delivery.ID}, jobs.MaxAttempts(webhookMaxAttempts))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+// Ping sends a test event to a subscription right away and returns the
+// logged result. Pings are not retried.
+func (s *WebhookService) Ping(ctx context.Context, id uuid.UUID) (*model.WebhookDelivery, error) {
+	subscription, err := s.GetWebhook(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(map[string]interface{}{"subscription_id": subscription.ID})
+	if err != nil {
+		return nil, err
+	}
+	body, err := json.Marshal(webhookBody{
+		ID:        uuid.NewString(),
+		Type:      webhookPingEvent,
+		CreatedAt: time.Now(),
+		Data:      data,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	delivery := model.WebhookDelivery{
+		SubscriptionID: subscription.ID,
+		EventType:      webhookPingEvent,
+		Payload:        string(body),
+		Status:         model.WebhookDeliveryPending,
+	}
+	if err := s.db.WithContext(ctx).Create(&delivery).Error; err != nil {
+		return nil, err
+	}
+
+	if err := s.send(ctx, subscription, &delivery); err != nil {
+		delivery.Status = model.WebhookDeliveryFailed
+		if err := s.db.WithContext(ctx).Model(&delivery).Update("status", delivery.Status).Error; err != nil {
+			return nil, err
+		}
+	}
+	return &delivery, nil
+}
+
+// Name implements the events.Sink interface.
+func (s *WebhookService) Name() string {
+	return "webhooks"
+}
+
+// Send implements the events.Sink interface. It logs a delivery for every
+// active subscription to the event and queues it. A subscription limited to
+// an outlet only receives events about that outlet; events without one, such
+// as catalog changes, only go to subscriptions for all outlets.
+func (s *WebhookService) Send(ctx context.Context, event *events.Event) error {
+	if !isWebhookEventType(event.Type) {
+		return nil
+	}
+
+	var scope struct {
+		OutletID *uuid.UUID `json:"outlet_id"`
+	}
+	if err := event.Decode(&scope); err != nil {
+		return jobs.Permanent(err)
+	}
+
+	query := s.db.WithContext(ctx).
+		Where("is_active = ? AND JSON_CONTAINS(event_types, JSON_QUOTE(?))", true, event.Type)
+	if scope.OutletID != nil {
+		query = query.Where("outlet_id IS NULL OR outlet_id = ?", *scope.OutletID)
+	} else {
+		query = query.Where("outlet_id IS NULL")
+	}
+
+	var subscriptions []model.WebhookSubscription
+	if err := query.Find(&subscriptions).Error; err != nil {
+		return err
+	}
+	if len(subscriptions) == 0 {
+		return nil
+	}
+
+	body, err := json.Marshal(webhookBody{
+		ID:        strconv.FormatUint(event.ID, 10),
+		Type:      event.Type,
+		CreatedAt: event.OccurredAt,
+		Data:      json.RawMessage(event.Payload),
+	})
+	if err != nil {
+		return jobs.Permanent(err)
+	}
+
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, subscription := range subscriptions {
+			delivery := model.WebhookDelivery{
+				SubscriptionID: subscription.ID,
+				EventID:        &event.ID,
+				EventType:      event.Type,
+				Payload:        string(body),
+				Status:         model.WebhookDeliveryPending,
+			}
+			// A retried Send must not queue the same event twice.
+			result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&delivery)
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				continue
+			}
+
+			err := jobs.Enqueue(tx, JobDeliverWebhook, webhookDelivery{DeliveryID: delivery.ID}, jobs.MaxAttempts(webhookMaxAttempts))
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// deliverWebhook handles JobDeliverWebhook. The delivery is marked failed when
+// the last attempt fails.
+func (s *WebhookService) deliverWebhook(ctx context.Context, job *jobs.Job) error {
+	var payload webhookDelivery
+	if err := job.Decode(&payload); err != nil {
+		return jobs.Permanent(err)
+	}
+
+	var delivery model.WebhookDelivery
+	err := s.db.WithContext(ctx).First(&delivery, "id = ?", payload.DeliveryID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil // The subscription was deleted
+	}
+	if err != nil {
+		return err
+	}
+	if delivery.Status != model.WebhookDeliveryPending {
+		return nil
+	}
+
+	subscription, err := s.GetWebhook(ctx, delivery.SubscriptionID)
+	if err != nil {
+		return nil // The subscription was deleted
+	}
+	if !subscription.IsActive {
+		delivery.Status = model.WebhookDeliveryFailed
+		delivery.LastError = "the subscription is inactive"
+		return s.db.WithContext(ctx).Model(&delivery).Select("status", "last_error").Updates(&delivery).Error
+	}
+
+	err = s.send(ctx, subscription, &delivery)
+	if err != nil && job.LastAttempt() {
+		delivery.Status = model.WebhookDeliveryFailed
+		if err := s.db.WithContext(ctx).Model(&delivery).Update("status", delivery.Status).Error; err != nil {
+			return err
+		}
+	}
+	return err
+}
+
+// send makes one signed delivery attempt and records its outcome. It returns
+// an error unless the endpoint answered with a 2xx status.
+func (s *WebhookService) send(ctx context.Context, subscription *model.WebhookSubscription, delivery *model.WebhookDelivery) error {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, subscription.URL, strings.NewReader(delivery.Payload))
+	if err != nil {
+		return s.recordAttempt(ctx, delivery, nil, "", 0, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "venturo-core-webhooks")
+	req.Header.Set(WebhookEventHeader, delivery.EventType)
+	req.Header.Set(WebhookDeliveryHeader, delivery.ID.String())
+	req.Header.Set(WebhookTimestampHeader, timestamp)
+	req.Header.Set(WebhookSignatureHeader, "sha256="+signWebhook(subscription.Secret, timestamp, delivery.Payload))
+
+	start := time.Now()
+	resp, err := s.client.Do(req)
+	duration := time.Since(start)
+	if err != nil {
+		return s.recordAttempt(ctx, delivery, nil, "", duration, err)
+	}
+	defer resp.Body.Close()
+
+	responseBody, _ := io.ReadAll(io.LimitReader(resp.Body, webhookResponseLimit))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		err = fmt.Errorf("endpoint answered %d", resp.StatusCode)
+	}
+	return s.recordAttempt(ctx, delivery, &resp.StatusCode, string(responseBody), duration, err)
+}
+
+// recordAttempt stores the result of a delivery attempt and returns sendErr.
+func (s *WebhookService) recordAttempt(ctx context.Context, delivery *model.WebhookDelivery, status *int, body string, duration time.Duration, sendErr error) error {
+	now := time.Now()
+	durationMs := int(duration.Milliseconds())
+	delivery.Attempts++
+	delivery.ResponseStatus = status
+	delivery.ResponseBody = strings.ToValidUTF8(body, "")
+	delivery.DurationMs = &durationMs
+	delivery.LastAttemptAt = &now
+	delivery.LastError = ""
+	if sendErr != nil {
+		delivery.LastError = sendErr.Error()
+	} else {
+		delivery.Status = model.WebhookDeliverySucceeded
+		delivery.DeliveredAt = &now
+	}
+
+	err := s.db.WithContext(ctx).Model(delivery).
+		Select("status", "attempts", "response_status", "response_body", "last_error", "duration_ms", "last_attempt_at", "delivered_at").
+		Updates(delivery).Error
+	if err != nil {
+		return err
+	}
+	return sendErr
+}
+
+// checkOutlet rejects an outlet that does not exist.
+func (s *WebhookService) checkOutlet(ctx context.Context, id uuid.UUID) error {
+	var outlet model.Outlet
+	if err := s.db.WithContext(ctx).Select("id").First(&outlet, "id = ?", id).Error; err != nil {
+		return errors.New("outlet not found")
+	}
+	return nil
+}
+
+// checkWebhookURL rejects an endpoint that is not HTTP(S) or whose host
+// resolves to an address the delivery client would refuse.
+func checkWebhookURL(ctx context.Context, rawURL string) error {
+	endpoint, err := url.Parse(rawURL)
+	if err != nil || (endpoint.Scheme != "http" && endpoint.Scheme != "https") || endpoint.Hostname() == "" {
+		return ErrWebhookURL
+	}
+
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, endpoint.Hostname())
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrWebhookURL, err)
+	}
+	for _, addr := range addrs {
+		if !isPublicIP(addr.IP) {
+			return fmt.Errorf("%w: %s resolves to %s", ErrWebhookURL, endpoint.Hostname(), addr.IP)
+		}
+	}
+	return nil
+}
+
+// checkWebhookDial is the dialer Control of the delivery client. It runs on
+// the resolved address of every connection, redirects included.
+func checkWebhookDial(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); ip == nil || !isPublicIP(ip) {
+		return fmt.Errorf("%w: refusing to connect to %s", ErrWebhookURL, host)
+	}
+	return nil
+}
+
+// nonPublicNetworks are the ranges isPublicIP rejects that net.IP has no
+// predicate for.
+var nonPublicNetworks = []*net.IPNet{
+	mustParseCIDR("0.0.0.0/8"),     // "This network"
+	mustParseCIDR("100.64.0.0/10"), // Carrier-grade NAT
+}
+
+// isPublicIP reports whether ip is a routable unicast address outside the
+// loopback, private, link-local and carrier-grade NAT ranges.
+func isPublicIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() {
+		return false
+	}
+	for _, network := range nonPublicNetworks {
+		if network.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// mustParseCIDR parses a CIDR constant.
+func mustParseCIDR(cidr string) *net.IPNet {
+	_, network, err := net.ParseCIDR(cidr)
+	if err != nil {
+		panic(err)
+	}
+	return network
+}
+
+// checkEventTypes rejects unknown event types and removes duplicates.
+func checkEventTypes(eventTypes []string) (model.WebhookEventTypes, error) {
+	if len(eventTypes) == 0 {
+		return nil, fmt.Errorf("%w: at least one event type is required", ErrUnknownEventType)
+	}
+
+	checked := model.WebhookEventTypes{}
+	for _, eventType := range eventTypes {
+		if !isWebhookEventType(eventType) {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownEventType, eventType)
+		}
+		if !checked.Contains(eventType) {
+			checked = append(checked, eventType)
+		}
+	}
+	return checked, nil
+}
+
+// isWebhookEventType reports whether subscriptions can receive an event type.
+func isWebhookEventType(eventType string) bool {
+	for _, known := range WebhookEventTypes {
+		if known == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// signWebhook returns the hex HMAC-SHA256 of a timestamp and body.
+func signWebhook(secret, timestamp, body string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "." + body))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// generateWebhookSecret returns a random signing secret.
+func generateWebhookSecret() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(secret), nil
+}
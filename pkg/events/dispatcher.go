@@ -0,0 +1,173 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+	"venturo-core/pkg/jobs"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// JobDeliver delivers one event to one subscriber.
+const JobDeliver = "events.deliver"
+
+// Dispatcher tuning.
+const (
+	dispatchInterval  = time.Second // Wait between polls when the outbox is empty
+	dispatchBatchSize = 100
+)
+
+// subscription is a named handler for one event type or AllEvents.
+type subscription struct {
+	eventType string
+	handler   Handler
+}
+
+// delivery is the payload of a JobDeliver job.
+type delivery struct {
+	EventID    uint64 `json:"event_id"`
+	Subscriber string `json:"subscriber"`
+}
+
+// Dispatcher moves events from the outbox to their subscribers.
+type Dispatcher struct {
+	db            *gorm.DB
+	subscriptions map[string]subscription
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewDispatcher creates a dispatcher whose deliveries run on queue. Subscribe
+// handlers before the queue and the dispatcher are started.
+func NewDispatcher(db *gorm.DB, queue *jobs.Queue) *Dispatcher {
+	d := &Dispatcher{
+		db:            db,
+		subscriptions: make(map[string]subscription),
+		stop:          make(chan struct{}),
+		done:          make(chan struct{}),
+	}
+	queue.Register(JobDeliver, d.deliver)
+	return d
+}
+
+// Subscribe registers a handler for an event type, or for AllEvents. The name
+// identifies the subscriber's queued deliveries, so it must be unique and
+// stay the same across releases.
+func (d *Dispatcher) Subscribe(eventType, name string, handler Handler) {
+	if _, ok := d.subscriptions[name]; ok {
+		panic(fmt.Sprintf("events: subscriber %q registered twice", name))
+	}
+	d.subscriptions[name] = subscription{eventType: eventType, handler: handler}
+}
+
+// AddSink subscribes a sink to every event.
+func (d *Dispatcher) AddSink(sink Sink) {
+	d.Subscribe(AllEvents, sink.Name(), sink.Send)
+}
+
+// Start launches the dispatch loop.
+func (d *Dispatcher) Start() {
+	go d.run()
+}
+
+// Shutdown stops the dispatch loop, or gives up when ctx expires. Events not
+// yet dispatched stay in the outbox.
+func (d *Dispatcher) Shutdown(ctx context.Context) error {
+	close(d.stop)
+	select {
+	case <-d.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// run dispatches outbox batches until the dispatcher is stopped.
+func (d *Dispatcher) run() {
+	defer close(d.done)
+
+	for {
+		select {
+		case <-d.stop:
+			return
+		default:
+		}
+
+		count, err := d.dispatch()
+		if err != nil {
+			slog.Error("Failed to dispatch events", "error", err)
+		}
+		if count == dispatchBatchSize {
+			continue // More events are probably waiting
+		}
+
+		select {
+		case <-d.stop:
+			return
+		case <-time.After(dispatchInterval):
+		}
+	}
+}
+
+// dispatch queues a delivery per matching subscriber for the oldest batch of
+// undispatched events and marks them dispatched, all in one DB transaction.
+func (d *Dispatcher) dispatch() (int, error) {
+	var batch []Event
+	err := d.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("dispatched_at IS NULL").
+			Order("id").
+			Limit(dispatchBatchSize).
+			Find(&batch).Error
+		if err != nil || len(batch) == 0 {
+			return err
+		}
+
+		ids := make([]uint64, 0, len(batch))
+		for _, event := range batch {
+			for name, sub := range d.subscriptions {
+				if sub.eventType != AllEvents && sub.eventType != event.Type {
+					continue
+				}
+				if err := jobs.Enqueue(tx, JobDeliver, delivery{EventID: event.ID, Subscriber: name}); err != nil {
+					return err
+				}
+			}
+			ids = append(ids, event.ID)
+		}
+
+		return tx.Model(&Event{}).Where("id IN ?", ids).Update("dispatched_at", time.Now()).Error
+	})
+	return len(batch), err
+}
+
+// deliver handles JobDeliver.
+func (d *Dispatcher) deliver(ctx context.Context, job *jobs.Job) error {
+	var payload delivery
+	if err := job.Decode(&payload); err != nil {
+		return jobs.Permanent(err)
+	}
+
+	sub, ok := d.subscriptions[payload.Subscriber]
+	if !ok {
+		// The subscriber was removed after the delivery was queued.
+		slog.Warn("Dropping event for unknown subscriber", "event_id", payload.EventID, "subscriber", payload.Subscriber)
+		return nil
+	}
+
+	var event Event
+	err := d.db.WithContext(ctx).First(&event, payload.EventID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return jobs.Permanent(err)
+	}
+	if err != nil {
+		return err
+	}
+
+	return sub.handler(ctx, &event)
+}
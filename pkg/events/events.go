@@ -0,0 +1,81 @@
+// Package events publishes domain events through a transactional outbox.
+//
+// Publish writes an event to the event_outbox table with the same *gorm.DB
+// (or transaction) as the state change it describes, so an event exists if and
+// only if the change was committed. The Dispatcher reads the outbox and hands
+// every event to each matching subscriber as a separate background job, which
+// gives at-least-once delivery with per-subscriber retries. Subscribers must
+// therefore tolerate duplicates, and events may arrive out of order.
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// AllEvents subscribes a handler to every event type.
+const AllEvents = "*"
+
+// Event is a recorded domain event.
+type Event struct {
+	ID           uint64     `gorm:"primaryKey" json:"id"`
+	Type         string     `gorm:"size:100;not null" json:"type"`
+	Payload      string     `gorm:"type:json;not null" json:"payload"`
+	OccurredAt   time.Time  `gorm:"not null" json:"occurred_at"`
+	DispatchedAt *time.Time `json:"dispatched_at"` // Set once deliveries to all subscribers are queued
+}
+
+// TableName overrides the table name used by Event to `event_outbox`.
+func (Event) TableName() string {
+	return "event_outbox"
+}
+
+// Decode unmarshals the event's payload into v.
+func (e *Event) Decode(v interface{}) error {
+	return json.Unmarshal([]byte(e.Payload), v)
+}
+
+// Publish records an event. Pass the transaction that makes the change so
+// both commit or roll back together.
+func Publish(db *gorm.DB, eventType string, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	event := Event{
+		Type:       eventType,
+		Payload:    string(data),
+		OccurredAt: time.Now(),
+	}
+	return db.Create(&event).Error
+}
+
+// Handler reacts to an event. Returning an error retries the delivery later.
+type Handler func(ctx context.Context, event *Event) error
+
+// Sink is an external destination that receives every event, e.g. a message
+// broker or webhook. Its name identifies its deliveries and must be unique
+// among subscribers.
+type Sink interface {
+	Name() string
+	Send(ctx context.Context, event *Event) error
+}
+
+// LogSink writes every event to the application log.
+type LogSink struct{}
+
+// Name implements the Sink interface.
+func (LogSink) Name() string {
+	return "log"
+}
+
+// Send implements the Sink interface.
+func (LogSink) Send(ctx context.Context, event *Event) error {
+	slog.Info("Domain event", "event_id", event.ID, "type", event.Type, "occurred_at", event.OccurredAt, "payload", json.RawMessage(event.Payload))
+	return nil
+}
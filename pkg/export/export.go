@@ -0,0 +1,110 @@
+// Package export writes tabular data as CSV or XLSX, one row at a time, so
+// large exports can be streamed without holding the whole file in memory.
+package export
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+)
+
+// Export formats.
+const (
+	FormatCSV  = "csv"
+	FormatXLSX = "xlsx"
+)
+
+// Content types of the export formats.
+const (
+	MIMECSV  = "text/csv"
+	MIMEXLSX = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+)
+
+// FlushWriter is the destination of an export, typically a buffered response body.
+type FlushWriter interface {
+	io.Writer
+	Flush() error
+}
+
+// Cell is one exported value. Text is the localized rendering; numeric cells
+// also carry the machine value so spreadsheets can calculate with them.
+type Cell struct {
+	Text    string
+	Number  float64
+	Numeric bool
+}
+
+// Text returns a plain text cell.
+func Text(s string) Cell {
+	return Cell{Text: s}
+}
+
+// Writer writes rows in one export format.
+type Writer interface {
+	// WriteRow appends a row. Rows may have different lengths.
+	WriteRow(cells ...Cell) error
+	// Flush pushes buffered rows to the client.
+	Flush() error
+	// Close finishes the file and flushes it.
+	Close() error
+}
+
+// NewWriter returns a writer for format, which must be FormatCSV or FormatXLSX.
+// separator is the CSV field separator; sheet names the XLSX worksheet.
+func NewWriter(format string, w FlushWriter, separator rune, sheet string) (Writer, error) {
+	if format == FormatXLSX {
+		return NewXLSXWriter(w, sheet)
+	}
+	return NewCSVWriter(w, separator)
+}
+
+// MIMEType returns the content type of format.
+func MIMEType(format string) string {
+	if format == FormatXLSX {
+		return MIMEXLSX
+	}
+	return MIMECSV + "; charset=utf-8"
+}
+
+type csvWriter struct {
+	dst FlushWriter
+	csv *csv.Writer
+}
+
+// NewCSVWriter returns a CSV writer. The file starts with a UTF-8 byte order
+// mark so spreadsheet applications do not misread non-ASCII text.
+func NewCSVWriter(w FlushWriter, separator rune) (Writer, error) {
+	if _, err := io.WriteString(w, "\ufeff"); err != nil {
+		return nil, err
+	}
+	cw := csv.NewWriter(w)
+	if separator != 0 {
+		cw.Comma = separator
+	}
+	return &csvWriter{dst: w, csv: cw}, nil
+}
+
+func (w *csvWriter) WriteRow(cells ...Cell) error {
+	record := make([]string, len(cells))
+	for i, cell := range cells {
+		record[i] = cell.Text
+	}
+	return w.csv.Write(record)
+}
+
+func (w *csvWriter) Flush() error {
+	w.csv.Flush()
+	if err := w.csv.Error(); err != nil {
+		return err
+	}
+	return w.dst.Flush()
+}
+
+func (w *csvWriter) Close() error {
+	return w.Flush()
+}
+
+// formatNumber renders a machine value for XLSX without exponent notation.
+func formatNumber(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
@@ -0,0 +1,40 @@
+package export
+
+// headers holds the column titles per language, keyed by the column's JSON name.
+var headers = map[string]map[string]string{
+	"item_name":            {"en": "Item", "id": "Barang"},
+	"outlet_name":          {"en": "Outlet", "id": "Outlet"},
+	"on_hand_quantity":     {"en": "On Hand (Base Unit)", "id": "Stok (Satuan Dasar)"},
+	"base_unit":            {"en": "Base Unit", "id": "Satuan Dasar"},
+	"quantity":             {"en": "Quantity", "id": "Jumlah"},
+	"unit":                 {"en": "Unit", "id": "Satuan"},
+	"lot_number":           {"en": "Lot Number", "id": "Nomor Lot"},
+	"expiry_date":          {"en": "Expiry Date", "id": "Tanggal Kedaluwarsa"},
+	"days_until_expiry":    {"en": "Days Until Expiry", "id": "Hari Menuju Kedaluwarsa"},
+	"average_unit_cost":    {"en": "Average Unit Cost", "id": "Harga Pokok Rata-rata"},
+	"value":                {"en": "Value", "id": "Nilai"},
+	"period_start":         {"en": "Period Start", "id": "Awal Periode"},
+	"revenue":              {"en": "Revenue", "id": "Pendapatan"},
+	"transaction_count":    {"en": "Transactions", "id": "Transaksi"},
+	"average_basket":       {"en": "Average Basket", "id": "Rata-rata Belanja"},
+	"items_sold":           {"en": "Items Sold", "id": "Barang Terjual"},
+	"product_name":         {"en": "Product", "id": "Produk"},
+	"quantity_sold":        {"en": "Quantity Sold", "id": "Jumlah Terjual"},
+	"last_sold_at":         {"en": "Last Sold", "id": "Terakhir Terjual"},
+	"days_since_last_sale": {"en": "Days Since Last Sale", "id": "Hari Sejak Penjualan Terakhir"},
+	"revenue_share":        {"en": "Revenue Share", "id": "Porsi Pendapatan"},
+	"cumulative_share":     {"en": "Cumulative Share", "id": "Porsi Kumulatif"},
+	"class":                {"en": "Class", "id": "Kelas"},
+	"invoice_code":         {"en": "Invoice", "id": "Faktur"},
+	"created_at":           {"en": "Date", "id": "Tanggal"},
+	"total":                {"en": "Total", "id": "Total"},
+	"is_paid":              {"en": "Paid", "id": "Lunas"},
+	"paid_at":              {"en": "Paid At", "id": "Waktu Bayar"},
+	"note":                 {"en": "Note", "id": "Catatan"},
+}
+
+// yesNo holds the rendering of booleans per language.
+var yesNo = map[string][2]string{
+	"en": {"No", "Yes"},
+	"id": {"Tidak", "Ya"},
+}
@@ -0,0 +1,167 @@
+package export
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Locale controls how headers are labelled and how money, numbers and dates
+// are rendered in an export.
+type Locale struct {
+	Tag            string // BCP 47 tag, e.g. id-ID
+	Language       string // Language of the column headers
+	Thousands      string
+	Decimal        string
+	CurrencySymbol string
+	DateLayout     string
+	DateTimeLayout string
+	CSVSeparator   rune // Semicolon where the decimal separator is a comma
+}
+
+// DefaultLocale is used when neither the request nor the outlet names a supported locale.
+const DefaultLocale = "en-US"
+
+var locales = map[string]Locale{
+	"en-US": {
+		Tag: "en-US", Language: "en", Thousands: ",", Decimal: ".", CurrencySymbol: "$",
+		DateLayout: "01/02/2006", DateTimeLayout: "01/02/2006 15:04", CSVSeparator: ',',
+	},
+	"en-GB": {
+		Tag: "en-GB", Language: "en", Thousands: ",", Decimal: ".", CurrencySymbol: "£",
+		DateLayout: "02/01/2006", DateTimeLayout: "02/01/2006 15:04", CSVSeparator: ',',
+	},
+	"id-ID": {
+		Tag: "id-ID", Language: "id", Thousands: ".", Decimal: ",", CurrencySymbol: "Rp",
+		DateLayout: "02/01/2006", DateTimeLayout: "02/01/2006 15.04", CSVSeparator: ';',
+	},
+}
+
+// LookupLocale returns the locale for tag, matching case-insensitively and
+// falling back from a bare language ("id") to its first region.
+func LookupLocale(tag string) (Locale, bool) {
+	tag = strings.TrimSpace(tag)
+	for key, locale := range locales {
+		if strings.EqualFold(key, tag) {
+			return locale, true
+		}
+	}
+	for _, key := range []string{"en-US", "id-ID", "en-GB"} {
+		if strings.EqualFold(locales[key].Language, tag) {
+			return locales[key], true
+		}
+	}
+	return Locale{}, false
+}
+
+// LocaleOrDefault returns the locale for tag or DefaultLocale when tag is unsupported.
+func LocaleOrDefault(tag string) Locale {
+	if locale, ok := LookupLocale(tag); ok {
+		return locale
+	}
+	return locales[DefaultLocale]
+}
+
+// Header returns the column title for key in the locale's language, or key
+// itself when there is no translation.
+func (l Locale) Header(key string) string {
+	if titles, ok := headers[key]; ok {
+		if title, ok := titles[l.Language]; ok {
+			return title
+		}
+		return titles["en"]
+	}
+	return key
+}
+
+// Headers returns a header row for keys.
+func (l Locale) Headers(keys ...string) []Cell {
+	cells := make([]Cell, len(keys))
+	for i, key := range keys {
+		cells[i] = Text(l.Header(key))
+	}
+	return cells
+}
+
+// Money renders a whole-currency amount with the currency symbol.
+func (l Locale) Money(amount int64) Cell {
+	return Cell{Text: l.CurrencySymbol + " " + l.group(amount), Number: float64(amount), Numeric: true}
+}
+
+// Integer renders a whole number with thousands separators.
+func (l Locale) Integer(v int64) Cell {
+	return Cell{Text: l.group(v), Number: float64(v), Numeric: true}
+}
+
+// Number renders v rounded to decimals places.
+func (l Locale) Number(v float64, decimals int) Cell {
+	scale := math.Pow(10, float64(decimals))
+	rounded := math.Round(v*scale) / scale
+
+	whole := int64(rounded)
+	text := l.group(whole)
+	if decimals > 0 {
+		fraction := strconv.FormatFloat(math.Abs(rounded-float64(whole)), 'f', decimals, 64)
+		text += l.Decimal + fraction[2:]
+		if whole == 0 && rounded < 0 {
+			text = "-" + text
+		}
+	}
+	return Cell{Text: text, Number: rounded, Numeric: true}
+}
+
+// Percent renders a share between 0 and 1 as a percentage.
+func (l Locale) Percent(share float64) Cell {
+	cell := l.Number(share*100, 2)
+	cell.Text += "%"
+	cell.Number = share
+	return cell
+}
+
+// Date renders the calendar date of t.
+func (l Locale) Date(t time.Time) Cell {
+	return Text(t.Format(l.DateLayout))
+}
+
+// DateTime renders t with minutes.
+func (l Locale) DateTime(t time.Time) Cell {
+	return Text(t.Format(l.DateTimeLayout))
+}
+
+// group formats v with the locale's thousands separator.
+func (l Locale) group(v int64) string {
+	digits := strconv.FormatInt(v, 10)
+	sign := ""
+	if v < 0 {
+		sign, digits = "-", digits[1:]
+	}
+
+	var b strings.Builder
+	for i, d := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			b.WriteString(l.Thousands)
+		}
+		b.WriteRune(d)
+	}
+	return sign + b.String()
+}
+
+// Bool renders v as yes or no in the locale's language.
+func (l Locale) Bool(v bool) Cell {
+	words, ok := yesNo[l.Language]
+	if !ok {
+		words = yesNo["en"]
+	}
+	if v {
+		return Text(words[1])
+	}
+	return Text(words[0])
+}
+
+// MoneyDecimal renders a fractional amount, such as a unit cost, with the currency symbol.
+func (l Locale) MoneyDecimal(amount float64, decimals int) Cell {
+	cell := l.Number(amount, decimals)
+	cell.Text = l.CurrencySymbol + " " + cell.Text
+	return cell
+}
@@ -0,0 +1,137 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strings"
+)
+
+// The package parts written before the worksheet. They never change, so the
+// worksheet can be streamed as the last entry of the archive.
+var xlsxParts = []struct {
+	name    string
+	content string
+}{
+	{"[Content_Types].xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types"><Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/><Default Extension="xml" ContentType="application/xml"/><Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/><Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/></Types>`},
+	{"_rels/.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/></Relationships>`},
+	{"xl/_rels/workbook.xml.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/></Relationships>`},
+}
+
+const xlsxWorkbook = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets><sheet name="%s" sheetId="1" r:id="rId1"/></sheets></workbook>`
+
+const (
+	xlsxSheetStart = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`
+	xlsxSheetEnd = `</sheetData></worksheet>`
+)
+
+// maxSheetName is the longest worksheet name spreadsheet applications accept.
+const maxSheetName = 31
+
+type xlsxWriter struct {
+	dst   FlushWriter
+	zip   *zip.Writer
+	sheet io.Writer
+	row   bytes.Buffer
+}
+
+// NewXLSXWriter returns a writer producing a single-sheet workbook. Text is
+// stored inline, so no shared string table has to be kept in memory.
+func NewXLSXWriter(w FlushWriter, sheet string) (Writer, error) {
+	zw := zip.NewWriter(w)
+	for _, part := range xlsxParts {
+		if err := writeZipEntry(zw, part.name, part.content); err != nil {
+			return nil, err
+		}
+	}
+
+	workbook := strings.Replace(xlsxWorkbook, "%s", escapeXML(sheetName(sheet)), 1)
+	if err := writeZipEntry(zw, "xl/workbook.xml", workbook); err != nil {
+		return nil, err
+	}
+
+	sheetWriter, err := zw.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := io.WriteString(sheetWriter, xlsxSheetStart); err != nil {
+		return nil, err
+	}
+
+	return &xlsxWriter{dst: w, zip: zw, sheet: sheetWriter}, nil
+}
+
+func (w *xlsxWriter) WriteRow(cells ...Cell) error {
+	w.row.Reset()
+	w.row.WriteString("<row>")
+	for _, cell := range cells {
+		if cell.Numeric {
+			w.row.WriteString("<c><v>")
+			w.row.WriteString(formatNumber(cell.Number))
+			w.row.WriteString("</v></c>")
+			continue
+		}
+		w.row.WriteString(`<c t="inlineStr"><is><t xml:space="preserve">`)
+		xml.EscapeText(&w.row, []byte(cell.Text))
+		w.row.WriteString("</t></is></c>")
+	}
+	w.row.WriteString("</row>")
+
+	_, err := w.sheet.Write(w.row.Bytes())
+	return err
+}
+
+func (w *xlsxWriter) Flush() error {
+	if err := w.zip.Flush(); err != nil {
+		return err
+	}
+	return w.dst.Flush()
+}
+
+func (w *xlsxWriter) Close() error {
+	if _, err := io.WriteString(w.sheet, xlsxSheetEnd); err != nil {
+		return err
+	}
+	if err := w.zip.Close(); err != nil {
+		return err
+	}
+	return w.dst.Flush()
+}
+
+func writeZipEntry(zw *zip.Writer, name, content string) error {
+	entry, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(entry, content)
+	return err
+}
+
+// sheetName trims name to what spreadsheet applications accept.
+func sheetName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`\/?*[]:`, r) {
+			return '-'
+		}
+		return r
+	}, name)
+	if name == "" {
+		return "Sheet1"
+	}
+	if runes := []rune(name); len(runes) > maxSheetName {
+		name = string(runes[:maxSheetName])
+	}
+	return name
+}
+
+func escapeXML(s string) string {
+	var buf bytes.Buffer
+	xml.EscapeText(&buf, []byte(s))
+	return buf.String()
+}
@@ -0,0 +1,238 @@
+// Package imaging validates uploaded images and renders resized variants.
+//
+// Only JPEG, PNG and WebP are accepted, recognized by their content rather
+// than their file name. Every variant is re-encoded from the decoded pixels,
+// which drops EXIF and any other metadata; the EXIF orientation of a JPEG is
+// applied first so photos keep their intended rotation.
+package imaging
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"net/http"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp" // Registers the WebP decoder
+)
+
+// Accepted formats, as named by image.DecodeConfig.
+const (
+	FormatJPEG = "jpeg"
+	FormatPNG  = "png"
+	FormatWebP = "webp"
+)
+
+// allowedTypes maps sniffed content types to accepted formats.
+var allowedTypes = map[string]string{
+	"image/jpeg": FormatJPEG,
+	"image/png":  FormatPNG,
+	"image/webp": FormatWebP,
+}
+
+// jpegQuality is used for every JPEG rendition.
+const jpegQuality = 85
+
+var (
+	// ErrInvalidImage is wrapped by every error caused by the image itself.
+	ErrInvalidImage = errors.New("invalid image")
+	// ErrUnsupportedFormat is returned for content other than JPEG, PNG or WebP.
+	ErrUnsupportedFormat = fmt.Errorf("%w: only JPEG, PNG and WebP are accepted", ErrInvalidImage)
+	// ErrTooLarge is returned for a file over Limits.MaxBytes.
+	ErrTooLarge = fmt.Errorf("%w: file is too large", ErrInvalidImage)
+	// ErrDimensions is returned for an image wider or taller than the limits.
+	ErrDimensions = fmt.Errorf("%w: dimensions are too large", ErrInvalidImage)
+)
+
+// Limits bound what Check and Process accept.
+type Limits struct {
+	MaxBytes  int64
+	MaxWidth  int
+	MaxHeight int
+}
+
+// DefaultLimits apply to every upload. Multipart uploads are further capped
+// by Fiber's 4 MB body limit; direct uploads may use the full size.
+var DefaultLimits = Limits{
+	MaxBytes:  20 << 20,
+	MaxWidth:  6000,
+	MaxHeight: 6000,
+}
+
+// Variant is a rendition size; MaxSize bounds its longest side. Smaller
+// images are never upscaled.
+type Variant struct {
+	Name    string
+	MaxSize int
+}
+
+// DefaultVariants are rendered for every uploaded image, smallest first.
+var DefaultVariants = []Variant{
+	{Name: "thumbnail", MaxSize: 150},
+	{Name: "medium", MaxSize: 600},
+	{Name: "large", MaxSize: 1200},
+}
+
+// Info describes a checked image.
+type Info struct {
+	Format string
+	Width  int
+	Height int
+}
+
+// Rendition is one encoded variant.
+type Rendition struct {
+	Variant     string
+	Data        []byte
+	ContentType string
+	Ext         string // With the leading dot
+	Width       int
+	Height      int
+}
+
+// Check sniffs the format and reads the dimensions from the image header
+// without decoding the pixels. size is the file size, or -1 when unknown.
+func Check(r io.Reader, size int64, limits Limits) (*Info, error) {
+	if size > limits.MaxBytes {
+		return nil, ErrTooLarge
+	}
+
+	br := bufio.NewReader(r)
+	head, err := br.Peek(512)
+	if err != nil && err != io.EOF && !errors.Is(err, bufio.ErrBufferFull) {
+		return nil, err
+	}
+	format, ok := allowedTypes[http.DetectContentType(head)]
+	if !ok {
+		return nil, ErrUnsupportedFormat
+	}
+
+	config, decoded, err := image.DecodeConfig(br)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidImage, err)
+	}
+	if decoded != format {
+		return nil, ErrUnsupportedFormat
+	}
+	if config.Width < 1 || config.Height < 1 || config.Width > limits.MaxWidth || config.Height > limits.MaxHeight {
+		return nil, fmt.Errorf("%w: %dx%d exceeds %dx%d", ErrDimensions, config.Width, config.Height, limits.MaxWidth, limits.MaxHeight)
+	}
+
+	return &Info{Format: format, Width: config.Width, Height: config.Height}, nil
+}
+
+// Process checks an image and renders every variant. PNG input, and WebP
+// input with transparency, produce PNG renditions; everything else is JPEG.
+func Process(r io.Reader, limits Limits, variants []Variant) ([]Rendition, error) {
+	data, err := io.ReadAll(io.LimitReader(r, limits.MaxBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	info, err := Check(bytes.NewReader(data), int64(len(data)), limits)
+	if err != nil {
+		return nil, err
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidImage, err)
+	}
+
+	orientation := 1
+	if info.Format == FormatJPEG {
+		orientation = jpegOrientation(data)
+	}
+
+	renditions := make([]Rendition, 0, len(variants))
+	for _, variant := range variants {
+		// Rotation does not change the longest side, so scale first and
+		// orient the smaller image.
+		img := orient(fit(src, variant.MaxSize), orientation)
+
+		var buf bytes.Buffer
+		rendition := Rendition{
+			Variant: variant.Name,
+			Width:   img.Bounds().Dx(),
+			Height:  img.Bounds().Dy(),
+		}
+		if info.Format == FormatPNG || !img.Opaque() {
+			err = png.Encode(&buf, img)
+			rendition.ContentType, rendition.Ext = "image/png", ".png"
+		} else {
+			err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: jpegQuality})
+			rendition.ContentType, rendition.Ext = "image/jpeg", ".jpg"
+		}
+		if err != nil {
+			return nil, err
+		}
+		rendition.Data = buf.Bytes()
+		renditions = append(renditions, rendition)
+	}
+	return renditions, nil
+}
+
+// fit scales src so its longest side is at most maxSize.
+func fit(src image.Image, maxSize int) *image.RGBA {
+	bounds := src.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width > maxSize || height > maxSize {
+		if width >= height {
+			height = max(1, height*maxSize/width)
+			width = maxSize
+		} else {
+			width = max(1, width*maxSize/height)
+			height = maxSize
+		}
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	if width == bounds.Dx() && height == bounds.Dy() {
+		draw.Draw(dst, dst.Bounds(), src, bounds.Min, draw.Src)
+	} else {
+		draw.CatmullRom.Scale(dst, dst.Bounds(), src, bounds, draw.Src, nil)
+	}
+	return dst
+}
+
+// orient applies an EXIF orientation (1-8) to an image.
+func orient(src *image.RGBA, orientation int) *image.RGBA {
+	if orientation <= 1 || orientation > 8 {
+		return src
+	}
+
+	width, height := src.Bounds().Dx(), src.Bounds().Dy()
+	dstWidth, dstHeight := width, height
+	if orientation >= 5 {
+		dstWidth, dstHeight = height, width
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, dstWidth, dstHeight))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			var dx, dy int
+			switch orientation {
+			case 2: // Mirrored horizontally
+				dx, dy = width-1-x, y
+			case 3: // Rotated 180°
+				dx, dy = width-1-x, height-1-y
+			case 4: // Mirrored vertically
+				dx, dy = x, height-1-y
+			case 5: // Transposed
+				dx, dy = y, x
+			case 6: // Needs a 90° clockwise turn
+				dx, dy = height-1-y, x
+			case 7: // Transversed
+				dx, dy = height-1-y, width-1-x
+			case 8: // Needs a 90° counter-clockwise turn
+				dx, dy = y, width-1-x
+			}
+			dst.SetRGBA(dx, dy, src.RGBAAt(x, y))
+		}
+	}
+	return dst
+}
@@ -0,0 +1,108 @@
+// Package jobs is a durable background job queue stored in the MySQL jobs table.
+//
+// Jobs are enqueued with the same *gorm.DB (or transaction) as the data they
+// act on, so a rolled-back request never leaves a job behind. Workers claim
+// jobs with SELECT ... FOR UPDATE SKIP LOCKED, retry failures with exponential
+// backoff and move jobs that keep failing to the dead state. A claimed job that
+// is not finished within the visibility timeout, e.g. because its worker
+// crashed, becomes available again.
+package jobs
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Job statuses.
+const (
+	StatusPending   = "pending"
+	StatusRunning   = "running"
+	StatusSucceeded = "succeeded"
+	StatusDead      = "dead" // Failed permanently or ran out of attempts
+)
+
+// DefaultMaxAttempts is how many times a job runs before it is dead-lettered.
+const DefaultMaxAttempts = 5
+
+// Job is a unit of background work.
+type Job struct {
+	ID          uint64     `gorm:"primaryKey" json:"id"`
+	Kind        string     `gorm:"size:100;not null" json:"kind"`
+	Payload     string     `gorm:"type:json;not null" json:"payload"`
+	Status      string     `gorm:"size:20;not null;default:'pending'" json:"status"`
+	Attempts    int        `gorm:"not null;default:0" json:"attempts"`
+	MaxAttempts int        `gorm:"not null" json:"max_attempts"`
+	RunAt       time.Time  `gorm:"not null" json:"run_at"` // Earliest time of the next attempt
+	LockedUntil *time.Time `json:"locked_until"`           // Visibility deadline of a running job
+	LockedBy    string     `gorm:"size:100;not null;default:''" json:"locked_by"`
+	LastError   string     `gorm:"type:text" json:"last_error"`
+	FinishedAt  *time.Time `json:"finished_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// Decode unmarshals the job's payload into v.
+func (j *Job) Decode(v interface{}) error {
+	return json.Unmarshal([]byte(j.Payload), v)
+}
+
+// LastAttempt reports whether a failure of the current run dead-letters the job.
+func (j *Job) LastAttempt() bool {
+	return j.Attempts >= j.MaxAttempts
+}
+
+// Option customizes an enqueued job.
+type Option func(*Job)
+
+// Delay postpones the first attempt.
+func Delay(d time.Duration) Option {
+	return func(j *Job) { j.RunAt = j.RunAt.Add(d) }
+}
+
+// MaxAttempts overrides DefaultMaxAttempts.
+func MaxAttempts(n int) Option {
+	return func(j *Job) { j.MaxAttempts = n }
+}
+
+// Enqueue adds a job of the given kind. Pass the transaction that writes the
+// job's data so both commit or roll back together.
+func Enqueue(db *gorm.DB, kind string, payload interface{}, opts ...Option) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	job := Job{
+		Kind:        kind,
+		Payload:     string(data),
+		Status:      StatusPending,
+		MaxAttempts: DefaultMaxAttempts,
+		RunAt:       time.Now(),
+	}
+	for _, opt := range opts {
+		opt(&job)
+	}
+	return db.Create(&job).Error
+}
+
+// permanentError marks a failure that retrying cannot fix.
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent wraps an error so the job is dead-lettered without further retries.
+func Permanent(err error) error {
+	return &permanentError{err: err}
+}
+
+// isPermanent reports whether err was wrapped with Permanent.
+func isPermanent(err error) bool {
+	var permanent *permanentError
+	return errors.As(err, &permanent)
+}
@@ -0,0 +1,274 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand"
+	"os"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Handler processes one job. Its context expires at the visibility timeout.
+// Returning an error retries the job later, unless it is wrapped with Permanent.
+type Handler func(ctx context.Context, job *Job) error
+
+// Options tunes the worker pool.
+type Options struct {
+	Workers           int           // Jobs processed at the same time
+	PollInterval      time.Duration // Wait between polls when the queue is empty
+	VisibilityTimeout time.Duration // How long a claimed job may run before it is reclaimed
+	BaseBackoff       time.Duration // Delay before the first retry; doubles on every attempt
+	MaxBackoff        time.Duration
+}
+
+// DefaultOptions are used for every zero field of Options.
+var DefaultOptions = Options{
+	Workers:           4,
+	PollInterval:      time.Second,
+	VisibilityTimeout: 10 * time.Minute,
+	BaseBackoff:       10 * time.Second,
+	MaxBackoff:        time.Hour,
+}
+
+// lockGrace keeps a job locked a little past its handler's deadline so a
+// handler that just timed out can still record its result.
+const lockGrace = 30 * time.Second
+
+// Queue runs registered handlers for the jobs in the jobs table.
+type Queue struct {
+	db       *gorm.DB
+	opts     Options
+	handlers map[string]Handler
+	workerID string
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+// New creates a queue. Register handlers before calling Start.
+func New(db *gorm.DB, opts Options) *Queue {
+	if opts.Workers <= 0 {
+		opts.Workers = DefaultOptions.Workers
+	}
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = DefaultOptions.PollInterval
+	}
+	if opts.VisibilityTimeout <= 0 {
+		opts.VisibilityTimeout = DefaultOptions.VisibilityTimeout
+	}
+	if opts.BaseBackoff <= 0 {
+		opts.BaseBackoff = DefaultOptions.BaseBackoff
+	}
+	if opts.MaxBackoff <= 0 {
+		opts.MaxBackoff = DefaultOptions.MaxBackoff
+	}
+
+	host, _ := os.Hostname()
+	return &Queue{
+		db:       db,
+		opts:     opts,
+		handlers: make(map[string]Handler),
+		workerID: fmt.Sprintf("%s-%d", host, os.Getpid()),
+		stop:     make(chan struct{}),
+	}
+}
+
+// Register sets the handler of a job kind.
+func (q *Queue) Register(kind string, handler Handler) {
+	q.handlers[kind] = handler
+}
+
+// Start launches the workers.
+func (q *Queue) Start() {
+	for i := 0; i < q.opts.Workers; i++ {
+		q.wg.Add(1)
+		go q.work()
+	}
+	slog.Info("Job queue started", "workers", q.opts.Workers)
+}
+
+// Shutdown stops claiming jobs and waits for running ones to finish, or for
+// ctx to expire. Jobs left unfinished are picked up again after a restart.
+func (q *Queue) Shutdown(ctx context.Context) error {
+	close(q.stop)
+
+	done := make(chan struct{})
+	go func() {
+		q.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// work claims and runs jobs until the queue is stopped.
+func (q *Queue) work() {
+	defer q.wg.Done()
+
+	for {
+		select {
+		case <-q.stop:
+			return
+		default:
+		}
+
+		job, err := q.claim()
+		if err != nil {
+			slog.Error("Failed to claim job", "error", err)
+		}
+		if job == nil {
+			select {
+			case <-q.stop:
+				return
+			case <-time.After(q.opts.PollInterval):
+			}
+			continue
+		}
+
+		q.run(job)
+	}
+}
+
+// claim locks the next due job, skipping jobs other workers hold, and marks it
+// running. It returns nil when no job is due.
+func (q *Queue) claim() (*Job, error) {
+	kinds := make([]string, 0, len(q.handlers))
+	for kind := range q.handlers {
+		kinds = append(kinds, kind)
+	}
+	if len(kinds) == 0 {
+		return nil, nil
+	}
+
+	var claimed *Job
+	err := q.db.Transaction(func(tx *gorm.DB) error {
+		for {
+			now := time.Now()
+
+			var job Job
+			err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+				Where("kind IN ?", kinds).
+				Where("(status = ? AND run_at <= ?) OR (status = ? AND locked_until <= ?)", StatusPending, now, StatusRunning, now).
+				Order("run_at, id").
+				First(&job).Error
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+
+			// A job whose worker died during its last attempt is not run again.
+			if job.Status == StatusRunning && job.LastAttempt() {
+				err := tx.Model(&job).Updates(map[string]interface{}{
+					"status":       StatusDead,
+					"last_error":   "visibility timeout expired on the last attempt",
+					"locked_until": nil,
+					"finished_at":  now,
+				}).Error
+				if err != nil {
+					return err
+				}
+				slog.Error("Job dead-lettered", "job_id", job.ID, "kind", job.Kind, "error", "visibility timeout expired")
+				continue
+			}
+
+			lockedUntil := now.Add(q.opts.VisibilityTimeout + lockGrace)
+			job.Status = StatusRunning
+			job.Attempts++
+			job.LockedUntil = &lockedUntil
+			job.LockedBy = q.workerID
+			err = tx.Model(&job).Select("status", "attempts", "locked_until", "locked_by").Updates(&job).Error
+			if err != nil {
+				return err
+			}
+			claimed = &job
+			return nil
+		}
+	})
+	return claimed, err
+}
+
+// run executes a claimed job and records the outcome.
+func (q *Queue) run(job *Job) {
+	ctx, cancel := context.WithTimeout(context.Background(), q.opts.VisibilityTimeout)
+	defer cancel()
+
+	err := q.call(ctx, job)
+	if err == nil {
+		q.finish(job, map[string]interface{}{
+			"status":       StatusSucceeded,
+			"last_error":   "",
+			"locked_until": nil,
+			"finished_at":  time.Now(),
+		})
+		return
+	}
+
+	if isPermanent(err) || job.LastAttempt() {
+		slog.Error("Job dead-lettered", "job_id", job.ID, "kind", job.Kind, "attempts", job.Attempts, "error", err)
+		q.finish(job, map[string]interface{}{
+			"status":       StatusDead,
+			"last_error":   err.Error(),
+			"locked_until": nil,
+			"finished_at":  time.Now(),
+		})
+		return
+	}
+
+	retryAt := time.Now().Add(q.backoff(job.Attempts))
+	slog.Warn("Job failed, will retry", "job_id", job.ID, "kind", job.Kind, "attempts", job.Attempts, "retry_at", retryAt, "error", err)
+	q.finish(job, map[string]interface{}{
+		"status":       StatusPending,
+		"last_error":   err.Error(),
+		"locked_until": nil,
+		"run_at":       retryAt,
+	})
+}
+
+// call runs the job's handler, turning a panic into an error.
+func (q *Queue) call(ctx context.Context, job *Job) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return q.handlers[job.Kind](ctx, job)
+}
+
+// finish stores a job's outcome unless another worker reclaimed it meanwhile.
+func (q *Queue) finish(job *Job, updates map[string]interface{}) {
+	result := q.db.Model(&Job{}).
+		Where("id = ? AND locked_by = ? AND attempts = ?", job.ID, q.workerID, job.Attempts).
+		Updates(updates)
+	if result.Error != nil {
+		slog.Error("Failed to record job result", "job_id", job.ID, "kind", job.Kind, "error", result.Error)
+		return
+	}
+	if result.RowsAffected == 0 {
+		slog.Warn("Job was reclaimed before it finished", "job_id", job.ID, "kind", job.Kind)
+	}
+}
+
+// backoff is the delay before retrying after the given attempt: the base delay
+// doubled per attempt, capped, with the upper half randomized so failed jobs
+// do not retry in lockstep.
+func (q *Queue) backoff(attempt int) time.Duration {
+	delay := q.opts.MaxBackoff
+	if attempt < 32 {
+		delay = min(q.opts.BaseBackoff<<(attempt-1), q.opts.MaxBackoff)
+	}
+	half := delay / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
@@ -3,15 +3,23 @@ package logger
 import (
 	"log/slog"
 	"os"
+	"strings"
 )
 
-// InitLogger sets up our structured, JSON-based logger.
-func InitLogger() {
-	// Create a new JSON handler that writes to standard output.
-	// We can set a log level, e.g., slog.LevelDebug to see all logs.
-	handler := slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
-		Level: slog.LevelDebug,
-	})
+// InitLogger sets up our structured logger. level is one of debug, info, warn
+// or error; format is json or text.
+func InitLogger(level, format string) {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(level)); err != nil {
+		lvl = slog.LevelDebug
+	}
+	opts := &slog.HandlerOptions{Level: lvl}
+
+	// Create a handler that writes to standard output.
+	var handler slog.Handler = slog.NewJSONHandler(os.Stdout, opts)
+	if strings.EqualFold(format, "text") {
+		handler = slog.NewTextHandler(os.Stdout, opts)
+	}
 
 	// Set this handler as the default logger for the entire application.
 	slog.SetDefault(slog.New(handler))
@@ -10,7 +10,7 @@ import (
 type ApiResponse struct {
 	StatusCode int         `json:"status_code"`
 	Data       interface{} `json:"data,omitempty"`
-	Meta       *Meta       `json:"meta,omitempty"`
+	Meta       interface{} `json:"meta,omitempty"`
 	Errors     interface{} `json:"errors,omitempty"`
 }
 
@@ -22,6 +22,12 @@ type Meta struct {
 	TotalPages   int   `json:"total_pages"`
 }
 
+// CursorMeta holds the metadata of a cursor-paginated response.
+type CursorMeta struct {
+	NextCursor string `json:"next_cursor,omitempty"`
+	PerPage    int    `json:"per_page"`
+}
+
 // Success sends a standard success response.
 func Success(c *fiber.Ctx, statusCode int, data interface{}) error {
 	return c.Status(statusCode).JSON(ApiResponse{
@@ -45,6 +51,19 @@ func Pagination(c *fiber.Ctx, data interface{}, page, limit int, total int64) er
 	})
 }
 
+// CursorPagination sends a standard cursor-paginated response.
+// An empty nextCursor means this is the last page.
+func CursorPagination(c *fiber.Ctx, data interface{}, nextCursor string, limit int) error {
+	return c.Status(fiber.StatusOK).JSON(ApiResponse{
+		StatusCode: fiber.StatusOK,
+		Data:       data,
+		Meta: &CursorMeta{
+			NextCursor: nextCursor,
+			PerPage:    limit,
+		},
+	})
+}
+
 // Error sends a standard error response.
 func Error(c *fiber.Ctx, statusCode int, err error) error {
 	return c.Status(statusCode).JSON(ApiResponse{
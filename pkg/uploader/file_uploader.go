@@ -1,16 +1,20 @@
 package uploader
 
 import (
+	"bytes"
 	"context"
 	"io"
 	"log/slog"
 	"mime/multipart"
 	"os"
+	"path"
 	"path/filepath"
 	"venturo-core/internal/adapter/storage"
+	"venturo-core/pkg/imaging"
 )
 
-// FileUploader handles the low-level logic of saving and uploading files.
+// FileUploader stages request files on the local disk so a background job can
+// send them to storage after the request has finished.
 type FileUploader struct {
 	storageAdapter storage.StorageAdapter
 	localPath      string
@@ -18,7 +22,7 @@ type FileUploader struct {
 
 // NewFileUploader creates a new uploader instance.
 func NewFileUploader(storageAdapter storage.StorageAdapter, localPath string) *FileUploader {
-	// Ensure the temporary upload directory exists
+	// Ensure the staging directory exists
 	if err := os.MkdirAll(localPath, os.ModePerm); err != nil {
 		slog.Error("could not create temp upload directory", "error", err)
 		os.Exit(1)
@@ -26,41 +30,96 @@ func NewFileUploader(storageAdapter storage.StorageAdapter, localPath string) *F
 	return &FileUploader{storageAdapter: storageAdapter, localPath: localPath}
 }
 
-// UploadAsync handles the entire file processing pipeline in the background.
-func (u *FileUploader) UploadAsync(
-	file *multipart.FileHeader,
-	objectName string,
-	onLocalUploadSuccess func(),
-	onCloudUploadSuccess func(),
-) {
-	// --- Step 1: Save Locally ---
+// Stage saves an uploaded file under objectName in the staging directory.
+func (u *FileUploader) Stage(file *multipart.FileHeader, objectName string) error {
 	localFilePath := filepath.Join(u.localPath, objectName)
 	if err := saveToLocal(file, localFilePath); err != nil {
-		slog.Error("Error saving temp file", "file", objectName, "error", err)
-		return
+		return err
 	}
 	slog.Info("Successfully saved temp file", "path", localFilePath)
+	return nil
+}
+
+// StageImage checks that an uploaded file is an acceptable image before
+// staging it. Errors caused by the file wrap imaging.ErrInvalidImage.
+func (u *FileUploader) StageImage(file *multipart.FileHeader, objectName string) error {
+	src, err := file.Open()
+	if err != nil {
+		return err
+	}
+	_, err = imaging.Check(src, file.Size, imaging.DefaultLimits)
+	src.Close()
+	if err != nil {
+		return err
+	}
+	return u.Stage(file, objectName)
+}
+
+// StoredRendition is an image rendition sent to storage.
+type StoredRendition struct {
+	Variant string
+	Key     string
+	URL     string
+	Width   int
+	Height  int
+}
+
+// OpenStaged opens a staged file; the caller must close it.
+func (u *FileUploader) OpenStaged(objectName string) (*os.File, error) {
+	return os.Open(filepath.Join(u.localPath, objectName))
+}
 
-	// Execute the first callback
-	onLocalUploadSuccess()
+// AppendStaged writes data at offset in a staged file, dropping anything
+// already stored past offset, so an interrupted chunk can be sent again.
+func (u *FileUploader) AppendStaged(objectName string, offset int64, data []byte) error {
+	dst, err := os.OpenFile(filepath.Join(u.localPath, objectName), os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer dst.Close()
+
+	if err := dst.Truncate(offset); err != nil {
+		return err
+	}
+	if _, err := dst.WriteAt(data, offset); err != nil {
+		return err
+	}
+	return dst.Sync()
+}
 
-	// --- Step 2: Upload to Cloud ---
-	// In a real app, you would read the local file's contents to upload.
-	// We pass the header for placeholder simplicity.
-	if _, err := u.storageAdapter.Upload(context.Background(), file, objectName); err != nil {
-		slog.Error("Error uploading to cloud", "file", objectName, "error", err)
-		return // Don't continue if cloud upload fails
+// UploadImage renders the variants of an image and stores each one as
+// keyPrefix/<variant><ext>. Errors caused by the image wrap
+// imaging.ErrInvalidImage.
+func (u *FileUploader) UploadImage(ctx context.Context, src io.Reader, keyPrefix string) ([]StoredRendition, error) {
+	renditions, err := imaging.Process(src, imaging.DefaultLimits, imaging.DefaultVariants)
+	if err != nil {
+		return nil, err
 	}
-	slog.Info("Successfully uploaded to cloud", "file", objectName)
 
-	// Execute the second callback
-	onCloudUploadSuccess()
+	stored := make([]StoredRendition, 0, len(renditions))
+	for _, rendition := range renditions {
+		key := path.Join(keyPrefix, rendition.Variant+rendition.Ext)
+		url, err := u.storageAdapter.Upload(ctx, key, bytes.NewReader(rendition.Data), int64(len(rendition.Data)), rendition.ContentType)
+		if err != nil {
+			return nil, err
+		}
+		stored = append(stored, StoredRendition{
+			Variant: rendition.Variant,
+			Key:     key,
+			URL:     url,
+			Width:   rendition.Width,
+			Height:  rendition.Height,
+		})
+	}
+	slog.Info("Successfully uploaded image renditions to cloud", "prefix", keyPrefix)
+	return stored, nil
+}
 
-	// --- Step 3: Cleanup ---
-	if err := os.Remove(localFilePath); err != nil {
+// RemoveStaged deletes a staged file. A file that is already gone is not an error.
+func (u *FileUploader) RemoveStaged(objectName string) {
+	err := os.Remove(filepath.Join(u.localPath, objectName))
+	if err != nil && !os.IsNotExist(err) {
 		slog.Error("Error cleaning up temp file", "file", objectName, "error", err)
-	} else {
-		slog.Info("Successfully cleaned up temp file", "file", objectName)
 	}
 }
 
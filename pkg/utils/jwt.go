@@ -8,11 +8,11 @@ import (
 )
 
 // GenerateToken creates a new JWT for a given user.
-func GenerateToken(userID uuid.UUID, secretKey string) (string, error) {
+func GenerateToken(userID uuid.UUID, secretKey string, ttl time.Duration) (string, error) {
 	// Create the claims
 	claims := jwt.MapClaims{
 		"user_id": userID.String(),
-		"exp":     time.Now().Add(time.Hour * 72).Unix(), // Token expires in 72 hours
+		"exp":     time.Now().Add(ttl).Unix(),
 		"iat":     time.Now().Unix(),
 	}
 